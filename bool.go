@@ -2,7 +2,6 @@
 package tree
 
 import (
-	"slices"
 	"iter"
 	"strings"
 	"strconv"
@@ -110,7 +109,8 @@ func (tn BoolNode) Child() iter.Seq[*BoolNode] {
 }
 
 // Cleanup cleans the node and returns its children.
-// This function logically removes the node from the siblings and the parent.
+// This function logically removes the node from the siblings and the parent. The
+// returned children are detached from the node but keep their own children.
 //
 // Finally, it is not safe to use in goroutines as pointers may be dereferenced while another
 // goroutine is still using them.
@@ -126,25 +126,36 @@ func (tn *BoolNode) Cleanup() []*BoolNode {
 
 	var children []*BoolNode
 
-	for c := tn.FirstChild; c != nil; c = c.NextSibling {
+	for c := tn.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		c.Parent = nil
+		c.PrevSibling = nil
+		c.NextSibling = nil
+
 		children = append(children, c)
+		c = next
 	}
 
-	tn.FirstChild = nil
-	tn.LastChild = nil
-	tn.Parent = nil
-
+	parent := tn.Parent
 	prev := tn.PrevSibling
 	next := tn.NextSibling
 
 	if prev != nil {
 		prev.NextSibling = next
+	} else if parent != nil {
+		parent.FirstChild = next
 	}
 
 	if next != nil {
 		next.PrevSibling = prev
+	} else if parent != nil {
+		parent.LastChild = prev
 	}
 
+	tn.FirstChild = nil
+	tn.LastChild = nil
+	tn.Parent = nil
 	tn.PrevSibling = nil
 	tn.NextSibling = nil
 
@@ -232,8 +243,8 @@ func (tn *BoolNode) DeleteChild(target *BoolNode) []*BoolNode {
 		child.Parent = nil
 	}
 
-	tn.FirstChild = nil
-	tn.LastChild = nil
+	target.FirstChild = nil
+	target.LastChild = nil
 
 	return children
 }
@@ -244,7 +255,7 @@ func (tn *BoolNode) DeleteChild(target *BoolNode) []*BoolNode {
 //   - *BoolNode: The first child of the node.
 //   - bool: True if the node has a child, false otherwise.
 func (tn BoolNode) GetFirstChild() (*BoolNode, bool) {
-	return tn.FirstChild, tn.FirstChild == nil
+	return tn.FirstChild, tn.FirstChild != nil
 }
 
 // GetParent returns the parent of the node.
@@ -253,7 +264,7 @@ func (tn BoolNode) GetFirstChild() (*BoolNode, bool) {
 //   - *BoolNode: The parent of the node.
 //   - bool: True if the node has a parent, false otherwise.
 func (tn BoolNode) GetParent() (*BoolNode, bool) {
-	return tn.Parent, tn.Parent == nil
+	return tn.Parent, tn.Parent != nil
 }
 
 // LinkChildren is a method that links the children of the node.
@@ -301,6 +312,124 @@ func (tn *BoolNode) LinkChildren(children []*BoolNode) {
 	tn.FirstChild, tn.LastChild = valid_children[0], valid_children[len(valid_children)-1]
 }
 
+// Links returns the pointers of the node.
+//
+// Returns:
+//   - tree.Links[*BoolNode]: The pointers of the node.
+func (tn BoolNode) Links() tree.Links[*BoolNode] {
+	return tree.Links[*BoolNode]{
+		Parent:      tn.Parent,
+		FirstChild:  tn.FirstChild,
+		NextSibling: tn.NextSibling,
+		LastChild:   tn.LastChild,
+		PrevSibling: tn.PrevSibling,
+	}
+}
+
+// MarshalData encodes the payload of the node into JSON.
+//
+// Returns:
+//   - []byte: The JSON representation of the payload.
+//   - error: An error if the encoding fails.
+func (tn BoolNode) MarshalData() ([]byte, error) {
+	return tree.MarshalValue(tn.Data)
+}
+
+// UnmarshalData decodes the payload of the node from JSON.
+//
+// Parameters:
+//   - data: The JSON representation of the payload.
+//
+// Returns:
+//   - error: An error if the decoding fails.
+func (tn *BoolNode) UnmarshalData(data []byte) error {
+	if tn == nil {
+		return nil
+	}
+
+	return tree.UnmarshalValue(data, &tn.Data)
+}
+
+// would_cycle is a helper function that checks whether adding the target as a
+// child of the node would create a cycle; that is, whether the target is the node
+// itself or one of its ancestors.
+//
+// Parameters:
+//   - target: The child to check.
+//
+// Returns:
+//   - bool: True if a cycle would be created, false otherwise.
+func (tn *BoolNode) would_cycle(target *BoolNode) bool {
+	for node := tn; node != nil; node = node.Parent {
+		if node == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddChildSafe works like AddChild but it refuses to add the target if doing so
+// would create a cycle.
+//
+// Parameters:
+//   - target: The child to add.
+//
+// Returns:
+//   - error: An error of type *tree.ErrCycle if the target is the node itself or
+//     one of its ancestors.
+//
+// If the receiver or the target are nil, it does nothing.
+func (tn *BoolNode) AddChildSafe(target *BoolNode) error {
+	if tn == nil || target == nil {
+		return nil
+	}
+
+	if tn.would_cycle(target) {
+		return tree.NewErrCycle(target)
+	}
+
+	tn.AddChild(target)
+
+	return nil
+}
+
+// LinkChildrenSafe works like LinkChildren but it refuses to link the children if
+// doing so would create a cycle. In that case, the node is left untouched.
+//
+// Parameters:
+//   - children: The children to link.
+//
+// Returns:
+//   - error: An error of type *tree.ErrCycle if any of the children is the node
+//     itself, one of its ancestors or appears more than once.
+//
+// Does nothing if the receiver is nil.
+func (tn *BoolNode) LinkChildrenSafe(children []*BoolNode) error {
+	if tn == nil {
+		return nil
+	}
+
+	seen := make(map[*BoolNode]struct{}, len(children))
+
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+
+		_, ok := seen[child]
+		if ok || tn.would_cycle(child) {
+			return tree.NewErrCycle(child)
+		}
+
+		seen[child] = struct{}{}
+	}
+
+	tn.LinkChildren(children)
+
+	return nil
+}
+
 // RemoveNode removes the node from the tree while shifting the children up one level to
 // maintain the tree structure. The returned children can be used to create a forest of
 // trees if the root node is removed.
@@ -330,54 +459,63 @@ func (tn *BoolNode) RemoveNode() []*BoolNode {
 		return nil
 	}
 
-	prev := tn.PrevSibling
-	next := tn.NextSibling
 	parent := tn.Parent
 
-	var sub_roots []*BoolNode
-
 	if parent == nil {
-		for c := tn.FirstChild; c != nil; c = c.NextSibling {
+		var sub_roots []*BoolNode
+
+		for c := tn.FirstChild; c != nil; {
+			next := c.NextSibling
+
+			c.Parent = nil
+			c.PrevSibling = nil
+			c.NextSibling = nil
+
 			sub_roots = append(sub_roots, c)
+			c = next
 		}
-	} else {
-		children := parent.delete_child(tn)
 
-		for _, child := range children {
-			child.Parent = parent
-		}
+		tn.FirstChild = nil
+		tn.LastChild = nil
+
+		return sub_roots
+	}
+
+	prev := tn.PrevSibling
+	next := tn.NextSibling
+	first := tn.FirstChild
+	last := tn.LastChild
+
+	for c := first; c != nil; c = c.NextSibling {
+		c.Parent = parent
+	}
+
+	if first == nil {
+		first, last = next, prev
+	} else {
+		first.PrevSibling = prev
+		last.NextSibling = next
 	}
 
 	if prev != nil {
-		prev.NextSibling = next
+		prev.NextSibling = first
 	} else {
-		parent.FirstChild = next
+		parent.FirstChild = first
 	}
 
 	if next != nil {
-		next.PrevSibling = prev
+		next.PrevSibling = last
 	} else {
-		parent.Parent.LastChild = prev
+		parent.LastChild = last
 	}
 
 	tn.Parent = nil
 	tn.PrevSibling = nil
 	tn.NextSibling = nil
-
-	if len(sub_roots) == 0 {
-		return nil
-	}
-
-	for _, child := range sub_roots {
-		child.PrevSibling = nil
-		child.NextSibling = nil
-		child.Parent = nil
-	}
-
 	tn.FirstChild = nil
 	tn.LastChild = nil
 
-	return sub_roots
+	return nil
 }
 
 // AddChildren is a convenience function to add multiple children to the node at once.
@@ -481,24 +619,21 @@ func (tn BoolNode) HasChild(target *BoolNode) bool {
 	return false
 }
 
-// IsChildOf returns true if the node is a child of the parent. If target is nil,
-// it returns false.
+// IsChildOf returns true if the node is a child, direct or not, of the target. If
+// target is nil, it returns false.
 //
 // Parameters:
 //   - target: The target parent to check for.
 //
 // Returns:
-//   - bool: True if the node is a child of the parent, false otherwise.
+//   - bool: True if the target is an ancestor of the node, false otherwise.
 func (tn BoolNode) IsChildOf(target *BoolNode) bool {
 	if target == nil {
 		return false
 	}
 
-	parents := tree.GetNodeAncestors(target)
-
-	for node := &tn; node.Parent != nil; node = node.Parent {
-		ok := slices.Contains(parents, node.Parent)
-		if ok {
+	for node := tn.Parent; node != nil; node = node.Parent {
+		if node == target {
 			return true
 		}
 	}
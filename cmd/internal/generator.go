@@ -132,7 +132,9 @@ const templ = `// Code generated by go generate; EDIT THIS FILE DIRECTLY
 package {{ .PackageName }}
 
 import (
-	"slices"
+	{{- if gt (len .Fields) 1 }}
+	"encoding/json"
+	{{- end }}
 	"iter"
 	"strings"
 
@@ -169,7 +171,9 @@ func (tn {{ .TypeSig }}) String() string {
 	builder.WriteString("{{ .TypeSig }}[")
 
 	{{- range $key, $value := .Stringer }}
-	{{- if ne $key 0 }}builder.WriteRune(','){{ end }}
+	{{- if ne $key 0 }}
+	builder.WriteRune(',')
+	{{- end }}
 	builder.WriteString({{ $value }})
 	{{- end }}
 	builder.WriteRune(']')
@@ -267,7 +271,8 @@ func (tn {{ .TypeSig }}) Child() iter.Seq[*{{ .TypeSig }}] {
 }
 
 // Cleanup cleans the node and returns its children.
-// This function logically removes the node from the siblings and the parent.
+// This function logically removes the node from the siblings and the parent. The
+// returned children are detached from the node but keep their own children.
 //
 // Finally, it is not safe to use in goroutines as pointers may be dereferenced while another
 // goroutine is still using them.
@@ -283,25 +288,36 @@ func (tn *{{ .TypeSig }}) Cleanup() []*{{ .TypeSig }} {
 
 	var children []*{{ .TypeSig }}
 
-	for c := tn.FirstChild; c != nil; c = c.NextSibling {
+	for c := tn.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		c.Parent = nil
+		c.PrevSibling = nil
+		c.NextSibling = nil
+
 		children = append(children, c)
+		c = next
 	}
 
-	tn.FirstChild = nil
-	tn.LastChild = nil
-	tn.Parent = nil
-
+	parent := tn.Parent
 	prev := tn.PrevSibling
 	next := tn.NextSibling
 
 	if prev != nil {
 		prev.NextSibling = next
+	} else if parent != nil {
+		parent.FirstChild = next
 	}
 
 	if next != nil {
 		next.PrevSibling = prev
+	} else if parent != nil {
+		parent.LastChild = prev
 	}
 
+	tn.FirstChild = nil
+	tn.LastChild = nil
+	tn.Parent = nil
 	tn.PrevSibling = nil
 	tn.NextSibling = nil
 
@@ -391,8 +407,8 @@ func (tn *{{ .TypeSig }}) DeleteChild(target *{{ .TypeSig }}) []*{{ .TypeSig }}
 		child.Parent = nil
 	}
 
-	tn.FirstChild = nil
-	tn.LastChild = nil
+	target.FirstChild = nil
+	target.LastChild = nil
 
 	return children
 }
@@ -403,7 +419,7 @@ func (tn *{{ .TypeSig }}) DeleteChild(target *{{ .TypeSig }}) []*{{ .TypeSig }}
 //   - *{{ .TypeSig }}: The first child of the node.
 //   - bool: True if the node has a child, false otherwise.
 func (tn {{ .TypeSig }}) GetFirstChild() (*{{ .TypeSig }}, bool) {
-	return tn.FirstChild, tn.FirstChild == nil
+	return tn.FirstChild, tn.FirstChild != nil
 }
 
 // GetParent returns the parent of the node.
@@ -412,7 +428,7 @@ func (tn {{ .TypeSig }}) GetFirstChild() (*{{ .TypeSig }}, bool) {
 //   - *{{ .TypeSig }}: The parent of the node.
 //   - bool: True if the node has a parent, false otherwise.
 func (tn {{ .TypeSig }}) GetParent() (*{{ .TypeSig }}, bool) {
-	return tn.Parent, tn.Parent == nil
+	return tn.Parent, tn.Parent != nil
 }
 
 // LinkChildren is a method that links the children of the node.
@@ -460,6 +476,198 @@ func (tn *{{ .TypeSig }}) LinkChildren(children []*{{ .TypeSig }}) {
 	tn.FirstChild, tn.LastChild = valid_children[0], valid_children[len(valid_children)-1]
 }
 
+// Links returns the pointers of the node.
+//
+// Returns:
+//   - tree.Links[*{{ .TypeSig }}]: The pointers of the node.
+func (tn {{ .TypeSig }}) Links() tree.Links[*{{ .TypeSig }}] {
+	return tree.Links[*{{ .TypeSig }}]{
+		Parent:      tn.Parent,
+		FirstChild:  tn.FirstChild,
+		NextSibling: tn.NextSibling,
+		LastChild:   tn.LastChild,
+		PrevSibling: tn.PrevSibling,
+	}
+}
+
+{{- if eq (len .Fields) 1 }}
+{{- range $key, $value := .Fields }}
+
+// MarshalData encodes the payload of the node into JSON.
+//
+// Returns:
+//   - []byte: The JSON representation of the payload.
+//   - error: An error if the encoding fails.
+func (tn {{ $.TypeSig }}) MarshalData() ([]byte, error) {
+	return tree.MarshalValue(tn.{{ $key }})
+}
+
+// UnmarshalData decodes the payload of the node from JSON.
+//
+// Parameters:
+//   - data: The JSON representation of the payload.
+//
+// Returns:
+//   - error: An error if the decoding fails.
+func (tn *{{ $.TypeSig }}) UnmarshalData(data []byte) error {
+	if tn == nil {
+		return nil
+	}
+
+	return tree.UnmarshalValue(data, &tn.{{ $key }})
+}
+{{- end }}
+{{- else }}
+
+// MarshalData encodes the payload of the node into JSON as an object whose keys are
+// the names of the fields.
+//
+// Returns:
+//   - []byte: The JSON representation of the payload.
+//   - error: An error if the encoding fails.
+func (tn {{ .TypeSig }}) MarshalData() ([]byte, error) {
+	values := map[string]any{
+		{{- range $key, $value := .Fields }}
+		"{{ $key }}": tn.{{ $key }},
+		{{- end }}
+	}
+
+	fields := make(map[string]json.RawMessage, len(values))
+
+	for name, value := range values {
+		data, err := tree.MarshalValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[name] = data
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalData decodes the payload of the node from JSON. Missing fields are left
+// untouched.
+//
+// Parameters:
+//   - data: The JSON representation of the payload.
+//
+// Returns:
+//   - error: An error if the decoding fails.
+func (tn *{{ .TypeSig }}) UnmarshalData(data []byte) error {
+	if tn == nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	dests := map[string]any{
+		{{- range $key, $value := .Fields }}
+		"{{ $key }}": &tn.{{ $key }},
+		{{- end }}
+	}
+
+	for name, dest := range dests {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		err := tree.UnmarshalValue(raw, dest)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+{{- end }}
+
+// would_cycle is a helper function that checks whether adding the target as a
+// child of the node would create a cycle; that is, whether the target is the node
+// itself or one of its ancestors.
+//
+// Parameters:
+//   - target: The child to check.
+//
+// Returns:
+//   - bool: True if a cycle would be created, false otherwise.
+func (tn *{{ .TypeSig }}) would_cycle(target *{{ .TypeSig }}) bool {
+	for node := tn; node != nil; node = node.Parent {
+		if node == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddChildSafe works like AddChild but it refuses to add the target if doing so
+// would create a cycle.
+//
+// Parameters:
+//   - target: The child to add.
+//
+// Returns:
+//   - error: An error of type *tree.ErrCycle if the target is the node itself or
+//     one of its ancestors.
+//
+// If the receiver or the target are nil, it does nothing.
+func (tn *{{ .TypeSig }}) AddChildSafe(target *{{ .TypeSig }}) error {
+	if tn == nil || target == nil {
+		return nil
+	}
+
+	if tn.would_cycle(target) {
+		return tree.NewErrCycle(target)
+	}
+
+	tn.AddChild(target)
+
+	return nil
+}
+
+// LinkChildrenSafe works like LinkChildren but it refuses to link the children if
+// doing so would create a cycle. In that case, the node is left untouched.
+//
+// Parameters:
+//   - children: The children to link.
+//
+// Returns:
+//   - error: An error of type *tree.ErrCycle if any of the children is the node
+//     itself, one of its ancestors or appears more than once.
+//
+// Does nothing if the receiver is nil.
+func (tn *{{ .TypeSig }}) LinkChildrenSafe(children []*{{ .TypeSig }}) error {
+	if tn == nil {
+		return nil
+	}
+
+	seen := make(map[*{{ .TypeSig }}]struct{}, len(children))
+
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+
+		_, ok := seen[child]
+		if ok || tn.would_cycle(child) {
+			return tree.NewErrCycle(child)
+		}
+
+		seen[child] = struct{}{}
+	}
+
+	tn.LinkChildren(children)
+
+	return nil
+}
+
 // RemoveNode removes the node from the tree while shifting the children up one level to
 // maintain the tree structure. The returned children can be used to create a forest of
 // trees if the root node is removed.
@@ -489,54 +697,63 @@ func (tn *{{ .TypeSig }}) RemoveNode() []*{{ .TypeSig }} {
 		return nil
 	}
 
-	prev := tn.PrevSibling
-	next := tn.NextSibling
 	parent := tn.Parent
 
-	var sub_roots []*{{ .TypeSig }}
-
 	if parent == nil {
-		for c := tn.FirstChild; c != nil; c = c.NextSibling {
+		var sub_roots []*{{ .TypeSig }}
+
+		for c := tn.FirstChild; c != nil; {
+			next := c.NextSibling
+
+			c.Parent = nil
+			c.PrevSibling = nil
+			c.NextSibling = nil
+
 			sub_roots = append(sub_roots, c)
+			c = next
 		}
-	} else {
-		children := parent.delete_child(tn)
 
-		for _, child := range children {
-			child.Parent = parent
-		}
+		tn.FirstChild = nil
+		tn.LastChild = nil
+
+		return sub_roots
+	}
+
+	prev := tn.PrevSibling
+	next := tn.NextSibling
+	first := tn.FirstChild
+	last := tn.LastChild
+
+	for c := first; c != nil; c = c.NextSibling {
+		c.Parent = parent
+	}
+
+	if first == nil {
+		first, last = next, prev
+	} else {
+		first.PrevSibling = prev
+		last.NextSibling = next
 	}
 
 	if prev != nil {
-		prev.NextSibling = next
+		prev.NextSibling = first
 	} else {
-		parent.FirstChild = next
+		parent.FirstChild = first
 	}
 
 	if next != nil {
-		next.PrevSibling = prev
+		next.PrevSibling = last
 	} else {
-		parent.Parent.LastChild = prev
+		parent.LastChild = last
 	}
 
 	tn.Parent = nil
 	tn.PrevSibling = nil
 	tn.NextSibling = nil
-
-	if len(sub_roots) == 0 {
-		return nil
-	}
-
-	for _, child := range sub_roots {
-		child.PrevSibling = nil
-		child.NextSibling = nil
-		child.Parent = nil
-	}
-
 	tn.FirstChild = nil
 	tn.LastChild = nil
 
-	return sub_roots
+	return nil
 }
 
 // AddChildren is a convenience function to add multiple children to the node at once.
@@ -640,24 +857,21 @@ func (tn {{ .TypeSig }}) HasChild(target *{{ .TypeSig }}) bool {
 	return false
 }
 
-// IsChildOf returns true if the node is a child of the parent. If target is nil,
-// it returns false.
+// IsChildOf returns true if the node is a child, direct or not, of the target. If
+// target is nil, it returns false.
 //
 // Parameters:
 //   - target: The target parent to check for.
 //
 // Returns:
-//   - bool: True if the node is a child of the parent, false otherwise.
+//   - bool: True if the target is an ancestor of the node, false otherwise.
 func (tn {{ .TypeSig }}) IsChildOf(target *{{ .TypeSig }}) bool {
 	if target == nil {
 		return false
 	}
 
-	parents := tree.GetNodeAncestors(target)
-
-	for node := &tn; node.Parent != nil; node = node.Parent {
-		ok := slices.Contains(parents, node.Parent)
-		if ok {
+	for node := tn.Parent; node != nil; node = node.Parent {
+		if node == target {
 			return true
 		}
 	}
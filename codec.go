@@ -0,0 +1,388 @@
+package tree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/PlayerR9/tree/tree"
+)
+
+var (
+	// BoolCodec is the binary codec of BoolNode.
+	BoolCodec tree.Codec[*BoolNode]
+
+	// ByteCodec is the binary codec of ByteNode.
+	ByteCodec tree.Codec[*ByteNode]
+
+	// Complex64Codec is the binary codec of Complex64Node.
+	Complex64Codec tree.Codec[*Complex64Node]
+
+	// Complex128Codec is the binary codec of Complex128Node.
+	Complex128Codec tree.Codec[*Complex128Node]
+
+	// ErrorCodec is the binary codec of ErrorNode. Errors are encoded as their
+	// message and decoded with errors.New.
+	ErrorCodec tree.Codec[*ErrorNode]
+
+	// Float32Codec is the binary codec of Float32Node.
+	Float32Codec tree.Codec[*Float32Node]
+
+	// Float64Codec is the binary codec of Float64Node.
+	Float64Codec tree.Codec[*Float64Node]
+
+	// IntCodec is the binary codec of IntNode.
+	IntCodec tree.Codec[*IntNode]
+
+	// Int8Codec is the binary codec of Int8Node.
+	Int8Codec tree.Codec[*Int8Node]
+
+	// Int16Codec is the binary codec of Int16Node.
+	Int16Codec tree.Codec[*Int16Node]
+
+	// Int32Codec is the binary codec of Int32Node.
+	Int32Codec tree.Codec[*Int32Node]
+
+	// Int64Codec is the binary codec of Int64Node.
+	Int64Codec tree.Codec[*Int64Node]
+
+	// RuneCodec is the binary codec of RuneNode.
+	RuneCodec tree.Codec[*RuneNode]
+
+	// StringCodec is the binary codec of StringNode.
+	StringCodec tree.Codec[*StringNode]
+
+	// UintCodec is the binary codec of UintNode.
+	UintCodec tree.Codec[*UintNode]
+
+	// Uint8Codec is the binary codec of Uint8Node.
+	Uint8Codec tree.Codec[*Uint8Node]
+
+	// Uint16Codec is the binary codec of Uint16Node.
+	Uint16Codec tree.Codec[*Uint16Node]
+
+	// Uint32Codec is the binary codec of Uint32Node.
+	Uint32Codec tree.Codec[*Uint32Node]
+
+	// Uint64Codec is the binary codec of Uint64Node.
+	Uint64Codec tree.Codec[*Uint64Node]
+
+	// UintptrCodec is the binary codec of UintptrNode.
+	UintptrCodec tree.Codec[*UintptrNode]
+)
+
+func init() {
+	BoolCodec = must_codec(
+		func(n *BoolNode) ([]byte, error) {
+			if n.Data {
+				return []byte{1}, nil
+			}
+
+			return []byte{0}, nil
+		},
+		func(payload []byte) (*BoolNode, error) {
+			if len(payload) != 1 || payload[0] > 1 {
+				return nil, fmt.Errorf("invalid bool payload %v", payload)
+			}
+
+			return NewBoolNode(payload[0] == 1), nil
+		},
+	)
+
+	ByteCodec = new_uint_codec(8,
+		func(n *ByteNode) uint64 { return uint64(n.Data) },
+		func(v uint64) *ByteNode { return NewByteNode(byte(v)) },
+	)
+
+	Complex64Codec = must_codec(
+		func(n *Complex64Node) ([]byte, error) {
+			b := binary.LittleEndian.AppendUint32(nil, math.Float32bits(real(n.Data)))
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(imag(n.Data))), nil
+		},
+		func(payload []byte) (*Complex64Node, error) {
+			if len(payload) != 8 {
+				return nil, fmt.Errorf("complex64 payload must be 8 bytes, got %d", len(payload))
+			}
+
+			re := math.Float32frombits(binary.LittleEndian.Uint32(payload))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(payload[4:]))
+
+			return NewComplex64Node(complex(re, im)), nil
+		},
+	)
+
+	Complex128Codec = must_codec(
+		func(n *Complex128Node) ([]byte, error) {
+			b := binary.LittleEndian.AppendUint64(nil, math.Float64bits(real(n.Data)))
+			return binary.LittleEndian.AppendUint64(b, math.Float64bits(imag(n.Data))), nil
+		},
+		func(payload []byte) (*Complex128Node, error) {
+			if len(payload) != 16 {
+				return nil, fmt.Errorf("complex128 payload must be 16 bytes, got %d", len(payload))
+			}
+
+			re := math.Float64frombits(binary.LittleEndian.Uint64(payload))
+			im := math.Float64frombits(binary.LittleEndian.Uint64(payload[8:]))
+
+			return NewComplex128Node(complex(re, im)), nil
+		},
+	)
+
+	ErrorCodec = must_codec(
+		func(n *ErrorNode) ([]byte, error) {
+			if n.Data == nil {
+				return []byte{0}, nil
+			}
+
+			return append([]byte{1}, n.Data.Error()...), nil
+		},
+		func(payload []byte) (*ErrorNode, error) {
+			if len(payload) == 0 || payload[0] > 1 {
+				return nil, fmt.Errorf("invalid error payload %v", payload)
+			}
+
+			if payload[0] == 0 {
+				return NewErrorNode(nil), nil
+			}
+
+			return NewErrorNode(errors.New(string(payload[1:]))), nil
+		},
+	)
+
+	Float32Codec = must_codec(
+		func(n *Float32Node) ([]byte, error) {
+			return binary.LittleEndian.AppendUint32(nil, math.Float32bits(n.Data)), nil
+		},
+		func(payload []byte) (*Float32Node, error) {
+			if len(payload) != 4 {
+				return nil, fmt.Errorf("float32 payload must be 4 bytes, got %d", len(payload))
+			}
+
+			return NewFloat32Node(math.Float32frombits(binary.LittleEndian.Uint32(payload))), nil
+		},
+	)
+
+	Float64Codec = must_codec(
+		func(n *Float64Node) ([]byte, error) {
+			return binary.LittleEndian.AppendUint64(nil, math.Float64bits(n.Data)), nil
+		},
+		func(payload []byte) (*Float64Node, error) {
+			if len(payload) != 8 {
+				return nil, fmt.Errorf("float64 payload must be 8 bytes, got %d", len(payload))
+			}
+
+			return NewFloat64Node(math.Float64frombits(binary.LittleEndian.Uint64(payload))), nil
+		},
+	)
+
+	IntCodec = new_int_codec(64,
+		func(n *IntNode) int64 { return int64(n.Data) },
+		func(v int64) *IntNode { return NewIntNode(int(v)) },
+	)
+
+	Int8Codec = new_int_codec(8,
+		func(n *Int8Node) int64 { return int64(n.Data) },
+		func(v int64) *Int8Node { return NewInt8Node(int8(v)) },
+	)
+
+	Int16Codec = new_int_codec(16,
+		func(n *Int16Node) int64 { return int64(n.Data) },
+		func(v int64) *Int16Node { return NewInt16Node(int16(v)) },
+	)
+
+	Int32Codec = new_int_codec(32,
+		func(n *Int32Node) int64 { return int64(n.Data) },
+		func(v int64) *Int32Node { return NewInt32Node(int32(v)) },
+	)
+
+	Int64Codec = new_int_codec(64,
+		func(n *Int64Node) int64 { return n.Data },
+		func(v int64) *Int64Node { return NewInt64Node(v) },
+	)
+
+	RuneCodec = must_codec(
+		func(n *RuneNode) ([]byte, error) {
+			return binary.AppendVarint(nil, int64(n.Data)), nil
+		},
+		func(payload []byte) (*RuneNode, error) {
+			v, err := read_varint(payload)
+			if err != nil {
+				return nil, err
+			}
+
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				return nil, fmt.Errorf("value %d overflows rune", v)
+			}
+
+			return NewRuneNode(rune(v)), nil
+		},
+	)
+
+	StringCodec = must_codec(
+		func(n *StringNode) ([]byte, error) {
+			return []byte(n.Data), nil
+		},
+		func(payload []byte) (*StringNode, error) {
+			return NewStringNode(string(payload)), nil
+		},
+	)
+
+	UintCodec = new_uint_codec(64,
+		func(n *UintNode) uint64 { return uint64(n.Data) },
+		func(v uint64) *UintNode { return NewUintNode(uint(v)) },
+	)
+
+	Uint8Codec = new_uint_codec(8,
+		func(n *Uint8Node) uint64 { return uint64(n.Data) },
+		func(v uint64) *Uint8Node { return NewUint8Node(uint8(v)) },
+	)
+
+	Uint16Codec = new_uint_codec(16,
+		func(n *Uint16Node) uint64 { return uint64(n.Data) },
+		func(v uint64) *Uint16Node { return NewUint16Node(uint16(v)) },
+	)
+
+	Uint32Codec = new_uint_codec(32,
+		func(n *Uint32Node) uint64 { return uint64(n.Data) },
+		func(v uint64) *Uint32Node { return NewUint32Node(uint32(v)) },
+	)
+
+	Uint64Codec = new_uint_codec(64,
+		func(n *Uint64Node) uint64 { return n.Data },
+		func(v uint64) *Uint64Node { return NewUint64Node(v) },
+	)
+
+	UintptrCodec = new_uint_codec(64,
+		func(n *UintptrNode) uint64 { return uint64(n.Data) },
+		func(v uint64) *UintptrNode { return NewUintptrNode(uintptr(v)) },
+	)
+}
+
+// NewTreeNodeCodec creates a binary codec for TreeNode[T] out of the encoding
+// functions of its data.
+//
+// Parameters:
+//   - encode_fn: The function that encodes the data of a node.
+//   - decode_fn: The function that decodes the data of a node.
+//
+// Returns:
+//   - tree.Codec[*TreeNode[T]]: The new codec.
+//   - error: An error if any of the functions is nil.
+func NewTreeNodeCodec[T any](encode_fn func(data T) ([]byte, error), decode_fn func(payload []byte) (T, error)) (tree.Codec[*TreeNode[T]], error) {
+	if encode_fn == nil || decode_fn == nil {
+		return tree.NewCodec[*TreeNode[T]](nil, nil)
+	}
+
+	return tree.NewCodec(
+		func(n *TreeNode[T]) ([]byte, error) {
+			return encode_fn(n.Data)
+		},
+		func(payload []byte) (*TreeNode[T], error) {
+			data, err := decode_fn(payload)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewTreeNode(data), nil
+		},
+	)
+}
+
+// must_codec is a helper function that creates a codec out of non-nil functions.
+//
+// Parameters:
+//   - encode_fn: The encoding function. Must not be nil.
+//   - decode_fn: The decoding function. Must not be nil.
+//
+// Returns:
+//   - tree.Codec[T]: The new codec.
+func must_codec[T any](encode_fn func(node T) ([]byte, error), decode_fn func(payload []byte) (T, error)) tree.Codec[T] {
+	codec, err := tree.NewCodec(encode_fn, decode_fn)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return codec
+}
+
+// read_varint is a helper function that reads a payload made of exactly one varint.
+//
+// Parameters:
+//   - payload: The payload to read.
+//
+// Returns:
+//   - int64: The value read.
+//   - error: An error if the payload is not exactly one varint.
+func read_varint(payload []byte) (int64, error) {
+	v, n := binary.Varint(payload)
+	if n <= 0 || n != len(payload) {
+		return 0, fmt.Errorf("invalid varint payload %v", payload)
+	}
+
+	return v, nil
+}
+
+// new_int_codec is a helper function that creates a varint codec for signed
+// integer nodes.
+//
+// Parameters:
+//   - bits: The size of the integer type in bits.
+//   - get_fn: The function that returns the data of a node.
+//   - new_fn: The function that creates a node out of its data.
+//
+// Returns:
+//   - tree.Codec[T]: The new codec.
+func new_int_codec[T any](bits int, get_fn func(node T) int64, new_fn func(v int64) T) tree.Codec[T] {
+	lo, hi := int64(-1)<<(bits-1), int64(uint64(1)<<(bits-1)-1)
+
+	return must_codec(
+		func(node T) ([]byte, error) {
+			return binary.AppendVarint(nil, get_fn(node)), nil
+		},
+		func(payload []byte) (T, error) {
+			v, err := read_varint(payload)
+			if err != nil {
+				return *new(T), err
+			}
+
+			if v < lo || v > hi {
+				return *new(T), fmt.Errorf("value %d overflows int%d", v, bits)
+			}
+
+			return new_fn(v), nil
+		},
+	)
+}
+
+// new_uint_codec is a helper function that creates a varint codec for unsigned
+// integer nodes.
+//
+// Parameters:
+//   - bits: The size of the integer type in bits.
+//   - get_fn: The function that returns the data of a node.
+//   - new_fn: The function that creates a node out of its data.
+//
+// Returns:
+//   - tree.Codec[T]: The new codec.
+func new_uint_codec[T any](bits int, get_fn func(node T) uint64, new_fn func(v uint64) T) tree.Codec[T] {
+	hi := uint64(math.MaxUint64) >> (64 - bits)
+
+	return must_codec(
+		func(node T) ([]byte, error) {
+			return binary.AppendUvarint(nil, get_fn(node)), nil
+		},
+		func(payload []byte) (T, error) {
+			v, n := binary.Uvarint(payload)
+			if n <= 0 || n != len(payload) {
+				return *new(T), fmt.Errorf("invalid uvarint payload %v", payload)
+			}
+
+			if v > hi {
+				return *new(T), fmt.Errorf("value %d overflows uint%d", v, bits)
+			}
+
+			return new_fn(v), nil
+		},
+	)
+}
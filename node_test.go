@@ -0,0 +1,198 @@
+package tree
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/PlayerR9/tree/tree/treetest"
+)
+
+func TestTreeNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *TreeNode[int] {
+		count++
+		return NewTreeNode(count)
+	})
+}
+
+func TestBoolNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *BoolNode {
+		count++
+		return NewBoolNode(count%2 == 0)
+	})
+}
+
+func TestByteNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *ByteNode {
+		count++
+		return NewByteNode(byte(count))
+	})
+}
+
+func TestComplex64Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Complex64Node {
+		count++
+		return NewComplex64Node(complex(float32(count), 0))
+	})
+}
+
+func TestComplex128Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Complex128Node {
+		count++
+		return NewComplex128Node(complex(float64(count), 0))
+	})
+}
+
+func TestErrorNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *ErrorNode {
+		count++
+		return NewErrorNode(errors.New(strconv.Itoa(count)))
+	})
+}
+
+func TestFloat32Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Float32Node {
+		count++
+		return NewFloat32Node(float32(count))
+	})
+}
+
+func TestFloat64Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Float64Node {
+		count++
+		return NewFloat64Node(float64(count))
+	})
+}
+
+func TestIntNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *IntNode {
+		count++
+		return NewIntNode(count)
+	})
+}
+
+func TestInt8Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Int8Node {
+		count++
+		return NewInt8Node(int8(count))
+	})
+}
+
+func TestInt16Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Int16Node {
+		count++
+		return NewInt16Node(int16(count))
+	})
+}
+
+func TestInt32Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Int32Node {
+		count++
+		return NewInt32Node(int32(count))
+	})
+}
+
+func TestInt64Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Int64Node {
+		count++
+		return NewInt64Node(int64(count))
+	})
+}
+
+func TestRuneNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *RuneNode {
+		count++
+		return NewRuneNode(rune(count))
+	})
+}
+
+func TestStringNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *StringNode {
+		count++
+		return NewStringNode(strconv.Itoa(count))
+	})
+}
+
+func TestUintNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *UintNode {
+		count++
+		return NewUintNode(uint(count))
+	})
+}
+
+func TestUint8Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Uint8Node {
+		count++
+		return NewUint8Node(uint8(count))
+	})
+}
+
+func TestUint16Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Uint16Node {
+		count++
+		return NewUint16Node(uint16(count))
+	})
+}
+
+func TestUint32Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Uint32Node {
+		count++
+		return NewUint32Node(uint32(count))
+	})
+}
+
+func TestUint64Node(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *Uint64Node {
+		count++
+		return NewUint64Node(uint64(count))
+	})
+}
+
+func TestUintptrNode(t *testing.T) {
+	var count int
+
+	treetest.Run(t, func() *UintptrNode {
+		count++
+		return NewUintptrNode(uintptr(count))
+	})
+}
@@ -0,0 +1,31 @@
+package tree
+
+import (
+	"strings"
+
+	"github.com/PlayerR9/tree/tree"
+)
+
+// ParseStringTree parses the box-drawing format written by Tree.String into a
+// tree of StringNode.
+//
+// Parameters:
+//   - text: The text to parse.
+//
+// Returns:
+//   - *tree.Tree[*StringNode]: The parsed tree.
+//   - error: An *tree.ErrSyntax if the text is malformed.
+//
+// Labels of the form "StringNode[<data>]", as written by StringNode.String, are
+// unwrapped so that the output of Tree.String can be parsed back; any other label
+// is the data of the node as is. See tree.ParseTree for the format.
+func ParseStringTree(text string) (*tree.Tree[*StringNode], error) {
+	return tree.ParseTree(text, func(label string) (*StringNode, error) {
+		data, ok := strings.CutPrefix(label, "StringNode[")
+		if ok && strings.HasSuffix(data, "]") {
+			label = strings.TrimSuffix(data, "]")
+		}
+
+		return NewStringNode(label), nil
+	})
+}
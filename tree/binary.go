@@ -0,0 +1,419 @@
+package tree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+const (
+	// BinaryVersion is the current version of the binary format.
+	BinaryVersion byte = 1
+
+	// max_payload_size is the maximum size, in bytes, of a node payload accepted by
+	// the decoder. It protects against corrupted length prefixes.
+	max_payload_size uint64 = 1 << 30
+)
+
+// binary_magic is the magic number at the start of every binary tree.
+var binary_magic = [4]byte{'T', 'R', 'E', 'E'}
+
+// Codec encodes and decodes the payload of a node.
+type Codec[T any] interface {
+	// EncodeNode encodes the payload of the node. The pointers of the node must
+	// not be encoded.
+	//
+	// Parameters:
+	//   - node: The node to encode.
+	//
+	// Returns:
+	//   - []byte: The encoded payload.
+	//   - error: An error if the encoding fails.
+	EncodeNode(node T) ([]byte, error)
+
+	// DecodeNode creates a new detached node from its encoded payload.
+	//
+	// Parameters:
+	//   - payload: The encoded payload. It must not be retained.
+	//
+	// Returns:
+	//   - T: The new node.
+	//   - error: An error if the decoding fails.
+	DecodeNode(payload []byte) (T, error)
+}
+
+// func_codec is a Codec made out of two functions.
+type func_codec[T any] struct {
+	// encode_fn is the encoding function.
+	encode_fn func(node T) ([]byte, error)
+
+	// decode_fn is the decoding function.
+	decode_fn func(payload []byte) (T, error)
+}
+
+// EncodeNode implements the Codec interface.
+func (c func_codec[T]) EncodeNode(node T) ([]byte, error) {
+	return c.encode_fn(node)
+}
+
+// DecodeNode implements the Codec interface.
+func (c func_codec[T]) DecodeNode(payload []byte) (T, error) {
+	return c.decode_fn(payload)
+}
+
+// NewCodec creates a new codec from the given functions.
+//
+// Parameters:
+//   - encode_fn: The function that encodes the payload of a node.
+//   - decode_fn: The function that creates a new node from its payload.
+//
+// Returns:
+//   - Codec[T]: The new codec.
+//   - error: An error if any of the functions is nil.
+func NewCodec[T any](encode_fn func(node T) ([]byte, error), decode_fn func(payload []byte) (T, error)) (Codec[T], error) {
+	if encode_fn == nil {
+		return nil, gcers.NewErrNilParameter("encode_fn")
+	} else if decode_fn == nil {
+		return nil, gcers.NewErrNilParameter("decode_fn")
+	}
+
+	return func_codec[T]{
+		encode_fn: encode_fn,
+		decode_fn: decode_fn,
+	}, nil
+}
+
+// Encoder writes trees to an io.Writer in the binary format.
+//
+// Format (all integers are unsigned varints):
+//
+//	"TREE" <version> <node>
+//
+// where every <node> is written in DFS order as:
+//
+//	<number of children> <payload length> <payload bytes>
+type Encoder[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// w is the buffered writer.
+	w *bufio.Writer
+
+	// codec is the payload codec.
+	codec Codec[T]
+
+	// scratch is the scratch buffer for varints.
+	scratch [binary.MaxVarintLen64]byte
+}
+
+// NewEncoder creates a new encoder that writes to w.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - codec: The payload codec.
+//
+// Returns:
+//   - *Encoder[T]: The new encoder.
+//   - error: An error if w or codec is nil.
+func NewEncoder[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](w io.Writer, codec Codec[T]) (*Encoder[T], error) {
+	if w == nil {
+		return nil, gcers.NewErrNilParameter("w")
+	} else if codec == nil {
+		return nil, gcers.NewErrNilParameter("codec")
+	}
+
+	return &Encoder[T]{
+		w:     bufio.NewWriter(w),
+		codec: codec,
+	}, nil
+}
+
+// write_uvarint is a helper method that writes an unsigned varint.
+//
+// Parameters:
+//   - v: The value to write.
+//
+// Returns:
+//   - error: An error if the write fails.
+func (e *Encoder[T]) write_uvarint(v uint64) error {
+	n := binary.PutUvarint(e.scratch[:], v)
+
+	_, err := e.w.Write(e.scratch[:n])
+	return err
+}
+
+// Encode writes the tree, including its header. Several trees can be written to
+// the same writer one after the other.
+//
+// Parameters:
+//   - tree: The tree to write.
+//
+// Returns:
+//   - error: An error if the encoding or the write fails.
+//
+// Nodes are written as soon as they are visited; only the DFS stack is kept in memory.
+func (e *Encoder[T]) Encode(tree *Tree[T]) error {
+	if tree == nil {
+		return gcers.NewErrNilParameter("tree")
+	}
+
+	_, err := e.w.Write(binary_magic[:])
+	if err != nil {
+		return err
+	}
+
+	err = e.w.WriteByte(BinaryVersion)
+	if err != nil {
+		return err
+	}
+
+	for node := range tree.DFS() {
+		err := e.write_uvarint(uint64(count_children(node)))
+		if err != nil {
+			return err
+		}
+
+		payload, err := e.codec.EncodeNode(node)
+		if err != nil {
+			return gcers.NewErrAt(node.String(), err)
+		}
+
+		err = e.write_uvarint(uint64(len(payload)))
+		if err != nil {
+			return err
+		}
+
+		_, err = e.w.Write(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.w.Flush()
+}
+
+// Decoder reads trees written by an Encoder from an io.Reader.
+type Decoder[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// r is the buffered reader.
+	r *bufio.Reader
+
+	// codec is the payload codec.
+	codec Codec[T]
+
+	// payload is the reusable payload buffer.
+	payload bytes.Buffer
+}
+
+// NewDecoder creates a new decoder that reads from r.
+//
+// Parameters:
+//   - r: The reader to read from.
+//   - codec: The payload codec.
+//
+// Returns:
+//   - *Decoder[T]: The new decoder.
+//   - error: An error if r or codec is nil.
+func NewDecoder[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](r io.Reader, codec Codec[T]) (*Decoder[T], error) {
+	if r == nil {
+		return nil, gcers.NewErrNilParameter("r")
+	} else if codec == nil {
+		return nil, gcers.NewErrNilParameter("codec")
+	}
+
+	return &Decoder[T]{
+		r:     bufio.NewReader(r),
+		codec: codec,
+	}, nil
+}
+
+// read_header is a helper method that reads and checks the header of a tree.
+//
+// Returns:
+//   - error: io.EOF if the stream ends cleanly before the header, an error wrapping
+//     InvalidBinaryFormat if the header is malformed or any read error.
+func (d *Decoder[T]) read_header() error {
+	var header [len(binary_magic) + 1]byte
+
+	n, err := io.ReadFull(d.r, header[:])
+	if err == io.EOF {
+		return io.EOF
+	} else if err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: truncated header (%d bytes)", InvalidBinaryFormat, n)
+	} else if err != nil {
+		return err
+	}
+
+	if [4]byte(header[:4]) != binary_magic {
+		return fmt.Errorf("%w: bad magic number %q", InvalidBinaryFormat, header[:4])
+	}
+
+	if header[4] != BinaryVersion {
+		return fmt.Errorf("%w: unsupported version %d", InvalidBinaryFormat, header[4])
+	}
+
+	return nil
+}
+
+// read_node is a helper method that reads one node.
+//
+// Returns:
+//   - T: The node read.
+//   - uint64: The number of children of the node.
+//   - error: An error if the read or the decoding fails.
+func (d *Decoder[T]) read_node() (T, uint64, error) {
+	count, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return *new(T), 0, unexpected_eof(err)
+	}
+
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return *new(T), 0, unexpected_eof(err)
+	}
+
+	if size > max_payload_size {
+		return *new(T), 0, fmt.Errorf("%w: payload of %d bytes is too large", InvalidBinaryFormat, size)
+	}
+
+	// The buffer grows with the bytes actually read, so that a corrupted length
+	// prefix cannot allocate more memory than the stream holds.
+	d.payload.Reset()
+
+	_, err = io.CopyN(&d.payload, d.r, int64(size))
+	if err != nil {
+		return *new(T), 0, unexpected_eof(err)
+	}
+
+	node, err := d.codec.DecodeNode(d.payload.Bytes())
+	if err != nil {
+		return *new(T), 0, err
+	}
+
+	return node, count, nil
+}
+
+// unexpected_eof is a helper function that turns an io.EOF in the middle of a tree
+// into an io.ErrUnexpectedEOF.
+//
+// Parameters:
+//   - err: The error to convert.
+//
+// Returns:
+//   - error: The converted error.
+func unexpected_eof(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// Decode reads the next tree of the stream.
+//
+// Returns:
+//   - *Tree[T]: The tree read. The parent and sibling pointers, as well as the
+//     leaves and the size of the tree, are rebuilt.
+//   - error: io.EOF if there are no more trees, or an error if the read or the
+//     decoding fails.
+//
+// Nodes are read one at a time; only the nodes whose children have not all been
+// read yet are kept on the stack.
+func (d *Decoder[T]) Decode() (*Tree[T], error) {
+	err := d.read_header()
+	if err != nil {
+		return nil, err
+	}
+
+	type StackElement struct {
+		node      T
+		remaining uint64
+		children  []T
+	}
+
+	root, count, err := d.read_node()
+	if err != nil {
+		return nil, err
+	}
+
+	stack := []*StackElement{{node: root, remaining: count}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+
+		if top.remaining == 0 {
+			stack = stack[:len(stack)-1]
+
+			if len(top.children) > 0 {
+				top.node.LinkChildren(top.children)
+			}
+
+			continue
+		}
+
+		node, count, err := d.read_node()
+		if err != nil {
+			return nil, err
+		}
+
+		top.remaining--
+		top.children = append(top.children, node)
+
+		stack = append(stack, &StackElement{node: node, remaining: count})
+	}
+
+	return NewTree(root), nil
+}
+
+// All returns every remaining tree of the stream.
+//
+// Returns:
+//   - iter.Seq2[*Tree[T], error]: The sequence of trees. If an error occurs, a nil
+//     tree and the error are yielded last.
+func (d *Decoder[T]) All() iter.Seq2[*Tree[T], error] {
+	return func(yield func(*Tree[T], error) bool) {
+		for {
+			tree, err := d.Decode()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(tree, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package tree_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	trees := []*tree.Tree[*root.StringNode]{
+		sample_tree(),
+		tree.NewTree(make_node("x")),
+	}
+
+	var buf bytes.Buffer
+
+	enc, err := tree.NewEncoder(&buf, root.StringCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tr := range trees {
+		err := enc.Encode(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec, err := tree.NewDecoder(&buf, root.StringCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var i int
+
+	for got, err := range dec.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i >= len(trees) {
+			t.Fatalf("decoded %d trees, want %d", i+1, len(trees))
+		}
+
+		if !same_tree(got.Root(), trees[i].Root()) {
+			t.Errorf("tree %d: got\n%s\nwant\n%s", i, got, trees[i])
+		}
+
+		err = tree.Validate(got)
+		if err != nil {
+			t.Errorf("tree %d: %v", i, err)
+		}
+
+		i++
+	}
+
+	if i != len(trees) {
+		t.Errorf("decoded %d trees, want %d", i, len(trees))
+	}
+}
+
+func TestBinaryDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, err := tree.NewEncoder(&buf, root.StringCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = enc.Encode(tree.NewTree(make_node("a", make_node("b"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"bad magic", append([]byte("TRES"), valid[4:]...), tree.InvalidBinaryFormat},
+		{"bad version", append([]byte("TREE\x09"), valid[5:]...), tree.InvalidBinaryFormat},
+		{"truncated header", valid[:3], tree.InvalidBinaryFormat},
+		{"truncated node", valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{"payload too large", []byte("TREE\x01\x00\xff\xff\xff\xff\x0f"), tree.InvalidBinaryFormat},
+	}
+
+	for _, test := range tests {
+		dec, err := tree.NewDecoder(bytes.NewReader(test.input), root.StringCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = dec.Decode()
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestBinaryDecodeLyingLength(t *testing.T) {
+	// A root with no children whose payload claims to be almost 1 GiB long, but
+	// the stream ends right after the length prefix.
+	input := []byte("TREE\x01\x00\xff\xff\xff\xff\x03")
+
+	var before, after runtime.MemStats
+
+	runtime.ReadMemStats(&before)
+
+	dec, err := tree.NewDecoder(bytes.NewReader(input), root.StringCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dec.Decode()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	runtime.ReadMemStats(&after)
+
+	allocated := after.TotalAlloc - before.TotalAlloc
+	if allocated > 1<<20 {
+		t.Errorf("decoder allocated %d bytes for a %d byte stream", allocated, len(input))
+	}
+}
@@ -2,6 +2,7 @@ package tree
 
 import (
 	"iter"
+	"slices"
 )
 
 // Branch represents a branch in a tree.
@@ -70,3 +71,29 @@ func NewBranch[T interface {
 
 	return branch, nil
 }
+
+// Path returns the nodes of the branch from the top to the bottom.
+//
+// Returns:
+//   - []T: The nodes of the branch, where [0] is the top node and [len-1] is the
+//     bottom node.
+//
+// The path is computed by walking the parents of the bottom node, so it reflects
+// the current links of the nodes.
+func (b Branch[T]) Path() []T {
+	path := []T{b.to_node}
+
+	for n := b.to_node; n != b.from_node; {
+		parent, ok := n.GetParent()
+		if !ok {
+			break
+		}
+
+		path = append(path, parent)
+		n = parent
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
@@ -1,6 +1,7 @@
 package tree
 
 import (
+	"context"
 	"iter"
 
 	gcers "github.com/PlayerR9/go-errors"
@@ -45,6 +46,9 @@ type builder_stack_element[T interface {
 
 	// info is the info of the current node.
 	info I
+
+	// depth is the depth of the current node.
+	depth int
 }
 
 // Builder is a struct that builds a tree.
@@ -113,7 +117,30 @@ func NewBuilder[T interface {
 //   - The 'info' parameter is copied for each node and it specifies the initial info
 //     before traversing the tree.
 func (b *Builder[T, I]) Build(root T) (*Tree[T], error) {
+	return b.BuildContext(context.Background(), root, Limits{})
+}
+
+// BuildContext works like Build but it stops as soon as the context is done or one
+// of the limits is hit. Useful when the next function describes an infinite tree.
+//
+// Parameters:
+//   - ctx: The context of the building process.
+//   - root: The element to start the tree from.
+//   - limits: The limits of the building process.
+//
+// Returns:
+//   - *Tree: The tree created from the element.
+//   - error: An error if the next function fails or an error of type *ErrLimitReached
+//     if a limit is hit.
+func (b *Builder[T, I]) BuildContext(ctx context.Context, root T, limits Limits) (*Tree[T], error) {
+	lim := new_limiter(ctx, limits)
+
 	// 1. Handle the root node
+	err := lim.visit(0)
+	if err != nil {
+		return nil, err
+	}
+
 	nexts, err := b.f(root, b.info)
 	if err != nil {
 		return nil, err
@@ -129,9 +156,10 @@ func (b *Builder[T, I]) Build(root T) (*Tree[T], error) {
 
 	for _, next := range nexts {
 		se := builder_stack_element[T, I]{
-			prev: tree.Root(),
-			elem: next,
-			info: b.info.Copy(),
+			prev:  tree.Root(),
+			elem:  next,
+			info:  b.info.Copy(),
+			depth: 1,
 		}
 
 		stack = append(stack, se)
@@ -141,6 +169,11 @@ func (b *Builder[T, I]) Build(root T) (*Tree[T], error) {
 		top := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
+		err := lim.visit(top.depth)
+		if err != nil {
+			return nil, err
+		}
+
 		nexts, err := b.f(top.elem, top.info)
 		if err != nil {
 			return nil, err
@@ -154,9 +187,10 @@ func (b *Builder[T, I]) Build(root T) (*Tree[T], error) {
 
 		for _, next := range nexts {
 			se := builder_stack_element[T, I]{
-				prev: top.elem,
-				elem: next,
-				info: top.info.Copy(),
+				prev:  top.elem,
+				elem:  next,
+				info:  top.info.Copy(),
+				depth: top.depth + 1,
 			}
 
 			stack = append(stack, se)
@@ -197,6 +231,31 @@ func Build[T interface {
 	LinkChildren(children []T)
 	TreeNoder
 }](root T, fn func(elem T) ([]T, error)) (*Tree[T], error) {
+	return BuildContext(context.Background(), root, fn, Limits{})
+}
+
+// BuildContext works like Build but it stops as soon as the context is done or one
+// of the limits is hit. Useful when the next function describes an infinite tree.
+//
+// Parameters:
+//   - ctx: The context of the building process.
+//   - root: The element to start the tree from.
+//   - fn: The function that, given an element, returns the next elements.
+//   - limits: The limits of the building process.
+//
+// Returns:
+//   - *Tree: The tree created from the element.
+//   - error: An error if the next function fails or an error of type *ErrLimitReached
+//     if a limit is hit.
+func BuildContext[T interface {
+	AddChild(child T)
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](ctx context.Context, root T, fn func(elem T) ([]T, error), limits Limits) (*Tree[T], error) {
 	if fn == nil {
 		return nil, gcers.NewErrInvalidUsage(
 			"no next function is set",
@@ -204,7 +263,14 @@ func Build[T interface {
 		)
 	}
 
+	lim := new_limiter(ctx, limits)
+
 	// 1. Handle the root node
+	err := lim.visit(0)
+	if err != nil {
+		return nil, err
+	}
+
 	nexts, err := fn(root)
 	if err != nil {
 		return nil, err
@@ -223,14 +289,18 @@ func Build[T interface {
 
 		// elem is the current node.
 		elem T
+
+		// depth is the depth of the current node.
+		depth int
 	}
 
 	stack := make([]StackElement, 0, len(nexts))
 
 	for _, next := range nexts {
 		se := StackElement{
-			prev: tree.Root(),
-			elem: next,
+			prev:  tree.Root(),
+			elem:  next,
+			depth: 1,
 		}
 
 		stack = append(stack, se)
@@ -240,6 +310,11 @@ func Build[T interface {
 		top := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
+		err := lim.visit(top.depth)
+		if err != nil {
+			return nil, err
+		}
+
 		nexts, err := fn(top.elem)
 		if err != nil {
 			return nil, err
@@ -253,8 +328,9 @@ func Build[T interface {
 
 		for _, next := range nexts {
 			se := StackElement{
-				prev: top.elem,
-				elem: next,
+				prev:  top.elem,
+				elem:  next,
+				depth: top.depth + 1,
 			}
 
 			stack = append(stack, se)
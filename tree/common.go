@@ -255,27 +255,18 @@ func InsertBranch[T interface {
 			panic("IMPOSSIBLE: somehow the 'to_node' is not in the 'from' branch")
 		}
 
-		var next T
-		var found bool
-
-		for !found {
-			c, ok := root.GetFirstChild()
-			if !ok {
-				break
-			}
-
-			if c == from {
-				next = c
-				found = true
-			}
-		}
-
-		if !found {
+		c, found := root.GetFirstChild()
+		if !found || c != from {
 			break
 		}
 
 		// from is a child of the root. Keep going
-		root = next
+		root = c
+	}
+
+	if root == from {
+		// The whole branch is already in the tree.
+		return tree, nil
 	}
 
 	// From this point onward, anything from 'from' up to 'to' must be
@@ -498,6 +489,8 @@ func PruneBranches[T interface {
 //   - If this function returns only one tree, this is the updated tree. But, if
 //     it returns more than one tree, then we have deleted the root of the tree and
 //     obtained a forest.
+//   - If the root of the tree is removed, the forest is made of the trees of its
+//     remaining children and it is empty if there are none.
 func SkipFilter[T interface {
 	BackwardChild() iter.Seq[T]
 	Child() iter.Seq[T]
@@ -522,6 +515,10 @@ func SkipFilter[T interface {
 	seen := make(map[T]bool)
 	var leaves []T
 
+	// roots are the roots of the forest once the root of the tree is removed.
+	var roots []T
+	var root_removed bool
+
 	f := func(n T) bool {
 		return !seen[n]
 	}
@@ -530,56 +527,70 @@ func SkipFilter[T interface {
 		leaf := frontier[0]
 		seen[leaf] = true
 
-		// Remove any node that has been seen from the frontier.
-		frontier = gcslc.FilterSlice(frontier, f)
+		// Remove any other node that has been seen from the frontier.
+		frontier = append(frontier[:1], gcslc.FilterSlice(frontier[1:], f)...)
 
 		ok := filter(leaf)
 
 		parent, has_parent := leaf.GetParent()
 
 		if !ok {
+			ok := leaf.IsLeaf()
+			if ok {
+				leaves = append(leaves, leaf)
+			}
+
 			if !has_parent {
 				// We reached the root
 				frontier = frontier[1:]
 			} else {
-				ok := leaf.IsLeaf()
-				if ok {
-					leaves = append(leaves, leaf)
-				}
-
 				if !seen[parent] {
 					frontier[0] = parent
 				} else {
 					frontier = frontier[1:]
 				}
 			}
-		} else {
+		} else if !has_parent {
+			// We reached a root and obtained a forest
 			children := leaf.RemoveNode()
 
-			if len(children) != 0 {
-				// We obtained a forest as we reached the root
-				for _, child := range children {
-					forest = append(forest, NewTree(child))
-				}
+			if !root_removed {
+				roots = children
+				root_removed = true
+			} else {
+				idx := slices.Index(roots, leaf)
+				roots = slices.Replace(roots, idx, idx+1, children...)
+			}
 
-				// We reached the root
-				frontier = frontier[1:]
+			frontier = frontier[1:]
+		} else {
+			_ = leaf.RemoveNode()
+
+			if !seen[parent] {
+				frontier[0] = parent
 			} else {
-				if !seen[parent] {
-					frontier[0] = parent
-				} else {
-					frontier = frontier[1:]
+				// The parent was visited while it still had this node as a child.
+				if parent.IsLeaf() {
+					leaves = append(leaves, parent)
 				}
 
-				tree.size--
+				frontier = frontier[1:]
 			}
+
+			tree.size--
 		}
 	}
 
-	if len(forest) == 0 {
+	if !root_removed {
 		tree.leaves = leaves
 
-		forest = []*Tree[T]{tree}
+		return []*Tree[T]{tree}
+	}
+
+	// The trees are built once all nodes are visited as their cached sizes and leaves
+	// would not be updated otherwise.
+	for _, root := range roots {
+		forest = append(forest, NewTree(root))
 	}
 
 	return
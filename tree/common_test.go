@@ -0,0 +1,153 @@
+package tree_test
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// describe renders a tree as an s-expression along with its sorted cached leaves.
+func describe(t *testing.T, tr *tree.Tree[*root.StringNode]) string {
+	t.Helper()
+
+	sexpr, err := tree.Fold(tr, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sexpr + " " + sorted_leaves(tr)
+}
+
+// sorted_leaves returns the labels of the cached leaves of a tree in sorted order.
+func sorted_leaves(tr *tree.Tree[*root.StringNode]) string {
+	var names []string
+
+	for _, leaf := range tr.Leaves() {
+		names = append(names, leaf.Data)
+	}
+
+	slices.Sort(names)
+
+	return join_labels(names)
+}
+
+func TestSkipFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{"nothing", "x", []string{"a(b(d,e),c(f)) d e f"}},
+		{"inner node", "b", []string{"a(d,e,c(f)) d e f"}},
+		{"leaves of a parent", "de", []string{"a(b,c(f)) b f"}},
+		{"whole branch", "cf", []string{"a(b(d,e)) d e"}},
+		{"root", "a", []string{"b(d,e) d e", "c(f) f"}},
+		{"root and a new root", "ab", []string{"d d", "e e", "c(f) f"}},
+		{"everything", "abcdef", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forest := tree.SkipFilter(sample_tree(), func(node *root.StringNode) bool {
+				return strings.Contains(tt.filter, node.Data)
+			})
+
+			var got []string
+
+			for _, tr := range forest {
+				err := tree.Validate(tr)
+				if err != nil {
+					t.Error(err)
+				}
+
+				got = append(got, describe(t, tr))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipFilterRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for trial := range 500 {
+		tr := tree.NewTree(random_tree(r, 5, 6))
+		name := string(rune('a' + r.Intn(6)))
+		keep_root := r.Intn(2) == 0
+
+		forest := tree.SkipFilter(tr, func(node *root.StringNode) bool {
+			if _, ok := node.GetParent(); !ok && keep_root {
+				return false
+			}
+
+			return node.Data == name
+		})
+
+		for _, tr := range forest {
+			err := tree.Validate(tr)
+			if err != nil {
+				t.Fatalf("trial %d: %v", trial, err)
+			}
+
+			for node := range tr.DFS() {
+				if node.Data == name && node != tr.Root() {
+					t.Fatalf("trial %d: %q was not removed", trial, name)
+				}
+			}
+		}
+	}
+}
+
+func TestInsertBranch(t *testing.T) {
+	tr := sample_tree()
+	before := describe(t, tr)
+
+	branch, err := tree.NewBranch(tr.Root().FirstChild.FirstChild)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The branch is already in the tree, so inserting it used to link the last
+	// node of the branch to itself.
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := tree.InsertBranch(tr, branch)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("InsertBranch did not return")
+	}
+
+	if got := describe(t, tr); got != before {
+		t.Errorf("got %q, want %q", got, before)
+	}
+
+	err = tree.Validate(tr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	got, err := tree.InsertBranch(nil, branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Root() != tr.Root() {
+		t.Error("InsertBranch on a nil tree did not start from the top of the branch")
+	}
+}
@@ -0,0 +1,516 @@
+package tree
+
+import (
+	"iter"
+	"slices"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// Cursor is a zipper over a tree: it points to a node of the tree, the focus, and
+// it moves and edits the tree around it. Every edit keeps the cached leaves and
+// size of the tree up to date; so, there is no need to call RegenerateLeaves.
+//
+// The tree must not be edited by other means while the cursor is in use.
+type Cursor[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	Links() Links[T]
+	TreeNoder
+}] struct {
+	// tree is the tree the cursor is bound to.
+	tree *Tree[T]
+
+	// focus is the node the cursor points to.
+	focus T
+}
+
+// NewCursor creates a new cursor that points to the root of the tree.
+//
+// Parameters:
+//   - tree: The tree to bind the cursor to.
+//
+// Returns:
+//   - *Cursor[T]: The new cursor. Nil if an error occurs.
+//   - error: An error if tree is nil.
+func NewCursor[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	Links() Links[T]
+	TreeNoder
+}](tree *Tree[T]) (*Cursor[T], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	}
+
+	return &Cursor[T]{
+		tree:  tree,
+		focus: tree.root,
+	}, nil
+}
+
+// Focus returns the node the cursor points to.
+//
+// Returns:
+//   - T: The focus.
+func (c Cursor[T]) Focus() T {
+	return c.focus
+}
+
+// Tree returns the tree the cursor is bound to.
+//
+// Returns:
+//   - *Tree[T]: The tree. Never returns nil.
+func (c Cursor[T]) Tree() *Tree[T] {
+	return c.tree
+}
+
+// IsRoot checks whether the cursor points to the root of the tree.
+//
+// Returns:
+//   - bool: True if the focus is the root, false otherwise.
+func (c Cursor[T]) IsRoot() bool {
+	return c.focus == c.tree.root
+}
+
+// Index returns the position of the focus among its siblings.
+//
+// Returns:
+//   - int: The index of the focus. 0 for the root.
+func (c Cursor[T]) Index() int {
+	var zero T
+
+	var idx int
+
+	for prev := c.focus.Links().PrevSibling; prev != zero; prev = prev.Links().PrevSibling {
+		idx++
+	}
+
+	return idx
+}
+
+// Up moves the cursor to the parent of the focus.
+//
+// Returns:
+//   - bool: False if the focus is the root, in which case the cursor does not move.
+func (c *Cursor[T]) Up() bool {
+	var zero T
+
+	parent := c.focus.Links().Parent
+	if parent == zero {
+		return false
+	}
+
+	c.focus = parent
+
+	return true
+}
+
+// Down moves the cursor to the i-th child of the focus.
+//
+// Parameters:
+//   - i: The index of the child, starting from 0.
+//
+// Returns:
+//   - bool: False if the focus has no such child, in which case the cursor does
+//     not move.
+func (c *Cursor[T]) Down(i int) bool {
+	if i < 0 {
+		return false
+	}
+
+	var zero T
+
+	child := c.focus.Links().FirstChild
+
+	for ; i > 0 && child != zero; i-- {
+		child = child.Links().NextSibling
+	}
+
+	if child == zero {
+		return false
+	}
+
+	c.focus = child
+
+	return true
+}
+
+// Next moves the cursor to the next sibling of the focus.
+//
+// Returns:
+//   - bool: False if the focus is the last child or the root, in which case the
+//     cursor does not move.
+func (c *Cursor[T]) Next() bool {
+	var zero T
+
+	next := c.focus.Links().NextSibling
+	if next == zero {
+		return false
+	}
+
+	c.focus = next
+
+	return true
+}
+
+// Prev moves the cursor to the previous sibling of the focus.
+//
+// Returns:
+//   - bool: False if the focus is the first child or the root, in which case the
+//     cursor does not move.
+func (c *Cursor[T]) Prev() bool {
+	var zero T
+
+	prev := c.focus.Links().PrevSibling
+	if prev == zero {
+		return false
+	}
+
+	c.focus = prev
+
+	return true
+}
+
+// Root moves the cursor to the root of the tree.
+func (c *Cursor[T]) Root() {
+	c.focus = c.tree.root
+}
+
+// check_detached is a helper method that checks that a node can be attached to the
+// tree.
+//
+// Parameters:
+//   - node: The node to check.
+//
+// Returns:
+//   - []T: The leaves of the node in DFS order.
+//   - int: The size of the node.
+//   - error: An error if the node is nil, has a parent or siblings, is the root of
+//     the tree, or an *ErrCycle if its subtree has a cycle.
+func (c Cursor[T]) check_detached(node T) ([]T, int, error) {
+	var zero T
+
+	if node == zero {
+		return nil, 0, gcers.NewErrNilParameter("node")
+	}
+
+	links := node.Links()
+
+	if links.Parent != zero || links.PrevSibling != zero || links.NextSibling != zero || node == c.tree.root {
+		return nil, 0, gcers.NewErrInvalidParameter("node is already part of a tree")
+	}
+
+	return leaves_and_size_safe(node)
+}
+
+// detach is a helper function that unlinks a node from its parent and siblings
+// while keeping its subtree.
+//
+// Parameters:
+//   - node: The node to detach.
+func detach[T interface {
+	Cleanup() []T
+	LinkChildren(children []T)
+}](node T) {
+	children := node.Cleanup()
+	node.LinkChildren(children)
+}
+
+// prev_leaf is a helper method that returns the leaf that comes right before the
+// subtree of a node in DFS order.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - T: The previous leaf.
+//   - bool: False if no leaf comes before the node.
+func (c Cursor[T]) prev_leaf(node T) (T, bool) {
+	var zero T
+
+	for ; node != zero && node != c.tree.root; node = node.Links().Parent {
+		prev := node.Links().PrevSibling
+		if prev == zero {
+			continue
+		}
+
+		for last := prev.Links().LastChild; last != zero; last = prev.Links().LastChild {
+			prev = last
+		}
+
+		return prev, true
+	}
+
+	return zero, false
+}
+
+// prev_leaf_fn is a helper method that binds prev_leaf to a node.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - func() (T, bool): The bound function.
+func (c Cursor[T]) prev_leaf_fn(node T) func() (T, bool) {
+	return func() (T, bool) {
+		return c.prev_leaf(node)
+	}
+}
+
+// InsertChild inserts a node as the i-th child of the focus. The cursor does not
+// move.
+//
+// Parameters:
+//   - i: The index of the new child. The number of children appends it.
+//   - node: The node to insert, with its subtree. It must not be part of a tree.
+//
+// Returns:
+//   - error: An error if the node cannot be attached or if i is out of range.
+func (c *Cursor[T]) InsertChild(i int, node T) error {
+	leaves, size, err := c.check_detached(node)
+	if err != nil {
+		return err
+	}
+
+	var children []T
+
+	for child := range c.focus.Child() {
+		children = append(children, child)
+	}
+
+	if i < 0 || i > len(children) {
+		return gcers.NewErrInvalidParameter("child index out of range")
+	}
+
+	var old []T
+
+	if len(children) == 0 {
+		old = []T{c.focus}
+	}
+
+	c.focus.LinkChildren(slices.Insert(children, i, node))
+
+	c.tree.splice_leaves(old, leaves, c.prev_leaf_fn(node))
+	c.tree.size += size
+
+	return nil
+}
+
+// siblings is a helper method that returns the parent of the focus and its children.
+//
+// Returns:
+//   - T: The parent of the focus.
+//   - []T: The children of the parent.
+//   - int: The index of the focus among the children.
+//   - error: CursorAtRoot if the focus is the root.
+func (c Cursor[T]) siblings() (T, []T, int, error) {
+	var zero T
+
+	parent := c.focus.Links().Parent
+	if parent == zero {
+		return zero, nil, 0, CursorAtRoot
+	}
+
+	var children []T
+
+	for child := range parent.Child() {
+		children = append(children, child)
+	}
+
+	return parent, children, slices.Index(children, c.focus), nil
+}
+
+// insert_sibling is a helper method that inserts a sibling next to the focus.
+//
+// Parameters:
+//   - node: The node to insert.
+//   - offset: 0 to insert before the focus, 1 to insert after it.
+//
+// Returns:
+//   - error: An error if the node cannot be attached or the focus is the root.
+func (c *Cursor[T]) insert_sibling(node T, offset int) error {
+	leaves, size, err := c.check_detached(node)
+	if err != nil {
+		return err
+	}
+
+	parent, children, idx, err := c.siblings()
+	if err != nil {
+		return err
+	}
+
+	parent.LinkChildren(slices.Insert(children, idx+offset, node))
+
+	c.tree.splice_leaves(nil, leaves, c.prev_leaf_fn(node))
+	c.tree.size += size
+
+	return nil
+}
+
+// InsertBefore inserts a node as the previous sibling of the focus. The cursor does
+// not move.
+//
+// Parameters:
+//   - node: The node to insert, with its subtree. It must not be part of a tree.
+//
+// Returns:
+//   - error: An error if the node cannot be attached, or CursorAtRoot if the focus
+//     is the root.
+func (c *Cursor[T]) InsertBefore(node T) error {
+	return c.insert_sibling(node, 0)
+}
+
+// InsertAfter inserts a node as the next sibling of the focus. The cursor does not
+// move.
+//
+// Parameters:
+//   - node: The node to insert, with its subtree. It must not be part of a tree.
+//
+// Returns:
+//   - error: An error if the node cannot be attached, or CursorAtRoot if the focus
+//     is the root.
+func (c *Cursor[T]) InsertAfter(node T) error {
+	return c.insert_sibling(node, 1)
+}
+
+// Replace replaces the subtree of the focus with a node. The cursor then points to
+// the node.
+//
+// Parameters:
+//   - node: The new node, with its subtree. It must not be part of a tree.
+//
+// Returns:
+//   - T: The old focus, detached from the tree but with its subtree.
+//   - error: An error if the node cannot be attached.
+//
+// Replacing the root replaces the whole tree.
+func (c *Cursor[T]) Replace(node T) (T, error) {
+	leaves, size, err := c.check_detached(node)
+	if err != nil {
+		return *new(T), err
+	}
+
+	old := c.focus
+
+	if old == c.tree.root {
+		c.tree.root = node
+		c.tree.leaves = leaves
+		c.tree.size = size
+		c.focus = node
+
+		return old, nil
+	}
+
+	old_leaves, old_size, err := leaves_and_size_safe(old)
+	if err != nil {
+		return *new(T), err
+	}
+
+	parent, children, idx, _ := c.siblings()
+
+	detach(old)
+
+	children[idx] = node
+	parent.LinkChildren(children)
+
+	c.tree.splice_leaves(old_leaves, leaves, nil)
+	c.tree.size += size - old_size
+	c.focus = node
+
+	return old, nil
+}
+
+// Delete removes the subtree of the focus from the tree. The cursor then points to
+// the next sibling, the previous sibling or the parent; whichever exists first.
+//
+// Returns:
+//   - T: The deleted node, detached from the tree but with its subtree.
+//   - error: CursorAtRoot if the focus is the root, or an *ErrCycle if its subtree
+//     has a cycle.
+func (c *Cursor[T]) Delete() (T, error) {
+	var zero T
+
+	old := c.focus
+	links := old.Links()
+
+	if links.Parent == zero {
+		return zero, CursorAtRoot
+	}
+
+	old_leaves, old_size, err := leaves_and_size_safe(old)
+	if err != nil {
+		return zero, err
+	}
+
+	next := links.NextSibling
+	if next == zero {
+		next = links.PrevSibling
+	}
+
+	if next == zero {
+		next = links.Parent
+	}
+
+	detach(old)
+
+	var added []T
+
+	if links.Parent.IsLeaf() {
+		added = []T{links.Parent}
+	}
+
+	c.tree.splice_leaves(old_leaves, added, nil)
+	c.tree.size -= old_size
+	c.focus = next
+
+	return old, nil
+}
+
+// Wrap inserts a node between the focus and its parent, so that the focus becomes
+// the only child of the node. The cursor then points to the node.
+//
+// Parameters:
+//   - node: The wrapper. It must be a leaf that is not part of a tree.
+//
+// Returns:
+//   - error: An error if the node cannot be attached or is not a leaf.
+//
+// Wrapping the root makes the node the new root of the tree.
+func (c *Cursor[T]) Wrap(node T) error {
+	_, _, err := c.check_detached(node)
+	if err != nil {
+		return err
+	}
+
+	if !node.IsLeaf() {
+		return gcers.NewErrInvalidParameter("node must be a leaf")
+	}
+
+	old := c.focus
+
+	if old == c.tree.root {
+		node.LinkChildren([]T{old})
+
+		c.tree.root = node
+	} else {
+		parent, children, idx, _ := c.siblings()
+
+		detach(old)
+		node.LinkChildren([]T{old})
+
+		children[idx] = node
+		parent.LinkChildren(children)
+	}
+
+	c.tree.size++
+	c.focus = node
+
+	return nil
+}
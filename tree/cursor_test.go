@@ -0,0 +1,223 @@
+package tree_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// must_cursor creates a cursor over the tree.
+func must_cursor(t *testing.T, tr *tree.Tree[*root.StringNode]) *tree.Cursor[*root.StringNode] {
+	t.Helper()
+
+	c, err := tree.NewCursor(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestCursorNavigation(t *testing.T) {
+	c := must_cursor(t, sample_tree())
+
+	steps := []struct {
+		name string
+		move func() bool
+		ok   bool
+		want string
+	}{
+		{"up from the root", c.Up, false, "a"},
+		{"next from the root", c.Next, false, "a"},
+		{"down", func() bool { return c.Down(1) }, true, "c"},
+		{"prev", c.Prev, true, "b"},
+		{"prev from the first child", c.Prev, false, "b"},
+		{"down out of range", func() bool { return c.Down(2) }, false, "b"},
+		{"down to the last child", func() bool { return c.Down(1) }, true, "e"},
+		{"next from the last child", c.Next, false, "e"},
+		{"up", c.Up, true, "b"},
+	}
+
+	for _, step := range steps {
+		if ok := step.move(); ok != step.ok || c.Focus().Data != step.want {
+			t.Fatalf("%s: got (%t, %s), want (%t, %s)", step.name, ok, c.Focus().Data, step.ok, step.want)
+		}
+	}
+
+	if c.Index() != 0 || c.IsRoot() {
+		t.Errorf("got index %d and root %t at b", c.Index(), c.IsRoot())
+	}
+
+	c.Root()
+
+	if !c.IsRoot() {
+		t.Error("Root did not move the cursor to the root")
+	}
+}
+
+func TestCursorEdits(t *testing.T) {
+	tr := sample_tree()
+	c := must_cursor(t, tr)
+
+	c.Down(0)
+
+	err := c.InsertBefore(make_node("x", make_node("y")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Wrap(make_node("w"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Focus().Data != "w" || c.Index() != 1 {
+		t.Fatalf("got focus %s at %d, want w at 1", c.Focus().Data, c.Index())
+	}
+
+	c.Next()
+
+	old, err := c.Replace(make_node("r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if old.Data != "c" || labels(tree.NewTree(old).DFS()) != "c f" {
+		t.Errorf("got %s, want the detached subtree of c", labels(tree.NewTree(old).DFS()))
+	}
+
+	want := tree.NewTree(make_node("a",
+		make_node("x", make_node("y")),
+		make_node("w", make_node("b", make_node("d"), make_node("e"))),
+		make_node("r"),
+	))
+
+	if !same_tree(tr.Root(), want.Root()) {
+		t.Errorf("got\n%s\nwant\n%s", tr, want)
+	}
+
+	err = tree.Validate(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		tr := tree.NewTree(random_tree(r, 4, 26))
+		want := tr.DeepCopy()
+
+		c := must_cursor(t, tr)
+
+		// Walk down to a random node.
+		for r.Intn(3) > 0 {
+			if !c.Down(r.Intn(3)) {
+				break
+			}
+		}
+
+		focus := c.Focus()
+		idx := r.Intn(count_children(focus) + 1)
+
+		node := random_tree(r, 2, 26)
+		size := len(all_nodes(node))
+
+		err := c.InsertChild(idx, node)
+		if err != nil {
+			t.Fatalf("tree %d: %v", i, err)
+		}
+
+		err = tree.Validate(tr)
+		if err != nil {
+			t.Fatalf("tree %d: after the insertion: %v", i, err)
+		}
+
+		if tr.Size() != want.Size()+size {
+			t.Fatalf("tree %d: got size %d, want %d", i, tr.Size(), want.Size()+size)
+		}
+
+		if c.Focus() != focus || !c.Down(idx) || c.Focus() != node {
+			t.Fatalf("tree %d: the node is not the child %d of the focus", i, idx)
+		}
+
+		deleted, err := c.Delete()
+		if err != nil {
+			t.Fatalf("tree %d: %v", i, err)
+		}
+
+		if deleted != node {
+			t.Fatalf("tree %d: deleted %s, want the inserted node", i, deleted.Data)
+		}
+
+		err = tree.Validate(tr)
+		if err != nil {
+			t.Fatalf("tree %d: after the deletion: %v", i, err)
+		}
+
+		if !same_tree(tr.Root(), want.Root()) || tr.Size() != want.Size() {
+			t.Fatalf("tree %d: got\n%s\nwant\n%s", i, tr, want)
+		}
+
+		if len(all_nodes(deleted)) != size || deleted.Parent != nil {
+			t.Fatalf("tree %d: the deleted subtree was not kept whole and detached", i)
+		}
+	}
+}
+
+// count_children returns the number of children of a node.
+func count_children(node *root.StringNode) int {
+	var n int
+
+	for range node.Child() {
+		n++
+	}
+
+	return n
+}
+
+func TestCursorErrors(t *testing.T) {
+	tr := sample_tree()
+	c := must_cursor(t, tr)
+
+	_, err := c.Delete()
+	if !errors.Is(err, tree.CursorAtRoot) {
+		t.Errorf("Delete at the root: got %v, want CursorAtRoot", err)
+	}
+
+	err = c.InsertAfter(make_node("x"))
+	if !errors.Is(err, tree.CursorAtRoot) {
+		t.Errorf("InsertAfter at the root: got %v, want CursorAtRoot", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"index out of range", c.InsertChild(3, make_node("x"))},
+		{"negative index", c.InsertChild(-1, make_node("x"))},
+		{"attached node", c.InsertChild(0, tr.Root().LastChild.FirstChild)},
+		{"root", c.InsertChild(0, tr.Root())},
+		{"non-leaf wrapper", c.Wrap(make_node("w", make_node("x")))},
+	}
+
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Errorf("%s: want an error", tt.name)
+		}
+	}
+
+	// The refused edits left the tree untouched.
+	if !same_tree(tr.Root(), sample_tree().Root()) {
+		t.Errorf("got\n%s\nwant the sample tree", tr)
+	}
+
+	err = tree.Validate(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,330 @@
+package tree
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// DiagramFormat is the language of a text diagram.
+type DiagramFormat int
+
+const (
+	// MermaidGraph is a Mermaid flowchart (e.g., "graph TD").
+	MermaidGraph DiagramFormat = iota
+
+	// MermaidMindmap is a Mermaid mindmap.
+	MermaidMindmap
+
+	// PlantUMLMindmap is a PlantUML mindmap.
+	PlantUMLMindmap
+)
+
+// String implements the fmt.Stringer interface.
+func (f DiagramFormat) String() string {
+	switch f {
+	case MermaidGraph:
+		return "mermaid graph"
+	case MermaidMindmap:
+		return "mermaid mindmap"
+	case PlantUMLMindmap:
+		return "plantuml mindmap"
+	default:
+		return "unknown format"
+	}
+}
+
+// DiagramOptions are the options of the text diagram exporter. The zero value is
+// a top-down Mermaid graph of the whole tree.
+type DiagramOptions[T TreeNoder] struct {
+	// Format is the language of the diagram.
+	Format DiagramFormat
+
+	// Direction is the direction of a MermaidGraph: "TD" (default), "TB", "BT",
+	// "LR" or "RL".
+	Direction string
+
+	// MaxDepth is the depth, where the root is at depth 0, after which nodes are
+	// replaced by a single "..." node. Zero or less means no limit.
+	MaxDepth int
+
+	// Label returns the label of a node. Defaults to the String of the node. Labels
+	// are escaped by the exporter.
+	Label func(node T) string
+
+	// Classes returns the CSS classes of a node (PlantUML stereotypes for
+	// PlantUMLMindmap). Optional.
+	Classes func(node T) []string
+
+	// ClassDefs are the class definitions of a MermaidGraph, by class name
+	// (e.g., {"hot": "fill:#f96"}). Mindmaps take their classes from the CSS of
+	// the page instead.
+	ClassDefs map[string]string
+}
+
+// mermaid_escape is a helper function that escapes a label for a quoted Mermaid
+// string.
+//
+// Parameters:
+//   - str: The label to escape.
+//
+// Returns:
+//   - string: The escaped label, without the quotes.
+func mermaid_escape(str string) string {
+	var builder strings.Builder
+
+	for _, r := range str {
+		switch r {
+		case '#':
+			builder.WriteString("#35;")
+		case '"':
+			builder.WriteString("#quot;")
+		case '<':
+			builder.WriteString("#lt;")
+		case '>':
+			builder.WriteString("#gt;")
+		case '\n':
+			builder.WriteString("<br/>")
+		case '\r':
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// plantuml_escape is a helper function that escapes the characters of a label
+// that PlantUML would otherwise read as markup; such as the depth stars, the
+// end of a multiline label, creole tags or colors.
+//
+// Parameters:
+//   - str: The label to escape.
+//
+// Returns:
+//   - string: The escaped label.
+func plantuml_escape(str string) string {
+	var builder strings.Builder
+
+	for _, r := range str {
+		switch r {
+		case '\\', '*', ';', '<', '>', '[', ']':
+			builder.WriteRune('\\')
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+// plantuml_label is a helper function that formats a label for a PlantUML
+// mindmap node.
+//
+// Parameters:
+//   - str: The label.
+//
+// Returns:
+//   - string: The escaped label, in the multiline ":...;" form if it spans several
+//     lines.
+func plantuml_label(str string) string {
+	str = plantuml_escape(strings.ReplaceAll(str, "\r", ""))
+
+	if !strings.Contains(str, "\n") {
+		return " " + str
+	}
+
+	return ":" + str + ";"
+}
+
+// WriteDiagram writes the tree as a Mermaid or PlantUML text diagram.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tree: The tree to write.
+//   - opts: The options of the exporter.
+//
+// Returns:
+//   - error: An error if w or tree is nil, if the options are invalid, or if the
+//     write fails.
+//
+// Nodes are named n0, n1, ... in DFS order, so the IDs are stable as long as the
+// shape of the tree does not change.
+func WriteDiagram[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](w io.Writer, tree *Tree[T], opts DiagramOptions[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tree == nil {
+		return gcers.NewErrNilParameter("tree")
+	}
+
+	dir := opts.Direction
+	if dir == "" {
+		dir = "TD"
+	}
+
+	switch opts.Format {
+	case MermaidGraph:
+		if !slices.Contains([]string{"TD", "TB", "BT", "LR", "RL"}, dir) {
+			return gcers.NewErrInvalidParameter("unknown direction " + strconv.Quote(dir))
+		}
+	case MermaidMindmap, PlantUMLMindmap:
+	default:
+		return gcers.NewErrInvalidParameter("unknown diagram format " + strconv.Itoa(int(opts.Format)))
+	}
+
+	label_fn := opts.Label
+	if label_fn == nil {
+		label_fn = T.String
+	}
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	switch opts.Format {
+	case MermaidGraph:
+		tw.write("graph ", dir, "\n")
+	case MermaidMindmap:
+		tw.write("mindmap\n")
+	case PlantUMLMindmap:
+		tw.write("@startmindmap\n")
+	}
+
+	type StackElement struct {
+		node   T
+		parent string
+		depth  int
+	}
+
+	classes := make(map[string][]string)
+	var count int
+
+	stack := []StackElement{{node: tree.root}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		id := "n" + strconv.Itoa(count)
+		count++
+
+		var node_classes []string
+
+		if opts.Classes != nil {
+			node_classes = opts.Classes(top.node)
+		}
+
+		label := label_fn(top.node)
+
+		switch opts.Format {
+		case MermaidGraph:
+			tw.write("\t", id, "[\"", mermaid_escape(label), "\"]\n")
+
+			if top.parent != "" {
+				tw.write("\t", top.parent, " --> ", id, "\n")
+			}
+
+			for _, class := range node_classes {
+				classes[class] = append(classes[class], id)
+			}
+		case MermaidMindmap:
+			indent := strings.Repeat("  ", top.depth+1)
+
+			tw.write(indent, id, "[\"", mermaid_escape(label), "\"]\n")
+
+			if len(node_classes) > 0 {
+				tw.write(indent, "  :::", strings.Join(node_classes, " "), "\n")
+			}
+		case PlantUMLMindmap:
+			tw.write(strings.Repeat("*", top.depth+1), plantuml_label(label))
+
+			for _, class := range node_classes {
+				tw.write(" <<", class, ">>")
+			}
+
+			tw.write("\n")
+		}
+
+		if top.node.IsLeaf() {
+			continue
+		}
+
+		if opts.MaxDepth > 0 && top.depth >= opts.MaxDepth {
+			more_id := id + "_more"
+			more := "... (" + strconv.Itoa(count_children(top.node)) + " children)"
+
+			switch opts.Format {
+			case MermaidGraph:
+				tw.write("\t", more_id, "[\"", mermaid_escape(more), "\"]\n")
+				tw.write("\t", id, " -.-> ", more_id, "\n")
+			case MermaidMindmap:
+				tw.write(strings.Repeat("  ", top.depth+2), more_id, "[\"", mermaid_escape(more), "\"]\n")
+			case PlantUMLMindmap:
+				tw.write(strings.Repeat("*", top.depth+2), " ", more, "\n")
+			}
+
+			continue
+		}
+
+		for child := range top.node.BackwardChild() {
+			stack = append(stack, StackElement{
+				node:   child,
+				parent: id,
+				depth:  top.depth + 1,
+			})
+		}
+	}
+
+	switch opts.Format {
+	case MermaidGraph:
+		for _, name := range slices.Sorted(maps.Keys(opts.ClassDefs)) {
+			tw.write("\tclassDef ", name, " ", opts.ClassDefs[name], ";\n")
+		}
+
+		for _, class := range slices.Sorted(maps.Keys(classes)) {
+			tw.write("\tclass ", strings.Join(classes[class], ","), " ", class, ";\n")
+		}
+	case PlantUMLMindmap:
+		tw.write("@endmindmap\n")
+	}
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
+
+// Diagram returns the tree as a Mermaid or PlantUML text diagram.
+//
+// Parameters:
+//   - opts: The options of the exporter.
+//
+// Returns:
+//   - string: The text diagram.
+//   - error: An error if the options are invalid.
+//
+// See WriteDiagram for more details.
+func (t *Tree[T]) Diagram(opts DiagramOptions[T]) (string, error) {
+	var builder strings.Builder
+
+	err := WriteDiagram(&builder, t, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,75 @@
+package tree_test
+
+import (
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestDiagram(t *testing.T) {
+	tr := sample_tree()
+	tr.Root().FirstChild.LastChild.Data = "two\nlines;"
+	tr.Root().LastChild.FirstChild.Data = "x*;<[y]"
+
+	label := func(node *root.StringNode) string {
+		return node.Data
+	}
+
+	tests := []struct {
+		name string
+		opts tree.DiagramOptions[*root.StringNode]
+		want string
+	}{
+		{
+			name: "mermaid graph",
+			opts: tree.DiagramOptions[*root.StringNode]{Format: tree.MermaidGraph, Label: label},
+			want: "graph TD\n\tn0[\"a\"]\n\tn1[\"b\"]\n\tn0 --> n1\n\tn2[\"d\"]\n\tn1 --> n2\n" +
+				"\tn3[\"two<br/>lines;\"]\n\tn1 --> n3\n\tn4[\"c\"]\n\tn0 --> n4\n\tn5[\"x*;#lt;[y]\"]\n\tn4 --> n5\n",
+		},
+		{
+			name: "mermaid mindmap",
+			opts: tree.DiagramOptions[*root.StringNode]{Format: tree.MermaidMindmap, Label: label},
+			want: "mindmap\n  n0[\"a\"]\n    n1[\"b\"]\n      n2[\"d\"]\n      n3[\"two<br/>lines;\"]\n" +
+				"    n4[\"c\"]\n      n5[\"x*;#lt;[y]\"]\n",
+		},
+		{
+			name: "plantuml mindmap",
+			opts: tree.DiagramOptions[*root.StringNode]{Format: tree.PlantUMLMindmap, Label: label},
+			want: "@startmindmap\n* a\n** b\n*** d\n***:two\nlines\\;;\n** c\n*** x\\*\\;\\<\\[y\\]\n@endmindmap\n",
+		},
+		{
+			name: "max depth",
+			opts: tree.DiagramOptions[*root.StringNode]{Format: tree.PlantUMLMindmap, Label: label, MaxDepth: 1},
+			want: "@startmindmap\n* a\n** b\n*** ... (2 children)\n** c\n*** ... (1 children)\n@endmindmap\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.Diagram(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagramErrors(t *testing.T) {
+	tr := sample_tree()
+
+	_, err := tr.Diagram(tree.DiagramOptions[*root.StringNode]{Direction: "XY"})
+	if !strings.Contains(error_chain(err), `unknown direction "XY"`) {
+		t.Errorf("got %v, want an unknown direction", err)
+	}
+
+	err = tree.WriteDiagram(failing_writer{}, tr, tree.DiagramOptions[*root.StringNode]{})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("failing writer: got %v, want the write error", err)
+	}
+}
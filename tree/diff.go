@@ -0,0 +1,1298 @@
+package tree
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// EditKind is the kind of an edit.
+type EditKind int
+
+const (
+	// EditInsert inserts a subtree.
+	EditInsert EditKind = iota
+
+	// EditDelete deletes a subtree.
+	EditDelete
+
+	// EditUpdate replaces a node, but not its children, with another one.
+	EditUpdate
+
+	// EditMove moves a subtree.
+	EditMove
+)
+
+// String implements the fmt.Stringer interface.
+func (k EditKind) String() string {
+	switch k {
+	case EditInsert:
+		return "insert"
+	case EditDelete:
+		return "delete"
+	case EditUpdate:
+		return "update"
+	case EditMove:
+		return "move"
+	default:
+		return "unknown edit"
+	}
+}
+
+// Edit is an edit of an edit script. Paths are the sibling indices of the nodes
+// from the root, as in Violation, and they are resolved on the tree as left by
+// the previous edits.
+type Edit[T TreeNoder] struct {
+	// Kind is the kind of the edit.
+	Kind EditKind
+
+	// Path is the path of the node to delete, update or move; or, for EditInsert,
+	// the path of the parent of the new subtree.
+	Path []int
+
+	// To is the path of the new parent of a moved node, resolved once the node is
+	// detached. Only for EditMove.
+	To []int
+
+	// Index is the position of the new or moved subtree among the children of its
+	// new parent. Only for EditInsert and EditMove.
+	Index int
+
+	// Node is the subtree to insert for EditInsert, or the new node, without
+	// children, for EditUpdate. Zero otherwise.
+	Node T
+
+	// Old is a copy, without children, of the node expected at Path. Zero for
+	// EditInsert.
+	Old T
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Format:
+//
+//	insert /0 [2]: <node>
+//	delete /0/1: <old>
+//	update /0/1: <old> -> <node>
+//	move /0/1 -> /2 [0]: <old>
+func (e Edit[T]) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(e.Kind.String())
+	builder.WriteRune(' ')
+	builder.WriteString(format_path(e.Path))
+
+	switch e.Kind {
+	case EditInsert:
+		builder.WriteString(" [")
+		builder.WriteString(strconv.Itoa(e.Index))
+		builder.WriteString("]: ")
+		builder.WriteString(e.Node.String())
+	case EditUpdate:
+		builder.WriteString(": ")
+		builder.WriteString(e.Old.String())
+		builder.WriteString(" -> ")
+		builder.WriteString(e.Node.String())
+	case EditMove:
+		builder.WriteString(" -> ")
+		builder.WriteString(format_path(e.To))
+		builder.WriteString(" [")
+		builder.WriteString(strconv.Itoa(e.Index))
+		builder.WriteString("]: ")
+		builder.WriteString(e.Old.String())
+	default:
+		builder.WriteString(": ")
+		builder.WriteString(e.Old.String())
+	}
+
+	return builder.String()
+}
+
+// EditScript is the result of Diff: the edits that turn a tree into another one,
+// and what is needed to render them.
+type EditScript[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// Edits are the edits, in the order they must be applied.
+	Edits []Edit[T]
+
+	// eq is the node equality function.
+	eq func(a, b T) bool
+
+	// new_root is the root of a copy of the new tree.
+	new_root T
+
+	// news are the nodes of the copy of the new tree.
+	news map[T]struct{}
+
+	// partners maps the matched nodes of the old tree to the ones of the copy of
+	// the new tree, and vice versa.
+	partners map[T]T
+
+	// moved are the moved nodes, in both trees.
+	moved map[T]struct{}
+
+	// updated are the updated nodes of the copy of the new tree.
+	updated map[T]struct{}
+}
+
+// differ is the state of the matching of Diff.
+type differ[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// eq is the node equality function.
+	eq func(a, b T) bool
+
+	// a2b and b2a are the matched nodes of the old and the new tree.
+	a2b, b2a map[T]T
+}
+
+// diff_pair is a pair of matched nodes whose children are to be matched.
+type diff_pair[T TreeNoder] struct {
+	a, b T
+}
+
+// equal is a helper method that checks whether two subtrees are equal.
+//
+// Parameters:
+//   - a: The first subtree.
+//   - b: The second subtree.
+//
+// Returns:
+//   - bool: True if the subtrees have the same shape and equal nodes.
+func (d *differ[T]) equal(a, b T) bool {
+	stack := []diff_pair[T]{{a: a, b: b}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !d.eq(top.a, top.b) {
+			return false
+		}
+
+		ac := slices.Collect(top.a.Child())
+		bc := slices.Collect(top.b.Child())
+
+		if len(ac) != len(bc) {
+			return false
+		}
+
+		for i := range ac {
+			stack = append(stack, diff_pair[T]{a: ac[i], b: bc[i]})
+		}
+	}
+
+	return true
+}
+
+// unmatched is a helper method that checks whether no node of a subtree is matched.
+//
+// Parameters:
+//   - node: The root of the subtree.
+//   - matched: The matched nodes of its tree.
+//
+// Returns:
+//   - bool: True if no node of the subtree is in matched.
+func (d *differ[T]) unmatched(node T, matched map[T]T) bool {
+	stack := []T{node}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		_, ok := matched[top]
+		if ok {
+			return false
+		}
+
+		for child := range top.Child() {
+			stack = append(stack, child)
+		}
+	}
+
+	return true
+}
+
+// match_subtrees is a helper method that matches two equal subtrees node by node.
+//
+// Parameters:
+//   - a: The subtree of the old tree.
+//   - b: The subtree of the new tree.
+func (d *differ[T]) match_subtrees(a, b T) {
+	stack := []diff_pair[T]{{a: a, b: b}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d.a2b[top.a] = top.b
+		d.b2a[top.b] = top.a
+
+		ac := slices.Collect(top.a.Child())
+		bc := slices.Collect(top.b.Child())
+
+		for i := range ac {
+			stack = append(stack, diff_pair[T]{a: ac[i], b: bc[i]})
+		}
+	}
+}
+
+// lcs is a helper function that computes a longest common subsequence.
+//
+// Parameters:
+//   - n: The length of the first sequence.
+//   - m: The length of the second sequence.
+//   - eq: The function that checks whether the i-th element of the first sequence
+//     is equal to the j-th element of the second one.
+//
+// Returns:
+//   - [][2]int: The indices of the common elements, in order.
+func lcs(n, m int, eq func(i, j int) bool) [][2]int {
+	var prefix [][2]int
+
+	for len(prefix) < min(n, m) && eq(len(prefix), len(prefix)) {
+		prefix = append(prefix, [2]int{len(prefix), len(prefix)})
+	}
+
+	lo := len(prefix)
+
+	var suffix int
+
+	for lo+suffix < min(n, m) && eq(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	// table[i][j] is the length of the LCS of the elements from lo+i and lo+j on.
+	rows, cols := n-lo-suffix, m-lo-suffix
+
+	table := make([][]int, rows+1)
+	for i := range table {
+		table[i] = make([]int, cols+1)
+	}
+
+	for i := rows - 1; i >= 0; i-- {
+		for j := cols - 1; j >= 0; j-- {
+			if eq(lo+i, lo+j) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	result := prefix
+
+	for i, j := 0, 0; i < rows && j < cols; {
+		switch {
+		case eq(lo+i, lo+j) && table[i][j] == table[i+1][j+1]+1:
+			result = append(result, [2]int{lo + i, lo + j})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		result = append(result, [2]int{n - k, m - k})
+	}
+
+	return result
+}
+
+// align is a helper method that matches the unmatched children of two matched
+// nodes: the equal subtrees first, then the equal nodes between them.
+//
+// Parameters:
+//   - pair: The matched nodes.
+//
+// Returns:
+//   - []diff_pair[T]: The newly matched nodes whose children are to be matched.
+func (d *differ[T]) align(pair diff_pair[T]) []diff_pair[T] {
+	var ac, bc []T
+
+	for child := range pair.a.Child() {
+		_, ok := d.a2b[child]
+		if !ok {
+			ac = append(ac, child)
+		}
+	}
+
+	for child := range pair.b.Child() {
+		_, ok := d.b2a[child]
+		if !ok {
+			bc = append(bc, child)
+		}
+	}
+
+	// Moves may have matched some descendants already; such subtrees are only
+	// matched node by node.
+	free_a := make([]bool, len(ac))
+	for i, child := range ac {
+		free_a[i] = d.unmatched(child, d.a2b)
+	}
+
+	free_b := make([]bool, len(bc))
+	for j, child := range bc {
+		free_b[j] = d.unmatched(child, d.b2a)
+	}
+
+	anchors := lcs(len(ac), len(bc), func(i, j int) bool {
+		return free_a[i] && free_b[j] && d.equal(ac[i], bc[j])
+	})
+
+	var pairs []diff_pair[T]
+
+	prev_i, prev_j := 0, 0
+
+	for k := 0; k <= len(anchors); k++ {
+		end_i, end_j := len(ac), len(bc)
+
+		if k < len(anchors) {
+			end_i, end_j = anchors[k][0], anchors[k][1]
+			d.match_subtrees(ac[end_i], bc[end_j])
+		}
+
+		gap_a, gap_b := ac[prev_i:end_i], bc[prev_j:end_j]
+
+		for _, idx := range lcs(len(gap_a), len(gap_b), func(i, j int) bool {
+			return d.eq(gap_a[i], gap_b[j])
+		}) {
+			a, b := gap_a[idx[0]], gap_b[idx[1]]
+
+			d.a2b[a] = b
+			d.b2a[b] = a
+
+			pairs = append(pairs, diff_pair[T]{a: a, b: b})
+		}
+
+		prev_i, prev_j = end_i+1, end_j+1
+	}
+
+	return pairs
+}
+
+// subtree_info is what find_moves knows about a subtree.
+type subtree_info struct {
+	// shape is the hash of the shape of the subtree; that is, of its structure
+	// regardless of the nodes. Equal subtrees have the same shape.
+	shape uint64
+
+	// free is the flag that indicates whether no node of the subtree is matched.
+	free bool
+}
+
+// subtree_infos is a helper method that computes the info of every subtree.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - matched: The matched nodes of the tree.
+//
+// Returns:
+//   - map[T]subtree_info: The info of every node of the tree.
+func (d *differ[T]) subtree_infos(root T, matched map[T]T) map[T]subtree_info {
+	// FNV-1a offset basis and prime.
+	const (
+		basis uint64 = 14695981039346656037
+		prime uint64 = 1099511628211
+	)
+
+	_, infos, _ := FoldMemo(root, func(node T, children []subtree_info) (subtree_info, error) {
+		_, ok := matched[node]
+
+		info := subtree_info{
+			shape: basis,
+			free:  !ok,
+		}
+
+		for _, c := range children {
+			info.shape = (info.shape ^ c.shape) * prime
+			info.free = info.free && c.free
+		}
+
+		info.shape = (info.shape ^ uint64(len(children))) * prime
+
+		return info, nil
+	})
+
+	return infos
+}
+
+// find_moves is a helper method that matches the unmatched subtrees of the new
+// tree with equal unmatched subtrees of the old tree, wherever they are.
+//
+// Parameters:
+//   - old_root: The root of the old tree.
+//   - new_root: The root of the new tree.
+//
+// The candidates of the old tree are bucketed by shape, so that a subtree of the
+// new tree is only compared with the old subtrees that can be equal to it.
+func (d *differ[T]) find_moves(old_root, new_root T) {
+	olds := d.subtree_infos(old_root, d.a2b)
+
+	buckets := make(map[uint64][]T)
+
+	for node := range (&Tree[T]{root: old_root}).DFS() {
+		info := olds[node]
+		if info.free {
+			buckets[info.shape] = append(buckets[info.shape], node)
+		}
+	}
+
+	if len(buckets) == 0 {
+		return
+	}
+
+	// claim marks a as matched, together with its ancestors as they are no longer
+	// free. Descendants need not be marked as they are in a2b.
+	claim := func(a T) {
+		for {
+			info, ok := olds[a]
+			if !ok || !info.free {
+				return
+			}
+
+			info.free = false
+			olds[a] = info
+
+			a, ok = a.GetParent()
+			if !ok {
+				return
+			}
+		}
+	}
+
+	news := d.subtree_infos(new_root, d.b2a)
+
+	stack := []T{new_root}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		info := news[top]
+
+		found := false
+
+		if info.free {
+			bucket := buckets[info.shape]
+
+			for i, a := range bucket {
+				_, ok := d.a2b[a]
+				if ok || !olds[a].free || !d.equal(a, top) {
+					continue
+				}
+
+				d.match_subtrees(a, top)
+				claim(a)
+
+				buckets[info.shape] = slices.Delete(bucket, i, i+1)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			for child := range top.BackwardChild() {
+				stack = append(stack, child)
+			}
+		}
+	}
+}
+
+// zip is a helper method that matches, in order, the children of two matched
+// nodes that are still unmatched; these become updates.
+//
+// Parameters:
+//   - pair: The matched nodes.
+//
+// Returns:
+//   - []diff_pair[T]: The newly matched nodes.
+func (d *differ[T]) zip(pair diff_pair[T]) []diff_pair[T] {
+	var ua, ub []T
+
+	for child := range pair.a.Child() {
+		_, ok := d.a2b[child]
+		if !ok {
+			ua = append(ua, child)
+		}
+	}
+
+	for child := range pair.b.Child() {
+		_, ok := d.b2a[child]
+		if !ok {
+			ub = append(ub, child)
+		}
+	}
+
+	var pairs []diff_pair[T]
+
+	for i := 0; i < min(len(ua), len(ub)); i++ {
+		d.a2b[ua[i]] = ub[i]
+		d.b2a[ub[i]] = ua[i]
+
+		pairs = append(pairs, diff_pair[T]{a: ua[i], b: ub[i]})
+	}
+
+	return pairs
+}
+
+// copy_subtree is a helper function that deeply copies a subtree without recursion.
+//
+// Parameters:
+//   - root: The root of the subtree.
+//
+// Returns:
+//   - T: The copy.
+//   - map[T]T: The copy of every node of the subtree.
+func copy_subtree[T interface {
+	Child() iter.Seq[T]
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](root T) (T, map[T]T) {
+	copies := make(map[T]T)
+
+	var order []T
+
+	stack := []T{root}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		copies[top] = top.Copy()
+		order = append(order, top)
+
+		for child := range top.Child() {
+			stack = append(stack, child)
+		}
+	}
+
+	for _, node := range order {
+		var children []T
+
+		for child := range node.Child() {
+			children = append(children, copies[child])
+		}
+
+		copies[node].LinkChildren(children)
+	}
+
+	return copies[root], copies
+}
+
+// node_path is a helper function that returns the path of a node.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - []int: The sibling indices from the root to the node.
+func node_path[T interface {
+	Child() iter.Seq[T]
+	GetParent() (T, bool)
+	TreeNoder
+}](node T) []int {
+	var path []int
+
+	for parent, ok := node.GetParent(); ok; parent, ok = node.GetParent() {
+		var idx int
+
+		for child := range parent.Child() {
+			if child == node {
+				break
+			}
+
+			idx++
+		}
+
+		path = append(path, idx)
+		node = parent
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+// resolve_path is a helper function that returns the node at a path.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - path: The path of the node.
+//
+// Returns:
+//   - T: The node.
+//   - error: An error if no node is at the path.
+func resolve_path[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](root T, path []int) (T, error) {
+	node := root
+
+	for _, idx := range path {
+		found := false
+
+		var i int
+
+		for child := range node.Child() {
+			if i == idx {
+				node = child
+				found = true
+
+				break
+			}
+
+			i++
+		}
+
+		if !found {
+			return *new(T), errors.New("no node at this path")
+		}
+	}
+
+	return node, nil
+}
+
+// insert_child is a helper function that inserts a node among the children of
+// another one.
+//
+// Parameters:
+//   - parent: The parent.
+//   - idx: The position of the node. Must be in range.
+//   - node: The node to insert, without parent nor siblings.
+func insert_child[T interface {
+	Child() iter.Seq[T]
+	LinkChildren(children []T)
+}](parent T, idx int, node T) {
+	children := slices.Collect(parent.Child())
+	parent.LinkChildren(slices.Insert(children, idx, node))
+}
+
+// replace_node is a helper function that puts a node in the place of another one,
+// with its children.
+//
+// Parameters:
+//   - old: The node to replace.
+//   - node: The new node, without parent, siblings nor children.
+func replace_node[T interface {
+	Child() iter.Seq[T]
+	Cleanup() []T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}](old, node T) {
+	parent, ok := old.GetParent()
+
+	var siblings []T
+
+	if ok {
+		siblings = slices.Collect(parent.Child())
+	}
+
+	idx := slices.Index(siblings, old)
+
+	node.LinkChildren(old.Cleanup())
+
+	if ok {
+		siblings[idx] = node
+		parent.LinkChildren(siblings)
+	}
+}
+
+// longest_increasing is a helper function that returns a longest strictly
+// increasing subsequence.
+//
+// Parameters:
+//   - values: The values.
+//
+// Returns:
+//   - []bool: Whether each value is part of the subsequence.
+func longest_increasing(values []int) []bool {
+	// tails[k] is the index of the smallest value ending an increasing
+	// subsequence of length k+1.
+	var tails []int
+
+	prev := make([]int, len(values))
+
+	for i, v := range values {
+		k := sort.Search(len(tails), func(k int) bool {
+			return values[tails[k]] >= v
+		})
+
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	in := make([]bool, len(values))
+
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			in[i] = true
+		}
+	}
+
+	return in
+}
+
+// Diff computes an edit script that turns a tree into another one.
+//
+// Parameters:
+//   - old: The tree before the changes.
+//   - new: The tree after the changes.
+//   - eq: The function that checks whether two nodes are equal, regardless of
+//     their children (e.g., same kind and value).
+//
+// Returns:
+//   - *EditScript[T]: The edit script. Never returns nil on success.
+//   - error: An error if a parameter is nil.
+//
+// The roots are always matched. Then, the children of matched nodes are matched
+// by longest common subsequence: first the equal subtrees, then the equal nodes.
+// Unmatched subtrees of the new tree that are equal to unmatched subtrees of the
+// old one become moves, and the remaining children of matched nodes are paired in
+// order as updates. The script is small, but not always minimal. Neither tree is
+// modified and the script does not share nodes with them.
+func Diff[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}](old, new *Tree[T], eq func(a, b T) bool) (*EditScript[T], error) {
+	if old == nil {
+		return nil, gcers.NewErrNilParameter("old")
+	} else if new == nil {
+		return nil, gcers.NewErrNilParameter("new")
+	} else if eq == nil {
+		return nil, gcers.NewErrNilParameter("eq")
+	}
+
+	// The new tree is copied so that the nodes of both trees are distinct, even
+	// when a tree is compared with itself.
+	new_root, copies := copy_subtree(new.root)
+
+	d := &differ[T]{
+		eq:  eq,
+		a2b: make(map[T]T),
+		b2a: make(map[T]T),
+	}
+
+	d.a2b[old.root] = new_root
+	d.b2a[new_root] = old.root
+
+	pairs := []diff_pair[T]{{a: old.root, b: new_root}}
+
+	for i := 0; i < len(pairs); i++ {
+		pairs = append(pairs, d.align(pairs[i])...)
+	}
+
+	d.find_moves(old.root, new_root)
+
+	aligned := len(pairs)
+
+	for i := 0; i < len(pairs); i++ {
+		if i >= aligned {
+			pairs = append(pairs, d.align(pairs[i])...)
+		}
+
+		pairs = append(pairs, d.zip(pairs[i])...)
+	}
+
+	s := &EditScript[T]{
+		eq:       eq,
+		new_root: new_root,
+		news:     make(map[T]struct{}, len(copies)),
+		partners: make(map[T]T, 2*len(d.a2b)),
+		moved:    make(map[T]struct{}),
+		updated:  make(map[T]struct{}),
+	}
+
+	for _, b := range copies {
+		s.news[b] = struct{}{}
+	}
+
+	for a, b := range d.a2b {
+		s.partners[a] = b
+		s.partners[b] = a
+	}
+
+	s.write_edits(d, old.root)
+
+	return s, nil
+}
+
+// write_edits is a helper method that computes the edits on a working copy of the
+// old tree, so that every path is valid when the edit is applied.
+//
+// Parameters:
+//   - d: The matching.
+//   - old_root: The root of the old tree.
+func (s *EditScript[T]) write_edits(d *differ[T], old_root T) {
+	root, copies := copy_subtree(old_root)
+
+	// w2b and b2w match the working copy with the new tree, and w2a the working
+	// copy with the old tree.
+	w2b := make(map[T]T, len(d.a2b))
+	b2w := make(map[T]T, len(d.a2b))
+	w2a := make(map[T]T, len(copies))
+
+	for a, w := range copies {
+		w2a[w] = a
+
+		b, ok := d.a2b[a]
+		if ok {
+			w2b[w] = b
+			b2w[b] = w
+		}
+	}
+
+	update := func(x, y T) T {
+		if s.eq(x, y) {
+			return x
+		}
+
+		s.Edits = append(s.Edits, Edit[T]{
+			Kind: EditUpdate,
+			Path: node_path(x),
+			Node: y.Copy(),
+			Old:  x.Copy(),
+		})
+
+		s.updated[y] = struct{}{}
+
+		nx := y.Copy()
+		replace_node(x, nx)
+
+		delete(w2b, x)
+		w2b[nx], b2w[y], w2a[nx] = y, nx, w2a[x]
+
+		return nx
+	}
+
+	root = update(root, s.new_root)
+
+	queue := []T{s.new_root}
+
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+
+		w := b2w[b]
+		bc := slices.Collect(b.Child())
+
+		positions := make(map[T]int, len(bc))
+		for i, y := range bc {
+			positions[y] = i
+		}
+
+		// The children that are already in the right order stay; the others are
+		// moved after the last placed child.
+		var kept []T
+		var order []int
+
+		for x := range w.Child() {
+			y, ok := w2b[x]
+			if !ok {
+				continue
+			}
+
+			idx, ok := positions[y]
+			if ok {
+				kept = append(kept, x)
+				order = append(order, idx)
+			}
+		}
+
+		fixed := make(map[T]struct{}, len(kept))
+
+		for i, in := range longest_increasing(order) {
+			if in {
+				fixed[kept[i]] = struct{}{}
+			}
+		}
+
+		var last T
+		var has_last bool
+
+		next_index := func() int {
+			if !has_last {
+				return 0
+			}
+
+			p := node_path(last)
+
+			return p[len(p)-1] + 1
+		}
+
+		for _, y := range bc {
+			x, ok := b2w[y]
+
+			if !ok {
+				full := d.unmatched(y, d.b2a)
+
+				node := y.Copy()
+				if full {
+					node, _ = copy_subtree(y)
+				}
+
+				idx := next_index()
+
+				s.Edits = append(s.Edits, Edit[T]{
+					Kind:  EditInsert,
+					Path:  node_path(w),
+					Index: idx,
+					Node:  node,
+				})
+
+				x = y.Copy()
+				w2b[x], b2w[y] = y, x
+
+				insert_child(w, idx, x)
+
+				if full {
+					// The rest of the subtree is inserted with it; the working copy
+					// only needs the node itself from now on.
+					last, has_last = x, true
+					continue
+				}
+			} else {
+				_, is_fixed := fixed[x]
+
+				if !is_fixed {
+					from := node_path(x)
+					old := x.Copy()
+
+					detach(x)
+
+					idx := next_index()
+
+					s.Edits = append(s.Edits, Edit[T]{
+						Kind:  EditMove,
+						Path:  from,
+						To:    node_path(w),
+						Index: idx,
+						Old:   old,
+					})
+
+					insert_child(w, idx, x)
+
+					s.moved[y] = struct{}{}
+					s.moved[w2a[x]] = struct{}{}
+				}
+
+				x = update(x, y)
+			}
+
+			last, has_last = x, true
+
+			if !y.IsLeaf() {
+				queue = append(queue, y)
+			}
+		}
+	}
+
+	// Every matched node is in place; so, the unmatched nodes left are the roots
+	// of subtrees without matched nodes.
+	var deleted []T
+
+	for node := range (&Tree[T]{root: root}).DFS() {
+		_, ok := w2b[node]
+		if ok {
+			continue
+		}
+
+		parent, _ := node.GetParent()
+
+		_, ok = w2b[parent]
+		if ok {
+			deleted = append(deleted, node)
+		}
+	}
+
+	for i := len(deleted) - 1; i >= 0; i-- {
+		node := deleted[i]
+
+		s.Edits = append(s.Edits, Edit[T]{
+			Kind: EditDelete,
+			Path: node_path(node),
+			Old:  node.Copy(),
+		})
+	}
+}
+
+// Apply applies an edit script to a copy of a tree.
+//
+// Parameters:
+//   - tree: The tree to apply the script to.
+//   - script: The edit script, as computed by Diff.
+//
+// Returns:
+//   - *Tree[T]: The edited copy of the tree. Nil if an error occurs.
+//   - error: An error if a parameter is nil, or an error of type *ErrDiverged if
+//     the tree differs from the one the script was computed from.
+//
+// The tree is left unchanged, even on error.
+func Apply[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}](tree *Tree[T], script *EditScript[T]) (*Tree[T], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	} else if script == nil {
+		return nil, gcers.NewErrNilParameter("script")
+	}
+
+	root, _ := copy_subtree(tree.root)
+
+	for i, e := range script.Edits {
+		node, err := resolve_path(root, e.Path)
+		if err != nil {
+			return nil, NewErrDiverged(i, e.Path, err)
+		}
+
+		if e.Kind == EditInsert {
+			if e.Index < 0 || e.Index > count_children(node) {
+				return nil, NewErrDiverged(i, e.Path, errors.New("index "+strconv.Itoa(e.Index)+" is out of range"))
+			}
+
+			sub, _ := copy_subtree(e.Node)
+			insert_child(node, e.Index, sub)
+
+			continue
+		}
+
+		if !script.eq(node, e.Old) {
+			return nil, NewErrDiverged(i, e.Path, errors.New("expected "+e.Old.String()+", got "+node.String()))
+		}
+
+		if e.Kind != EditUpdate && node == root {
+			return nil, NewErrDiverged(i, e.Path, errors.New("cannot "+e.Kind.String()+" the root"))
+		}
+
+		switch e.Kind {
+		case EditDelete:
+			detach(node)
+		case EditUpdate:
+			nn := e.Node.Copy()
+			replace_node(node, nn)
+
+			if node == root {
+				root = nn
+			}
+		case EditMove:
+			detach(node)
+
+			parent, err := resolve_path(root, e.To)
+			if err != nil {
+				return nil, NewErrDiverged(i, e.To, err)
+			}
+
+			if e.Index < 0 || e.Index > count_children(parent) {
+				return nil, NewErrDiverged(i, e.To, errors.New("index "+strconv.Itoa(e.Index)+" is out of range"))
+			}
+
+			insert_child(parent, e.Index, node)
+		}
+	}
+
+	return NewTree(root), nil
+}
+
+// children is a helper method that returns the children to print of a node of the
+// unified view: the children of the new tree, interleaved with the deleted and
+// moved children of the old tree.
+//
+// Parameters:
+//   - p: The printer.
+//   - node: The node.
+//   - depth: The depth of the node.
+//
+// Returns:
+//   - []print_item[T]: The children to print.
+func (s *EditScript[T]) children(p Printer[T], node T, depth int) []print_item[T] {
+	_, is_new := s.news[node]
+	_, is_moved := s.moved[node]
+
+	if !is_new {
+		if is_moved {
+			return nil
+		}
+
+		return p.elide(slices.Collect(node.Child()), depth)
+	}
+
+	bc := slices.Collect(node.Child())
+
+	a, ok := s.partners[node]
+	if !ok {
+		return p.elide(bc, depth)
+	}
+
+	var merged []T
+	var j int
+
+	for x := range a.Child() {
+		y, ok := s.partners[x]
+		_, moved := s.moved[x]
+
+		if !ok || moved {
+			merged = append(merged, x)
+			continue
+		}
+
+		idx := slices.Index(bc, y)
+		if idx >= j {
+			merged = append(merged, bc[j:idx+1]...)
+			j = idx + 1
+		}
+	}
+
+	merged = append(merged, bc[j:]...)
+
+	return p.elide(merged, depth)
+}
+
+// Fprint writes a unified view of the changes: the new tree, where every line
+// starts with a marker, and where the deleted subtrees and the old places of the
+// moved ones are interleaved. With Printer.Standard set:
+//
+//	  root
+//	~ ├── port: 80 → port: 8080
+//	~ ├── debug → timeout
+//	< ├── name
+//	  └── sub
+//	      ├── x
+//	      ├── y
+//	>     └── name
+//
+// The markers are "+" (inserted), "-" (deleted), "~" (updated), ">" (moved here)
+// and "<" (moved away). Updated nodes show their old label before the new one.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - p: The printer used to draw the tree.
+//
+// Returns:
+//   - error: An error if w is nil, or the first write error.
+func (s *EditScript[T]) Fprint(w io.Writer, p Printer[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	}
+
+	label_fn := p.Label
+	if label_fn == nil {
+		label_fn = T.String
+	}
+
+	glyphs := p.Glyphs
+	if glyphs == (GlyphSet{}) {
+		glyphs = UnicodeGlyphs
+	}
+
+	arrow := glyphs.Arrow
+	if arrow == "" {
+		arrow = "->"
+	}
+
+	p.Label = func(node T) string {
+		_, ok := s.updated[node]
+		if !ok {
+			return label_fn(node)
+		}
+
+		return label_fn(s.partners[node]) + " " + arrow + " " + label_fn(node)
+	}
+
+	var zero T
+
+	p.margin = func(node T) string {
+		if node == zero {
+			return "  "
+		}
+
+		_, is_new := s.news[node]
+		_, is_moved := s.moved[node]
+		_, is_matched := s.partners[node]
+		_, is_updated := s.updated[node]
+
+		switch {
+		case !is_new && is_moved:
+			return "< "
+		case !is_new:
+			return "- "
+		case !is_matched:
+			return "+ "
+		case is_moved:
+			return "> "
+		case is_updated:
+			return "~ "
+		default:
+			return "  "
+		}
+	}
+
+	return p.fprint(w, s.new_root, func(node T, depth int) []print_item[T] {
+		return s.children(p, node, depth)
+	})
+}
+
+// Sprint renders a unified view of the changes.
+//
+// Parameters:
+//   - p: The printer used to draw the tree.
+//
+// Returns:
+//   - string: The unified view, without a trailing newline.
+//
+// See Fprint for more details.
+func (s *EditScript[T]) Sprint(p Printer[T]) string {
+	var builder strings.Builder
+
+	// Fprint only fails on a nil writer or a write error, and writing to a
+	// strings.Builder never fails; so this cannot happen.
+	err := s.Fprint(&builder, p)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
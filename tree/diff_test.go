@@ -0,0 +1,198 @@
+package tree_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// mutate applies k random edits to the subtree: renames, insertions, deletions
+// and moves.
+func mutate(r *rand.Rand, node *root.StringNode, k int) {
+	for range k {
+		nodes := all_nodes(node)
+		n := nodes[r.Intn(len(nodes))]
+
+		switch r.Intn(4) {
+		case 0:
+			n.Data = string(rune('a' + r.Intn(8)))
+		case 1:
+			children := slices.Collect(n.Child())
+			children = slices.Insert(children, r.Intn(len(children)+1), random_tree(r, 1, 6))
+			n.LinkChildren(children)
+		case 2:
+			parent, ok := n.GetParent()
+			if ok {
+				parent.DeleteChild(n)
+			}
+		case 3:
+			_, ok := n.GetParent()
+			if !ok {
+				continue
+			}
+
+			n.LinkChildren(n.Cleanup())
+
+			targets := all_nodes(node)
+			dst := targets[r.Intn(len(targets))]
+			children := slices.Collect(dst.Child())
+			dst.LinkChildren(slices.Insert(children, r.Intn(len(children)+1), n))
+		}
+	}
+}
+
+func TestDiffApplyRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	kinds := make(map[tree.EditKind]int)
+
+	for trial := range 1000 {
+		a := random_tree(r, 4, 6)
+		b := tree.DeepCopy(a)
+
+		k := r.Intn(5)
+		mutate(r, b, k)
+
+		old, new := tree.NewTree(a), tree.NewTree(b)
+		before := old.String()
+
+		script, err := tree.Diff(old, new, same_data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, edit := range script.Edits {
+			kinds[edit.Kind]++
+		}
+
+		if k == 0 && len(script.Edits) != 0 {
+			t.Fatalf("trial %d: %d edits between equal trees", trial, len(script.Edits))
+		}
+
+		got, err := tree.Apply(old, script)
+		if err != nil {
+			t.Fatalf("trial %d: %v", trial, err)
+		}
+
+		if old.String() != before {
+			t.Fatalf("trial %d: Apply modified its input", trial)
+		}
+
+		if !same_tree(got.Root(), b) {
+			t.Fatalf("trial %d: got\n%s\nwant\n%s", trial, got, new)
+		}
+
+		err = tree.Validate(got)
+		if err != nil {
+			t.Fatalf("trial %d: %v", trial, err)
+		}
+	}
+
+	for _, kind := range []tree.EditKind{tree.EditInsert, tree.EditDelete, tree.EditUpdate, tree.EditMove} {
+		if kinds[kind] == 0 {
+			t.Errorf("no %s edit was generated", kind)
+		}
+	}
+}
+
+func TestDiffRender(t *testing.T) {
+	old := must_parse(t, "root\n├── port: 80\n├── debug\n├── name\n└── sub\n    ├── x\n    └── y")
+	new := must_parse(t, "root\n├── port: 8080\n├── timeout\n└── sub\n    ├── x\n    ├── y\n    └── name")
+
+	script, err := tree.Diff(old, new, same_data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := script.Sprint(tree.Printer[*root.StringNode]{Standard: true, Label: node_data})
+
+	want := strings.Join([]string{
+		"  root",
+		"~ ├── port: 80 → port: 8080",
+		"~ ├── debug → timeout",
+		"< ├── name",
+		"  └── sub",
+		"      ├── x",
+		"      ├── y",
+		">     └── name",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyDiverged(t *testing.T) {
+	old := must_parse(t, "root\n├── a\n└── b\n    └── c")
+	new := must_parse(t, "root\n├── a\n└── b\n    └── d")
+
+	script, err := tree.Diff(old, new, same_data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := must_parse(t, "root\n└── a")
+	before := other.String()
+
+	_, err = tree.Apply(other, script)
+
+	var diverged *tree.ErrDiverged
+
+	if !errors.As(err, &diverged) {
+		t.Fatalf("got %v, want an *ErrDiverged", err)
+	}
+
+	if other.String() != before {
+		t.Errorf("Apply modified its input")
+	}
+}
+
+// grow returns a random tree of n nodes whose labels are lowercase letters.
+func grow(r *rand.Rand, n int) *tree.Tree[*root.StringNode] {
+	nodes := []*root.StringNode{root.NewStringNode("r")}
+
+	for len(nodes) < n {
+		node := root.NewStringNode(string(rune('a' + r.Intn(26))))
+		nodes[r.Intn(len(nodes))].AddChild(node)
+		nodes = append(nodes, node)
+	}
+
+	return tree.NewTree(nodes[0])
+}
+
+func TestDiffUnrelated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large trees")
+	}
+
+	r := rand.New(rand.NewSource(1))
+	old, new := grow(r, 5000), grow(r, 5000)
+
+	// Looking for moves used to compare every unmatched subtree of the new tree
+	// with every candidate of the old one, which took minutes at this size.
+	start := time.Now()
+
+	s, err := tree.Diff(old, new, same_data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Diff took %v", elapsed)
+	}
+
+	got, err := tree.Apply(old, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !same_tree(got.Root(), new.Root()) {
+		t.Error("applying the script does not give the new tree")
+	}
+}
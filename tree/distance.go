@@ -0,0 +1,450 @@
+package tree
+
+import (
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// EditCosts are the costs of the edit operations of EditDistance. Costs must not
+// be negative.
+type EditCosts[T TreeNoder] struct {
+	// Insert returns the cost of inserting a node. Defaults to 1.
+	Insert func(node T) float64
+
+	// Delete returns the cost of deleting a node. Defaults to 1.
+	Delete func(node T) float64
+
+	// Rename returns the cost of turning a node into another one. Defaults to 0 if
+	// both nodes have the same String, 1 otherwise.
+	Rename func(a, b T) float64
+}
+
+// NodePair is a node of the old tree mapped to a node of the new tree.
+type NodePair[T TreeNoder] struct {
+	// Old is the node of the old tree.
+	Old T
+
+	// New is the node of the new tree.
+	New T
+}
+
+// zs_tree is a tree as seen by the Zhang-Shasha algorithm. Nodes are numbered from
+// 1, in post-order.
+type zs_tree[T TreeNoder] struct {
+	// nodes are the nodes. nodes[0] is unused.
+	nodes []T
+
+	// lml is the leftmost leaf of the subtree of every node.
+	lml []int
+
+	// keyroots are the nodes that have no ancestor with the same leftmost leaf,
+	// in increasing order.
+	keyroots []int
+}
+
+// new_zs_tree is a helper function that numbers the nodes of a tree.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - mirror: Whether to visit the children from right to left.
+//
+// Returns:
+//   - *zs_tree[T]: The numbered tree. Nil if an error occurs.
+//   - error: An *ErrCycle if a node is reached twice.
+func new_zs_tree[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](root T, mirror bool) (*zs_tree[T], error) {
+	type frame struct {
+		node     T
+		children []T
+		next     int
+		lml      int
+	}
+
+	t := &zs_tree[T]{
+		nodes: []T{*new(T)},
+		lml:   []int{0},
+	}
+
+	seen := map[T]struct{}{root: {}}
+
+	push := func(stack []*frame, node T) []*frame {
+		var children []T
+
+		for child := range node.Child() {
+			children = append(children, child)
+		}
+
+		if mirror {
+			slices.Reverse(children)
+		}
+
+		return append(stack, &frame{node: node, children: children})
+	}
+
+	stack := push(nil, root)
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+
+		if top.next < len(top.children) {
+			child := top.children[top.next]
+			top.next++
+
+			_, ok := seen[child]
+			if ok {
+				return nil, NewErrCycle(child)
+			}
+
+			seen[child] = struct{}{}
+			stack = push(stack, child)
+
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+
+		idx := len(t.nodes)
+		if top.lml == 0 {
+			top.lml = idx
+		}
+
+		t.nodes = append(t.nodes, top.node)
+		t.lml = append(t.lml, top.lml)
+
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			if parent.lml == 0 {
+				parent.lml = top.lml
+			}
+		}
+	}
+
+	// The nodes are visited from the root down so that only the highest node of
+	// every leftmost leaf is kept.
+	is_key := make(map[int]struct{})
+
+	for i := len(t.nodes) - 1; i > 0; i-- {
+		_, ok := is_key[t.lml[i]]
+		if !ok {
+			is_key[t.lml[i]] = struct{}{}
+			t.keyroots = append(t.keyroots, i)
+		}
+	}
+
+	slices.Reverse(t.keyroots)
+
+	return t, nil
+}
+
+// work is a helper method that returns the total size of the subtrees of the
+// keyroots; the running time of the algorithm is proportional to the product of
+// the works of both trees.
+//
+// Returns:
+//   - int: The work.
+func (t zs_tree[T]) work() int {
+	var total int
+
+	for _, k := range t.keyroots {
+		total += k - t.lml[k] + 1
+	}
+
+	return total
+}
+
+// zs_state is the state of the Zhang-Shasha algorithm.
+type zs_state[T TreeNoder] struct {
+	// a and b are the old and the new tree.
+	a, b *zs_tree[T]
+
+	// del and ins are the costs of deleting the nodes of a and inserting the ones
+	// of b.
+	del, ins []float64
+
+	// rename returns the cost of renaming the node x of a into the node y of b.
+	rename func(x, y int) float64
+
+	// td is the distance between every pair of subtrees, indexed by
+	// x*len(b.nodes)+y.
+	td []float64
+
+	// fd is the forest distance table of the current pair of subtrees. The
+	// distance between the forests a[li..x] and b[lj..y] is at
+	// (x-li+1)*(j-lj+2)+(y-lj+1); so, the first row and column stand for the
+	// empty forests.
+	fd []float64
+
+	// err is the first invalid rename cost, if any.
+	err error
+}
+
+// forest_dist is a helper method that fills the forest distance table of two
+// subtrees, along with the distance of every pair of their subtrees that share
+// their leftmost leaf.
+//
+// Parameters:
+//   - i: The root of the subtree of the old tree.
+//   - j: The root of the subtree of the new tree.
+//   - fill: Whether to compute the subtree distances; otherwise, they are read
+//     from td.
+func (s *zs_state[T]) forest_dist(i, j int, fill bool) {
+	a_lml, b_lml := s.a.lml, s.b.lml
+	tw := len(s.b.nodes)
+
+	li, lj := a_lml[i], b_lml[j]
+	fw := j - lj + 2
+
+	fd := s.fd
+
+	fd[0] = 0
+
+	for x := li; x <= i; x++ {
+		fd[(x-li+1)*fw] = fd[(x-li)*fw] + s.del[x]
+	}
+
+	for y := lj; y <= j; y++ {
+		fd[y-lj+1] = fd[y-lj] + s.ins[y]
+	}
+
+	for x := li; x <= i; x++ {
+		row := (x - li + 1) * fw
+		prev := row - fw
+		del := s.del[x]
+		x_is_tree := a_lml[x] == li
+
+		for y := lj; y <= j; y++ {
+			c := y - lj + 1
+
+			best := min(fd[prev+c]+del, fd[row+c-1]+s.ins[y])
+
+			switch {
+			case x_is_tree && b_lml[y] == lj && fill:
+				cost := s.rename(x, y)
+				if (cost < 0 || math.IsNaN(cost)) && s.err == nil {
+					s.err = gcers.NewErrInvalidParameter("rename cost " + strconv.FormatFloat(cost, 'g', -1, 64) + " is invalid")
+				}
+
+				best = min(best, fd[prev+c-1]+cost)
+				s.td[x*tw+y] = best
+			case x_is_tree && b_lml[y] == lj:
+				// Both forests are trees whose distance is already known.
+				best = s.td[x*tw+y]
+			default:
+				best = min(best, fd[(a_lml[x]-li)*fw+b_lml[y]-lj]+s.td[x*tw+y])
+			}
+
+			fd[row+c] = best
+		}
+	}
+}
+
+// mapping is a helper method that recovers an optimal mapping from the subtree
+// distances.
+//
+// Returns:
+//   - []int: The node of b mapped to every node of a, or 0 if none.
+func (s *zs_state[T]) mapping() []int {
+	a, b := s.a, s.b
+
+	pairs := make([]int, len(a.nodes))
+
+	stack := [][2]int{{len(a.nodes) - 1, len(b.nodes) - 1}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		i, j := top[0], top[1]
+
+		s.forest_dist(i, j, false)
+
+		li, lj := a.lml[i], b.lml[j]
+		fw := j - lj + 2
+
+		at := func(x, y int) float64 {
+			return s.fd[(x-li+1)*fw+y-lj+1]
+		}
+
+		x, y := i, j
+
+		for x >= li || y >= lj {
+			switch {
+			case x >= li && at(x, y) == at(x-1, y)+s.del[x]:
+				x--
+			case y >= lj && at(x, y) == at(x, y-1)+s.ins[y]:
+				y--
+			case a.lml[x] == li && b.lml[y] == lj:
+				pairs[x] = y
+				x--
+				y--
+			default:
+				// The forests split into the subtrees of x and y, whose mapping is
+				// recovered separately, and what is left of them.
+				stack = append(stack, [2]int{x, y})
+
+				x, y = a.lml[x]-1, b.lml[y]-1
+			}
+		}
+	}
+
+	return pairs
+}
+
+// EditDistance computes the ordered tree edit distance between two trees with the
+// Zhang-Shasha algorithm: the minimum cost of the node insertions, deletions and
+// renames that turn a tree into the other.
+//
+// Parameters:
+//   - a: The root of the old tree.
+//   - b: The root of the new tree.
+//   - costs: The costs of the edit operations.
+//
+// Returns:
+//   - float64: The edit distance.
+//   - []NodePair[T]: An optimal mapping, in post-order of the old tree. The nodes
+//     of the old tree that are not mapped are deleted, and the ones of the new
+//     tree that are not mapped are inserted.
+//   - error: An error if a root is nil, if a cost is negative or NaN, or an
+//     *ErrCycle if a node is reached twice.
+//
+// Deleting a node moves its children up to its parent, and inserting a node adopts
+// a range of consecutive siblings; ancestors and sibling order are preserved by
+// the mapping. The trees are decomposed from the left or from the right, whichever
+// is cheaper; either way, the algorithm takes O(n*m*min(depth, leaves)^2) time for
+// trees of n and m nodes, and O(n*m) memory: two tables of n*m float64, the
+// subtree and the forest distances, or about 16*n*m bytes (e.g., 144 MB for two
+// trees of 3000 nodes).
+func EditDistance[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](a, b T, costs EditCosts[T]) (float64, []NodePair[T], error) {
+	var zero T
+
+	if a == zero {
+		return 0, nil, gcers.NewErrNilParameter("a")
+	} else if b == zero {
+		return 0, nil, gcers.NewErrNilParameter("b")
+	}
+
+	var trees [4]*zs_tree[T]
+
+	for i, root := range []T{a, b, a, b} {
+		t, err := new_zs_tree(root, i >= 2)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		trees[i] = t
+	}
+
+	left := trees[0]
+
+	ta, tb := trees[0], trees[1]
+	if trees[2].work()*trees[3].work() < ta.work()*tb.work() {
+		ta, tb = trees[2], trees[3]
+	}
+
+	s := &zs_state[T]{
+		a: ta,
+		b: tb,
+	}
+
+	if costs.Rename != nil {
+		s.rename = func(x, y int) float64 {
+			return costs.Rename(ta.nodes[x], tb.nodes[y])
+		}
+	} else {
+		labels := func(nodes []T) []string {
+			result := make([]string, len(nodes))
+
+			for i := 1; i < len(nodes); i++ {
+				result[i] = nodes[i].String()
+			}
+
+			return result
+		}
+
+		a_labels, b_labels := labels(ta.nodes), labels(tb.nodes)
+
+		s.rename = func(x, y int) float64 {
+			if a_labels[x] == b_labels[y] {
+				return 0
+			}
+
+			return 1
+		}
+	}
+
+	node_costs := func(nodes []T, fn func(node T) float64, name string) ([]float64, error) {
+		result := make([]float64, len(nodes))
+
+		for i := 1; i < len(nodes); i++ {
+			if fn == nil {
+				result[i] = 1
+				continue
+			}
+
+			result[i] = fn(nodes[i])
+
+			if result[i] < 0 || math.IsNaN(result[i]) {
+				return nil, gcers.NewErrInvalidParameter(name + " cost " + strconv.FormatFloat(result[i], 'g', -1, 64) + " is invalid")
+			}
+		}
+
+		return result, nil
+	}
+
+	var err error
+
+	s.del, err = node_costs(ta.nodes, costs.Delete, "delete")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	s.ins, err = node_costs(tb.nodes, costs.Insert, "insert")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := len(ta.nodes) * len(tb.nodes)
+
+	s.td = make([]float64, size)
+	s.fd = make([]float64, size)
+
+	for _, i := range ta.keyroots {
+		for _, j := range tb.keyroots {
+			s.forest_dist(i, j, true)
+		}
+	}
+
+	if s.err != nil {
+		return 0, nil, s.err
+	}
+
+	pairs := s.mapping()
+
+	mapped := make(map[T]T, len(pairs))
+
+	for x, y := range pairs {
+		if y != 0 {
+			mapped[ta.nodes[x]] = tb.nodes[y]
+		}
+	}
+
+	var result []NodePair[T]
+
+	for _, node := range left.nodes[1:] {
+		other, ok := mapped[node]
+		if ok {
+			result = append(result, NodePair[T]{Old: node, New: other})
+		}
+	}
+
+	return s.td[size-1], result, nil
+}
@@ -0,0 +1,228 @@
+package tree_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// naive_distance is the textbook recursive forest distance, memoized on the
+// identity of the nodes of the forests.
+type naive_distance struct {
+	memo map[string]float64
+}
+
+func (nd *naive_distance) key(f []*root.StringNode) string {
+	var builder strings.Builder
+
+	for _, n := range f {
+		fmt.Fprintf(&builder, "%p,", n)
+	}
+
+	return builder.String()
+}
+
+func (nd *naive_distance) forest(f, g []*root.StringNode) float64 {
+	if len(f) == 0 && len(g) == 0 {
+		return 0
+	}
+
+	k := nd.key(f) + "|" + nd.key(g)
+
+	d, ok := nd.memo[k]
+	if ok {
+		return d
+	}
+
+	best := math.Inf(1)
+
+	if len(f) > 0 {
+		v := f[len(f)-1]
+		rest := slices.Concat(f[:len(f)-1], slices.Collect(v.Child()))
+		best = min(best, nd.forest(rest, g)+1)
+	}
+
+	if len(g) > 0 {
+		w := g[len(g)-1]
+		rest := slices.Concat(g[:len(g)-1], slices.Collect(w.Child()))
+		best = min(best, nd.forest(f, rest)+1)
+	}
+
+	if len(f) > 0 && len(g) > 0 {
+		v, w := f[len(f)-1], g[len(g)-1]
+
+		var rename float64
+		if v.Data != w.Data {
+			rename = 1
+		}
+
+		sub := nd.forest(slices.Collect(v.Child()), slices.Collect(w.Child()))
+		best = min(best, sub+nd.forest(f[:len(f)-1], g[:len(g)-1])+rename)
+	}
+
+	nd.memo[k] = best
+
+	return best
+}
+
+// check_mapping checks that a mapping is a valid tree edit mapping whose cost is
+// the given distance, with unit costs.
+func check_mapping(t *testing.T, a, b *root.StringNode, pairs []tree.NodePair[*root.StringNode], distance float64) {
+	t.Helper()
+
+	post_a := slices.Collect(tree.NewTree(a).PostOrder())
+	post_b := slices.Collect(tree.NewTree(b).PostOrder())
+
+	index_a := make(map[*root.StringNode]int, len(post_a))
+	for i, n := range post_a {
+		index_a[n] = i
+	}
+
+	index_b := make(map[*root.StringNode]int, len(post_b))
+	for i, n := range post_b {
+		index_b[n] = i
+	}
+
+	cost := float64(len(post_a) + len(post_b) - 2*len(pairs))
+
+	for i, p := range pairs {
+		if p.Old.Data != p.New.Data {
+			cost++
+		}
+
+		if i > 0 {
+			prev := pairs[i-1]
+
+			if index_a[prev.Old] >= index_a[p.Old] || index_b[prev.New] >= index_b[p.New] {
+				t.Fatalf("pair %d breaks the post-order", i)
+			}
+		}
+	}
+
+	if cost != distance {
+		t.Fatalf("mapping costs %v, distance is %v", cost, distance)
+	}
+}
+
+// is_ancestor checks whether x is a proper ancestor of y.
+func is_ancestor(x, y *root.StringNode) bool {
+	for p, ok := y.GetParent(); ok; p, ok = p.GetParent() {
+		if p == x {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestEditDistanceNaive(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+
+	for trial := range 300 {
+		a, b := random_tree(r, 3, 4), random_tree(r, 3, 4)
+
+		nd := &naive_distance{memo: make(map[string]float64)}
+		want := nd.forest([]*root.StringNode{a}, []*root.StringNode{b})
+
+		got, pairs, err := tree.EditDistance(a, b, tree.EditCosts[*root.StringNode]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Fatalf("trial %d: got %v, want %v", trial, got, want)
+		}
+
+		check_mapping(t, a, b, pairs, got)
+
+		for _, p := range pairs {
+			for _, q := range pairs {
+				if is_ancestor(p.Old, q.Old) != is_ancestor(p.New, q.New) {
+					t.Fatalf("trial %d: the mapping does not preserve ancestry", trial)
+				}
+			}
+		}
+	}
+}
+
+func TestEditDistanceCosts(t *testing.T) {
+	a := must_parse(t, "f\n├── a\n└── b").Root()
+	b := must_parse(t, "f\n├── a\n└── c").Root()
+
+	costs := tree.EditCosts[*root.StringNode]{
+		Rename: func(x, y *root.StringNode) float64 {
+			if x.Data == y.Data {
+				return 0
+			}
+
+			return 5
+		},
+	}
+
+	got, pairs, err := tree.EditDistance(a, b, costs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting b and inserting c is cheaper than renaming it.
+	if got != 2 || len(pairs) != 2 {
+		t.Errorf("got %v with %d pairs, want 2 with 2 pairs", got, len(pairs))
+	}
+
+	costs.Delete = func(*root.StringNode) float64 {
+		return -1
+	}
+
+	_, _, err = tree.EditDistance(a, b, costs)
+	if err == nil {
+		t.Error("expected an error for a negative cost")
+	}
+}
+
+// random_recursive_tree builds a tree of n nodes where every node is attached to
+// a random earlier one.
+func random_recursive_tree(r *rand.Rand, n int) *root.StringNode {
+	nodes := []*root.StringNode{root.NewStringNode("a")}
+
+	for len(nodes) < n {
+		node := root.NewStringNode(string(rune('a' + r.Intn(8))))
+		nodes[r.Intn(len(nodes))].AddChild(node)
+		nodes = append(nodes, node)
+	}
+
+	return nodes[0]
+}
+
+func TestEditDistanceLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large trees are slow")
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	a := random_recursive_tree(r, 3000)
+	b := tree.DeepCopy(a)
+
+	nodes := all_nodes(b)
+
+	for range 200 {
+		nodes[r.Intn(len(nodes))].Data = "z"
+	}
+
+	got, pairs, err := tree.EditDistance(a, b, tree.EditCosts[*root.StringNode]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got <= 0 || got > 200 {
+		t.Errorf("got distance %v for 200 renames", got)
+	}
+
+	check_mapping(t, a, b, pairs, got)
+}
@@ -0,0 +1,371 @@
+package tree
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// DOTAttrs are the Graphviz attributes of a node, an edge, a cluster or a graph.
+// For example: {"label": "root", "shape": "box", "color": "red"}.
+type DOTAttrs map[string]string
+
+// DOTOptions are the options of the DOT exporter. The zero value is a plain
+// top-down graph where every node is labeled with its String method.
+type DOTOptions[T TreeNoder] struct {
+	// Name is the name of the graph. Defaults to "tree".
+	Name string
+
+	// GraphAttrs are the attributes of the graph (e.g., {"rankdir": "LR"}).
+	GraphAttrs DOTAttrs
+
+	// NodeAttrs returns the attributes of a node. If nil, or if the returned
+	// attributes have no "label", the label is the String of the node.
+	NodeAttrs func(node T) DOTAttrs
+
+	// EdgeAttrs returns the attributes of the edge from parent to child. Optional.
+	EdgeAttrs func(parent, child T) DOTAttrs
+
+	// Cluster reports whether the subtree rooted at the node is drawn as a
+	// cluster, and with which attributes. Clusters can be nested. Optional.
+	Cluster func(node T) (DOTAttrs, bool)
+
+	// RankByDepth, if true, groups the nodes of the same depth with rank=same.
+	RankByDepth bool
+
+	// Highlight are the nodes to highlight. Edges between two highlighted nodes are
+	// highlighted too; use Branch.Path to highlight a branch.
+	Highlight []T
+
+	// HighlightAttrs are the attributes added to the highlighted nodes and edges.
+	// Defaults to {"color": "red", "penwidth": "2"}.
+	HighlightAttrs DOTAttrs
+}
+
+// text_writer is a buffered writer that keeps the first write error.
+type text_writer struct {
+	// w is the buffered writer.
+	w *bufio.Writer
+
+	// err is the first write error, if any.
+	err error
+}
+
+// write is a helper method that writes strings while keeping the first error.
+//
+// Parameters:
+//   - strs: The strings to write.
+func (dw *text_writer) write(strs ...string) {
+	for _, str := range strs {
+		if dw.err != nil {
+			return
+		}
+
+		_, dw.err = dw.w.WriteString(str)
+	}
+}
+
+// write_attrs is a helper method that writes a statement followed by its
+// attributes, sorted by key.
+//
+// Parameters:
+//   - indent: The indentation of the statement.
+//   - stmt: The statement (e.g., a node id or an edge).
+//   - attrs: The attributes of the statement.
+func (dw *text_writer) write_attrs(indent, stmt string, attrs DOTAttrs) {
+	dw.write(indent, stmt)
+
+	if len(attrs) > 0 {
+		dw.write(" [")
+
+		for i, key := range slices.Sorted(maps.Keys(attrs)) {
+			if i > 0 {
+				dw.write(", ")
+			}
+
+			dw.write(dot_id(key), "=", dot_quote(attrs[key]))
+		}
+
+		dw.write("]")
+	}
+
+	dw.write(";\n")
+}
+
+// dot_quote is a helper function that quotes a string for DOT.
+//
+// Parameters:
+//   - str: The string to quote.
+//
+// Returns:
+//   - string: The quoted string.
+func dot_quote(str string) string {
+	var builder strings.Builder
+
+	builder.WriteByte('"')
+
+	for _, r := range str {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	builder.WriteByte('"')
+
+	return builder.String()
+}
+
+// dot_id is a helper function that returns str as is if it is a valid DOT
+// identifier, or quoted otherwise.
+//
+// Parameters:
+//   - str: The identifier.
+//
+// Returns:
+//   - string: The DOT identifier.
+func dot_id(str string) string {
+	if str == "" {
+		return `""`
+	}
+
+	for i, r := range str {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+
+		return dot_quote(str)
+	}
+
+	return str
+}
+
+// merge_attrs is a helper function that merges attribute sets; later sets win.
+//
+// Parameters:
+//   - sets: The attribute sets to merge.
+//
+// Returns:
+//   - DOTAttrs: The merged attributes. Nil if there are none.
+func merge_attrs(sets ...DOTAttrs) DOTAttrs {
+	var attrs DOTAttrs
+
+	for _, set := range sets {
+		for k, v := range set {
+			if attrs == nil {
+				attrs = make(DOTAttrs)
+			}
+
+			attrs[k] = v
+		}
+	}
+
+	return attrs
+}
+
+// WriteDOT writes the tree in the Graphviz DOT language.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tree: The tree to write.
+//   - opts: The options of the exporter.
+//
+// Returns:
+//   - error: An error if w or tree is nil, or if the write fails.
+//
+// Nodes are named n0, n1, ... in DFS order. Render the output with, for example,
+// `dot -Tsvg tree.dot -o tree.svg`.
+func WriteDOT[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](w io.Writer, tree *Tree[T], opts DOTOptions[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tree == nil {
+		return gcers.NewErrNilParameter("tree")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "tree"
+	}
+
+	hl_attrs := opts.HighlightAttrs
+	if hl_attrs == nil {
+		hl_attrs = DOTAttrs{"color": "red", "penwidth": "2"}
+	}
+
+	highlighted := make(map[T]struct{}, len(opts.Highlight))
+	for _, node := range opts.Highlight {
+		highlighted[node] = struct{}{}
+	}
+
+	dw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	dw.write("digraph ", dot_id(name), " {\n")
+
+	for _, key := range slices.Sorted(maps.Keys(opts.GraphAttrs)) {
+		dw.write("\t", dot_id(key), "=", dot_quote(opts.GraphAttrs[key]), ";\n")
+	}
+
+	type StackElement struct {
+		node   T
+		parent *T
+		depth  int
+		level  int
+		close  bool
+	}
+
+	var edges []string
+	var edges_attrs []DOTAttrs
+	var ranks [][]string
+	var clusters int
+
+	ids := make(map[T]string, tree.size)
+	stack := []StackElement{{node: tree.root}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if top.close {
+			dw.write(strings.Repeat("\t", top.level+1), "}\n")
+			continue
+		}
+
+		level := top.level
+
+		if opts.Cluster != nil {
+			attrs, ok := opts.Cluster(top.node)
+			if ok {
+				indent := strings.Repeat("\t", level+1)
+
+				dw.write(indent, "subgraph cluster_", strconv.Itoa(clusters), " {\n")
+				clusters++
+
+				for _, key := range slices.Sorted(maps.Keys(attrs)) {
+					dw.write(indent, "\t", dot_id(key), "=", dot_quote(attrs[key]), ";\n")
+				}
+
+				stack = append(stack, StackElement{level: level, close: true})
+				level++
+			}
+		}
+
+		id := "n" + strconv.Itoa(len(ids))
+		ids[top.node] = id
+
+		var attrs DOTAttrs
+
+		if opts.NodeAttrs != nil {
+			attrs = opts.NodeAttrs(top.node)
+		}
+
+		_, ok := attrs["label"]
+		if !ok {
+			attrs = merge_attrs(attrs, DOTAttrs{"label": top.node.String()})
+		}
+
+		_, is_hl := highlighted[top.node]
+		if is_hl {
+			attrs = merge_attrs(attrs, hl_attrs)
+		}
+
+		dw.write_attrs(strings.Repeat("\t", level+1), id, attrs)
+
+		if top.parent != nil {
+			parent := *top.parent
+
+			var attrs DOTAttrs
+
+			if opts.EdgeAttrs != nil {
+				attrs = opts.EdgeAttrs(parent, top.node)
+			}
+
+			_, ok := highlighted[parent]
+			if ok && is_hl {
+				attrs = merge_attrs(attrs, hl_attrs)
+			}
+
+			edges = append(edges, ids[parent]+" -> "+id)
+			edges_attrs = append(edges_attrs, attrs)
+		}
+
+		if opts.RankByDepth {
+			if top.depth == len(ranks) {
+				ranks = append(ranks, nil)
+			}
+
+			ranks[top.depth] = append(ranks[top.depth], id)
+		}
+
+		for child := range top.node.BackwardChild() {
+			stack = append(stack, StackElement{
+				node:   child,
+				parent: &top.node,
+				depth:  top.depth + 1,
+				level:  level,
+			})
+		}
+	}
+
+	for i, edge := range edges {
+		dw.write_attrs("\t", edge, edges_attrs[i])
+	}
+
+	for _, rank := range ranks {
+		if len(rank) < 2 {
+			continue
+		}
+
+		dw.write("\t{ rank=same; ", strings.Join(rank, "; "), "; }\n")
+	}
+
+	dw.write("}\n")
+
+	if dw.err != nil {
+		return dw.err
+	}
+
+	return dw.w.Flush()
+}
+
+// DOT returns the tree in the Graphviz DOT language.
+//
+// Parameters:
+//   - opts: The options of the exporter.
+//
+// Returns:
+//   - string: The DOT representation of the tree.
+//   - error: An error if the tree is nil.
+//
+// See WriteDOT for more details.
+func (t *Tree[T]) DOT(opts DOTOptions[T]) (string, error) {
+	var builder strings.Builder
+
+	err := WriteDOT(&builder, t, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,107 @@
+package tree_test
+
+import (
+	"errors"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// failing_writer is an io.Writer that always fails.
+type failing_writer struct{}
+
+// Write implements the io.Writer interface.
+func (failing_writer) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestDOT(t *testing.T) {
+	tr := sample_tree()
+
+	got, err := tr.DOT(tree.DOTOptions[*root.StringNode]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "digraph tree {\n" +
+		"\tn0 [label=\"StringNode[a]\"];\n" +
+		"\tn1 [label=\"StringNode[b]\"];\n" +
+		"\tn2 [label=\"StringNode[d]\"];\n" +
+		"\tn3 [label=\"StringNode[e]\"];\n" +
+		"\tn4 [label=\"StringNode[c]\"];\n" +
+		"\tn5 [label=\"StringNode[f]\"];\n" +
+		"\tn0 -> n1;\n" +
+		"\tn1 -> n2;\n" +
+		"\tn1 -> n3;\n" +
+		"\tn0 -> n4;\n" +
+		"\tn4 -> n5;\n" +
+		"}\n"
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDOTOptions(t *testing.T) {
+	tr := sample_tree()
+	b := tr.Root().FirstChild
+
+	got, err := tr.DOT(tree.DOTOptions[*root.StringNode]{
+		Name:       "my graph",
+		GraphAttrs: tree.DOTAttrs{"rankdir": "LR"},
+		NodeAttrs: func(node *root.StringNode) tree.DOTAttrs {
+			if node.Data != "e" {
+				return nil
+			}
+
+			return tree.DOTAttrs{"label": "say \"hi\"\n", "shape": "box"}
+		},
+		Cluster: func(node *root.StringNode) (tree.DOTAttrs, bool) {
+			return tree.DOTAttrs{"label": "B"}, node == b
+		},
+		RankByDepth: true,
+		Highlight:   []*root.StringNode{tr.Root(), b, b.FirstChild},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "digraph \"my graph\" {\n" +
+		"\trankdir=\"LR\";\n" +
+		"\tn0 [color=\"red\", label=\"StringNode[a]\", penwidth=\"2\"];\n" +
+		"\tsubgraph cluster_0 {\n" +
+		"\t\tlabel=\"B\";\n" +
+		"\t\tn1 [color=\"red\", label=\"StringNode[b]\", penwidth=\"2\"];\n" +
+		"\t\tn2 [color=\"red\", label=\"StringNode[d]\", penwidth=\"2\"];\n" +
+		"\t\tn3 [label=\"say \\\"hi\\\"\\n\", shape=\"box\"];\n" +
+		"\t}\n" +
+		"\tn4 [label=\"StringNode[c]\"];\n" +
+		"\tn5 [label=\"StringNode[f]\"];\n" +
+		"\tn0 -> n1 [color=\"red\", penwidth=\"2\"];\n" +
+		"\tn1 -> n2 [color=\"red\", penwidth=\"2\"];\n" +
+		"\tn1 -> n3;\n" +
+		"\tn0 -> n4;\n" +
+		"\tn4 -> n5;\n" +
+		"\t{ rank=same; n1; n4; }\n" +
+		"\t{ rank=same; n2; n3; n5; }\n" +
+		"}\n"
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDOTErrors(t *testing.T) {
+	var nil_tree *tree.Tree[*root.StringNode]
+
+	_, err := nil_tree.DOT(tree.DOTOptions[*root.StringNode]{})
+	if err == nil {
+		t.Error("nil tree: want an error")
+	}
+
+	err = tree.WriteDOT(failing_writer{}, sample_tree(), tree.DOTOptions[*root.StringNode]{})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("failing writer: got %v, want the write error", err)
+	}
+}
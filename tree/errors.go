@@ -1,12 +1,332 @@
 package tree
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 var (
 	// NodeNotPartOfTree is an error that is returned when a node is not part of a tree.
 	NodeNotPartOfTree error
+
+	// InvalidBinaryFormat is an error that is returned when a binary tree is malformed.
+	InvalidBinaryFormat error
+
+	// CursorAtRoot is an error that is returned when a cursor operation needs the
+	// focus to have a parent but the focus is the root.
+	CursorAtRoot error
+
+	// NothingToUndo is an error that is returned when a history has no operation
+	// to undo.
+	NothingToUndo error
+
+	// NothingToRedo is an error that is returned when a history has no operation
+	// to redo.
+	NothingToRedo error
 )
 
 func init() {
 	NodeNotPartOfTree = errors.New("node is not part of the tree")
+	InvalidBinaryFormat = errors.New("invalid binary tree format")
+	CursorAtRoot = errors.New("cursor is at the root")
+	NothingToUndo = errors.New("nothing to undo")
+	NothingToRedo = errors.New("nothing to redo")
+}
+
+// LimitKind is the kind of limit that stopped a bounded operation.
+type LimitKind int
+
+const (
+	// LimitCanceled means that the context was canceled.
+	LimitCanceled LimitKind = iota
+
+	// LimitDepth means that the maximum depth was exceeded.
+	LimitDepth
+
+	// LimitNodes means that the maximum number of visited nodes was exceeded.
+	LimitNodes
+
+	// LimitDeadline means that the deadline of the context, or the wall-clock
+	// deadline of the limits, expired.
+	LimitDeadline
+
+	// LimitSteps means that the maximum number of steps (e.g., rewrites) was
+	// exceeded.
+	LimitSteps
+)
+
+// String implements the fmt.Stringer interface.
+func (k LimitKind) String() string {
+	switch k {
+	case LimitCanceled:
+		return "context"
+	case LimitDepth:
+		return "max depth"
+	case LimitNodes:
+		return "max nodes"
+	case LimitDeadline:
+		return "deadline"
+	case LimitSteps:
+		return "max steps"
+	default:
+		return "unknown limit"
+	}
+}
+
+// ErrLimitReached is an error that is returned when a bounded operation hits
+// one of its limits.
+type ErrLimitReached struct {
+	// Kind is the limit that was hit.
+	Kind LimitKind
+
+	// Processed is the number of nodes that were processed before the limit was hit.
+	// For LimitSteps, it is the number of steps instead.
+	Processed int
+
+	// Reason is the underlying reason, if any. (i.e., the context error.)
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "<kind> limit reached after processing <processed> nodes", or "steps"
+// instead of "nodes" for LimitSteps.
+func (e ErrLimitReached) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString(e.Kind.String())
+	builder.WriteString(" limit reached after processing ")
+	builder.WriteString(strconv.Itoa(e.Processed))
+
+	if e.Kind == LimitSteps {
+		builder.WriteString(" steps")
+	} else {
+		builder.WriteString(" nodes")
+	}
+
+	if e.Reason != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Reason.Error())
+	}
+
+	return builder.String()
+}
+
+// Unwrap returns the underlying reason of the error.
+//
+// Returns:
+//   - error: The underlying reason. Nil if there is none.
+func (e ErrLimitReached) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrLimitReached creates a new ErrLimitReached error.
+//
+// Parameters:
+//   - kind: The limit that was hit.
+//   - processed: The number of nodes processed so far.
+//   - reason: The underlying reason, if any.
+//
+// Returns:
+//   - *ErrLimitReached: The new error. Never returns nil.
+func NewErrLimitReached(kind LimitKind, processed int, reason error) *ErrLimitReached {
+	return &ErrLimitReached{
+		Kind:      kind,
+		Processed: processed,
+		Reason:    reason,
+	}
+}
+
+// ErrCycle is an error that is returned when a node is reached more than once
+// while traversing a tree; which means that the tree contains a cycle.
+//
+// A node that is shared by two parents (i.e., the nodes form a DAG rather than a
+// tree) is reached more than once as well and it is reported with this same error:
+// the safe helpers only track which nodes were visited, not the current ancestor
+// path, and a shared node breaks the tree invariants just as a cycle does.
+type ErrCycle struct {
+	// Node is the node that closed the cycle or that was reached twice.
+	Node fmt.Stringer
+}
+
+// Error implements the error interface.
+//
+// Message: "cycle detected at node <node>"
+func (e ErrCycle) Error() string {
+	if e.Node == nil {
+		return "cycle detected"
+	}
+
+	return "cycle detected at node " + strconv.Quote(e.Node.String())
+}
+
+// NewErrCycle creates a new ErrCycle error.
+//
+// Parameters:
+//   - node: The node that closed the cycle.
+//
+// Returns:
+//   - *ErrCycle: The new error. Never returns nil.
+func NewErrCycle(node fmt.Stringer) *ErrCycle {
+	return &ErrCycle{
+		Node: node,
+	}
+}
+
+// ErrInvalidTree is an error that is returned when a tree breaks one or more
+// of its invariants.
+type ErrInvalidTree struct {
+	// Violations are all the violations found.
+	Violations []Violation
+}
+
+// Error implements the error interface.
+//
+// Message:
+//
+//	"tree has <n> violation(s):
+//	<violation>
+//	<violation>
+//	..."
+func (e ErrInvalidTree) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("tree has ")
+	builder.WriteString(strconv.Itoa(len(e.Violations)))
+	builder.WriteString(" violation(s):")
+
+	for _, v := range e.Violations {
+		builder.WriteRune('\n')
+		builder.WriteString(v.String())
+	}
+
+	return builder.String()
+}
+
+// NewErrInvalidTree creates a new ErrInvalidTree error.
+//
+// Parameters:
+//   - violations: The violations found.
+//
+// Returns:
+//   - *ErrInvalidTree: The new error. Never returns nil.
+func NewErrInvalidTree(violations []Violation) *ErrInvalidTree {
+	return &ErrInvalidTree{
+		Violations: violations,
+	}
+}
+
+// ErrSyntax is an error that is returned when a textual tree is malformed.
+type ErrSyntax struct {
+	// Line is the 1-based line of the error.
+	Line int
+
+	// Column is the 1-based column, in runes, of the error.
+	Column int
+
+	// Reason is the reason of the error.
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "line <line>, column <column>: <reason>"
+func (e ErrSyntax) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("line ")
+	builder.WriteString(strconv.Itoa(e.Line))
+	builder.WriteString(", column ")
+	builder.WriteString(strconv.Itoa(e.Column))
+
+	if e.Reason != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Reason.Error())
+	}
+
+	return builder.String()
+}
+
+// Unwrap returns the reason of the error.
+//
+// Returns:
+//   - error: The reason of the error.
+func (e ErrSyntax) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrSyntax creates a new ErrSyntax error.
+//
+// Parameters:
+//   - line: The 1-based line of the error.
+//   - column: The 1-based column, in runes, of the error.
+//   - reason: The reason of the error.
+//
+// Returns:
+//   - *ErrSyntax: The new error. Never returns nil.
+func NewErrSyntax(line, column int, reason error) *ErrSyntax {
+	return &ErrSyntax{
+		Line:   line,
+		Column: column,
+		Reason: reason,
+	}
+}
+
+// ErrDiverged is an error that is returned when an edit script cannot be applied
+// because the tree differs from the one the script was computed from.
+type ErrDiverged struct {
+	// Step is the 0-based index of the edit that failed.
+	Step int
+
+	// Path is the path of the edit that failed.
+	Path []int
+
+	// Reason is the reason of the error.
+	Reason error
+}
+
+// Error implements the error interface.
+//
+// Message: "edit <step> at <path>: <reason>"
+func (e ErrDiverged) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString("edit ")
+	builder.WriteString(strconv.Itoa(e.Step))
+	builder.WriteString(" at ")
+	builder.WriteString(format_path(e.Path))
+
+	if e.Reason != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Reason.Error())
+	}
+
+	return builder.String()
+}
+
+// Unwrap returns the reason of the error.
+//
+// Returns:
+//   - error: The reason of the error.
+func (e ErrDiverged) Unwrap() error {
+	return e.Reason
+}
+
+// NewErrDiverged creates a new ErrDiverged error.
+//
+// Parameters:
+//   - step: The 0-based index of the edit that failed.
+//   - path: The path of the edit that failed.
+//   - reason: The reason of the error.
+//
+// Returns:
+//   - *ErrDiverged: The new error. Never returns nil.
+func NewErrDiverged(step int, path []int, reason error) *ErrDiverged {
+	return &ErrDiverged{
+		Step:   step,
+		Path:   path,
+		Reason: reason,
+	}
 }
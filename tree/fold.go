@@ -0,0 +1,172 @@
+package tree
+
+import (
+	"iter"
+	"slices"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// FoldFunc is a function that computes the result of a node from the results of
+// its children.
+//
+// Parameters:
+//   - node: The node to compute the result of.
+//   - children: The results of the children of the node, in order. Empty if the
+//     node is a leaf.
+//
+// Returns:
+//   - R: The result of the node.
+//   - error: An error if the result could not be computed.
+type FoldFunc[T TreeNoder, R any] func(node T, children []R) (R, error)
+
+// fold_frame is a stack element of the fold.
+type fold_frame[T TreeNoder] struct {
+	// node is the node of the frame.
+	node T
+
+	// count is the number of children of the node.
+	count int
+
+	// expanded is the flag that indicates whether the children of the node have
+	// already been pushed onto the stack.
+	expanded bool
+}
+
+// Fold computes a value for each node of the tree from the values of its children
+// (i.e., bottom-up) and returns the value of the root.
+//
+// Parameters:
+//   - tree: The tree to fold.
+//   - fn: The function that computes the value of a node.
+//
+// Returns:
+//   - R: The value of the root.
+//   - error: An error if the fold fails.
+//
+// Errors:
+//   - *gcers.Err: If tree or fn is nil.
+//   - any error returned by fn.
+func Fold[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, R any](tree *Tree[T], fn FoldFunc[T, R]) (R, error) {
+	if tree == nil {
+		return *new(R), gcers.NewErrNilParameter("tree")
+	}
+
+	return FoldNode(tree.root, fn)
+}
+
+// FoldNode works like Fold but on the subtree rooted at the given node.
+//
+// Parameters:
+//   - node: The root of the subtree to fold.
+//   - fn: The function that computes the value of a node.
+//
+// Returns:
+//   - R: The value of the node.
+//   - error: An error if the fold fails.
+//
+// This function does not use recursion and is safe to use on very deep trees.
+func FoldNode[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	TreeNoder
+}, R any](node T, fn FoldFunc[T, R]) (R, error) {
+	return fold(node, fn, nil)
+}
+
+// FoldMemo works like FoldNode but it also returns the value computed for every
+// node of the subtree so that they can be queried afterwards.
+//
+// Parameters:
+//   - node: The root of the subtree to fold.
+//   - fn: The function that computes the value of a node.
+//
+// Returns:
+//   - R: The value of the node.
+//   - map[T]R: The value of every node of the subtree. Nil if the fold fails.
+//   - error: An error if the fold fails.
+func FoldMemo[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	TreeNoder
+}, R any](node T, fn FoldFunc[T, R]) (R, map[T]R, error) {
+	memo := make(map[T]R)
+
+	res, err := fold(node, fn, memo)
+	if err != nil {
+		return res, nil, err
+	}
+
+	return res, memo, nil
+}
+
+// fold is a helper function that performs the fold.
+//
+// Parameters:
+//   - node: The root of the subtree to fold.
+//   - fn: The function that computes the value of a node.
+//   - memo: The map where every value is stored. If nil, values are not stored.
+//
+// Returns:
+//   - R: The value of the node.
+//   - error: An error if the fold fails.
+func fold[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	TreeNoder
+}, R any](node T, fn FoldFunc[T, R], memo map[T]R) (R, error) {
+	if fn == nil {
+		return *new(R), gcers.NewErrNilParameter("fn")
+	}
+
+	stack := []fold_frame[T]{{node: node}}
+
+	var results []R
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+
+		if !top.expanded {
+			top.expanded = true
+
+			current := top.node
+
+			var count int
+
+			for child := range current.BackwardChild() {
+				stack = append(stack, fold_frame[T]{node: child})
+				count++
+			}
+
+			// top may have been invalidated by the appends.
+			stack[len(stack)-1-count].count = count
+
+			continue
+		}
+
+		current, count := top.node, top.count
+		stack = stack[:len(stack)-1]
+
+		children := slices.Clone(results[len(results)-count:])
+
+		res, err := fn(current, children)
+		if err != nil {
+			return *new(R), err
+		}
+
+		if memo != nil {
+			memo[current] = res
+		}
+
+		results = append(results[:len(results)-count], res)
+	}
+
+	return results[0], nil
+}
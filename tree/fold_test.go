@@ -0,0 +1,118 @@
+package tree_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// render folds a node into its s-expression, such as "a(b,c)".
+func render(node *root.StringNode, children []string) (string, error) {
+	if len(children) == 0 {
+		return node.Data, nil
+	}
+
+	return node.Data + "(" + strings.Join(children, ",") + ")", nil
+}
+
+func TestFold(t *testing.T) {
+	got, err := tree.Fold(sample_tree(), render)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a(b(d,e),c(f))"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	for range 200 {
+		tr := tree.NewTree(random_tree(r, 5, 6))
+
+		size, memo, err := tree.FoldMemo(tr.Root(), func(node *root.StringNode, children []int) (int, error) {
+			total := 1
+
+			for _, c := range children {
+				total += c
+			}
+
+			return total, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := len(all_nodes(tr.Root()))
+
+		if size != n || len(memo) != n {
+			t.Fatalf("got size %d and %d memoized values, want %d", size, len(memo), n)
+		}
+
+		for node := range tr.DFS() {
+			if want := len(all_nodes(node)); memo[node] != want {
+				t.Fatalf("node %s: got size %d, want %d", node.Data, memo[node], want)
+			}
+		}
+	}
+}
+
+func TestFoldErrors(t *testing.T) {
+	_, err := tree.Fold[*root.StringNode, string](nil, render)
+	if err == nil {
+		t.Error("Fold accepted a nil tree")
+	}
+
+	_, err = tree.Fold[*root.StringNode, string](sample_tree(), nil)
+	if err == nil {
+		t.Error("Fold accepted a nil function")
+	}
+
+	boom := errors.New("boom")
+
+	var calls []string
+
+	_, memo, err := tree.FoldMemo(sample_tree().Root(), func(node *root.StringNode, children []int) (int, error) {
+		calls = append(calls, node.Data)
+
+		if node.Data == "b" {
+			return 0, boom
+		}
+
+		return 0, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+
+	if memo != nil {
+		t.Error("a memo was returned along with the error")
+	}
+
+	if got := join_labels(calls); got != "d e b" {
+		t.Errorf("got calls %q, want the fold to stop at b", got)
+	}
+}
+
+func TestFoldDeep(t *testing.T) {
+	const n = 100000
+
+	depth, err := tree.Fold(chain(n), func(node *root.StringNode, children []int) (int, error) {
+		if len(children) == 0 {
+			return 1, nil
+		}
+
+		return children[0] + 1, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if depth != n {
+		t.Errorf("got depth %d, want %d", depth, n)
+	}
+}
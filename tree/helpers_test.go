@@ -0,0 +1,131 @@
+package tree_test
+
+import (
+	"errors"
+	"iter"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	gerr "github.com/PlayerR9/go-errors/error"
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// error_chain returns the messages of an error and of all its reasons, joined
+// by ": ".
+func error_chain(err error) string {
+	var msgs []string
+
+	for err != nil {
+		msgs = append(msgs, err.Error())
+
+		var e *gerr.Err
+
+		if errors.As(err, &e) && e.Info != nil && e.Inner != nil && e.Inner != err {
+			err = e.Inner
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+
+	return strings.Join(msgs, ": ")
+}
+
+// make_node creates a node with the given label and children.
+func make_node(data string, children ...*root.StringNode) *root.StringNode {
+	node := root.NewStringNode(data)
+
+	for _, child := range children {
+		node.AddChild(child)
+	}
+
+	return node
+}
+
+// sample_tree returns the tree a(b(d, e), c(f)).
+func sample_tree() *tree.Tree[*root.StringNode] {
+	return tree.NewTree(make_node("a",
+		make_node("b", make_node("d"), make_node("e")),
+		make_node("c", make_node("f")),
+	))
+}
+
+// chain returns a tree made of a single branch of n nodes.
+func chain(n int) *tree.Tree[*root.StringNode] {
+	top := root.NewStringNode("0")
+	node := top
+
+	for i := 1; i < n; i++ {
+		child := root.NewStringNode(strconv.Itoa(i))
+		node.AddChild(child)
+		node = child
+	}
+
+	return tree.NewTree(top)
+}
+
+// labels returns the labels of a sequence of nodes, joined by spaces.
+func labels(seq iter.Seq[*root.StringNode]) string {
+	var names []string
+
+	for node := range seq {
+		names = append(names, node.Data)
+	}
+
+	return join_labels(names)
+}
+
+// join_labels joins labels by spaces.
+func join_labels(names []string) string {
+	return strings.Join(names, " ")
+}
+
+// must_parse parses a tree of StringNode written in the box-drawing format.
+func must_parse(t *testing.T, text string) *tree.Tree[*root.StringNode] {
+	t.Helper()
+
+	tr, err := root.ParseStringTree(text)
+	if err != nil {
+		t.Fatalf("parse %q: %v", text, err)
+	}
+
+	return tr
+}
+
+// random_tree builds a random tree of the given depth whose labels are among the
+// first n letters.
+func random_tree(r *rand.Rand, depth, n int) *root.StringNode {
+	node := root.NewStringNode(string(rune('a' + r.Intn(n))))
+
+	if depth > 0 {
+		for i := r.Intn(4); i > 0; i-- {
+			node.AddChild(random_tree(r, depth-1, n))
+		}
+	}
+
+	return node
+}
+
+// all_nodes returns the nodes of a subtree in DFS order.
+func all_nodes(node *root.StringNode) []*root.StringNode {
+	return slices.Collect(tree.NewTree(node).DFS())
+}
+
+// same_tree checks whether two subtrees have the same shape and labels.
+func same_tree(a, b *root.StringNode) bool {
+	if a.Data != b.Data {
+		return false
+	}
+
+	ac, bc := slices.Collect(a.Child()), slices.Collect(b.Child())
+
+	return slices.EqualFunc(ac, bc, same_tree)
+}
+
+// same_data checks whether two nodes have the same label.
+func same_data(a, b *root.StringNode) bool {
+	return a.Data == b.Data
+}
@@ -0,0 +1,646 @@
+package tree
+
+import (
+	"iter"
+	"slices"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// link_change is a node whose children were changed by an operation.
+type link_change[T TreeNoder] struct {
+	// node is the node.
+	node T
+
+	// before and after are the children of the node before and after the
+	// operation.
+	before, after []T
+}
+
+// leaves_change is the part of the cached leaves that an operation replaced.
+type leaves_change[T TreeNoder] struct {
+	// at is the index of the first replaced leaf.
+	at int
+
+	// before and after are the replaced leaves and the ones that replaced them.
+	before, after []T
+}
+
+// new_leaves_change is a helper function that computes the part of the cached
+// leaves that an operation replaced; only that part is kept.
+//
+// Parameters:
+//   - before: The leaves before the operation.
+//   - after: The leaves after the operation.
+//
+// Returns:
+//   - leaves_change[T]: The replaced part.
+func new_leaves_change[T TreeNoder](before, after []T) leaves_change[T] {
+	n := min(len(before), len(after))
+
+	var i int
+
+	for i < n && before[i] == after[i] {
+		i++
+	}
+
+	var j int
+
+	for j < n-i && before[len(before)-1-j] == after[len(after)-1-j] {
+		j++
+	}
+
+	return leaves_change[T]{
+		at:     i,
+		before: slices.Clone(before[i : len(before)-j]),
+		after:  slices.Clone(after[i : len(after)-j]),
+	}
+}
+
+// apply is a helper method that replaces the part of the leaves back.
+//
+// Parameters:
+//   - leaves: The current leaves.
+//   - before: True to undo the operation, false to redo it.
+//
+// Returns:
+//   - []T: The new leaves. The current ones are not modified.
+func (c leaves_change[T]) apply(leaves []T, before bool) []T {
+	from, to := c.before, c.after
+	if before {
+		from, to = c.after, c.before
+	}
+
+	return slices.Concat(leaves[:c.at], to, leaves[c.at+len(from):])
+}
+
+// history_step is a recorded operation. Only the nodes that the operation touched
+// are recorded, along with the part of the cached leaves it replaced; so,
+// recording, undoing and redoing it is proportional to the size of the change
+// rather than to the size of the tree.
+type history_step[T TreeNoder] struct {
+	// changes are the nodes whose children changed.
+	changes []link_change[T]
+
+	// leaves is the part of the cached leaves that changed.
+	leaves leaves_change[T]
+
+	// before and after are the sizes of the tree before and after the operation.
+	before, after int
+}
+
+// is_empty is a helper method that checks whether the operation changed nothing.
+//
+// Returns:
+//   - bool: True if neither the children of a node nor the tree changed.
+func (s history_step[T]) is_empty() bool {
+	return len(s.changes) == 0 && len(s.leaves.before) == 0 && len(s.leaves.after) == 0 && s.before == s.after
+}
+
+// History records the operations on a tree so that they can be undone and redone.
+//
+// Every operation is recorded as the children of the nodes it touched and the
+// part of the cached leaves it replaced; the nodes themselves are never copied.
+// The content of the nodes is not recorded, and the tree must not be edited by
+// other means while the history is in use.
+type History[T interface {
+	AddChild(child T)
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	DeleteChild(child T) []T
+	GetFirstChild() (T, bool)
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	RemoveNode() []T
+	TreeNoder
+}] struct {
+	// tree is the tree.
+	tree *Tree[T]
+
+	// entries are the recorded entries, oldest first. Each entry is made of the
+	// operations that are undone and redone as one, in the order they were run.
+	entries [][]*history_step[T]
+
+	// pos is the number of entries that are applied; the ones after it can be
+	// redone.
+	pos int
+
+	// limit is the maximum number of entries. Zero or less means no limit.
+	limit int
+
+	// group are the operations being grouped, if any.
+	group []*history_step[T]
+
+	// depth is the number of nested calls to Group.
+	depth int
+}
+
+// NewHistory creates a new history for a tree.
+//
+// Parameters:
+//   - tree: The tree to record the operations of.
+//   - limit: The maximum number of operations that can be undone; the oldest
+//     ones are forgotten first. Zero or less means no limit.
+//
+// Returns:
+//   - *History[T]: The new history. Nil if an error occurs.
+//   - error: An error if tree is nil.
+func NewHistory[T interface {
+	AddChild(child T)
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	DeleteChild(child T) []T
+	GetFirstChild() (T, bool)
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	RemoveNode() []T
+	TreeNoder
+}](tree *Tree[T], limit int) (*History[T], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	}
+
+	return &History[T]{
+		tree:  tree,
+		limit: limit,
+	}, nil
+}
+
+// Tree returns the tree of the history.
+//
+// Returns:
+//   - *Tree[T]: The tree. Never returns nil.
+func (h History[T]) Tree() *Tree[T] {
+	return h.tree
+}
+
+// CanUndo checks whether an operation can be undone.
+//
+// Returns:
+//   - bool: True if Undo would succeed, false otherwise.
+func (h History[T]) CanUndo() bool {
+	return h.pos > 0
+}
+
+// CanRedo checks whether an operation can be redone.
+//
+// Returns:
+//   - bool: True if Redo would succeed, false otherwise.
+func (h History[T]) CanRedo() bool {
+	return h.pos < len(h.entries)
+}
+
+// restore is a helper method that puts the tree back in the state before or after
+// an operation.
+//
+// Parameters:
+//   - s: The operation.
+//   - before: True to undo the operation, false to redo it.
+func (h *History[T]) restore(s *history_step[T], before bool) {
+	targets := make(map[T][]T, len(s.changes))
+
+	for _, change := range s.changes {
+		if before {
+			targets[change.node] = change.before
+		} else {
+			targets[change.node] = change.after
+		}
+	}
+
+	// LinkChildren cannot remove every child of a node, so such nodes are cleaned
+	// up instead; which also detaches them from their parent. If the parent is
+	// not relinked anyway, its children are linked again afterwards.
+	reattach := make(map[T][]T)
+
+	for node, children := range targets {
+		if len(children) > 0 || node.IsLeaf() {
+			continue
+		}
+
+		parent, ok := node.GetParent()
+		if !ok {
+			continue
+		}
+
+		_, ok = targets[parent]
+		if ok {
+			continue
+		}
+
+		_, ok = reattach[parent]
+		if !ok {
+			reattach[parent] = slices.Collect(parent.Child())
+		}
+	}
+
+	for node, children := range targets {
+		if len(children) == 0 && !node.IsLeaf() {
+			node.Cleanup()
+		}
+	}
+
+	for node, children := range targets {
+		if len(children) > 0 {
+			node.LinkChildren(children)
+		}
+	}
+
+	for parent, children := range reattach {
+		parent.LinkChildren(children)
+	}
+
+	h.tree.leaves = s.leaves.apply(h.tree.leaves, before)
+
+	if before {
+		h.tree.size = s.before
+	} else {
+		h.tree.size = s.after
+	}
+}
+
+// rollback is a helper method that undoes operations, the last one first.
+//
+// Parameters:
+//   - steps: The operations, in the order they were run.
+func (h *History[T]) rollback(steps []*history_step[T]) {
+	for _, s := range slices.Backward(steps) {
+		h.restore(s, true)
+	}
+}
+
+// record is a helper method that runs an operation and records it.
+//
+// Parameters:
+//   - op: The operation. It is given a function that it must call with every
+//     node whose children it is about to change, before changing them.
+//
+// Returns:
+//   - error: The error of the operation. In that case, the operation is undone
+//     and not recorded.
+//
+// The operation must not modify the cached leaves in place, as they are kept to
+// find the part that it replaced.
+func (h *History[T]) record(op func(touch func(node T)) error) error {
+	s := &history_step[T]{
+		before: h.tree.size,
+	}
+
+	leaves := h.tree.leaves
+	touched := make(map[T]struct{})
+
+	err := op(func(node T) {
+		_, ok := touched[node]
+		if ok {
+			return
+		}
+
+		touched[node] = struct{}{}
+
+		s.changes = append(s.changes, link_change[T]{
+			node:   node,
+			before: slices.Collect(node.Child()),
+		})
+	})
+
+	for i := range s.changes {
+		s.changes[i].after = slices.Collect(s.changes[i].node.Child())
+	}
+
+	s.changes = slices.DeleteFunc(s.changes, func(change link_change[T]) bool {
+		return slices.Equal(change.before, change.after)
+	})
+
+	s.leaves = new_leaves_change(leaves, h.tree.leaves)
+	s.after = h.tree.size
+
+	if err != nil {
+		h.restore(s, true)
+		return err
+	}
+
+	if s.is_empty() {
+		return nil
+	}
+
+	if h.depth > 0 {
+		h.group = append(h.group, s)
+	} else {
+		h.push([]*history_step[T]{s})
+	}
+
+	return nil
+}
+
+// touch_subtree is a helper function that touches every node of a subtree that
+// has children.
+//
+// Parameters:
+//   - node: The root of the subtree.
+//   - touch: The function to call.
+func touch_subtree[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](node T, touch func(node T)) {
+	stack := []T{node}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if top.IsLeaf() {
+			continue
+		}
+
+		touch(top)
+
+		for child := range top.Child() {
+			stack = append(stack, child)
+		}
+	}
+}
+
+// push is a helper method that adds an entry to the history, which forgets the
+// entries that could be redone.
+//
+// Parameters:
+//   - steps: The operations of the entry.
+func (h *History[T]) push(steps []*history_step[T]) {
+	h.entries = append(h.entries[:h.pos], steps)
+	h.pos++
+
+	if h.limit > 0 && len(h.entries) > h.limit {
+		extra := len(h.entries) - h.limit
+
+		h.entries = slices.Delete(h.entries, 0, extra)
+		h.pos -= extra
+	}
+}
+
+// Undo undoes the last operation.
+//
+// Returns:
+//   - error: NothingToUndo if no operation can be undone, or an error if called
+//     within Group.
+func (h *History[T]) Undo() error {
+	if h.depth > 0 {
+		return gcers.NewErrInvalidUsage("cannot undo within a group", "Please call Undo after Group returns")
+	} else if h.pos == 0 {
+		return NothingToUndo
+	}
+
+	h.pos--
+	h.rollback(h.entries[h.pos])
+
+	return nil
+}
+
+// Redo redoes the last undone operation.
+//
+// Returns:
+//   - error: NothingToRedo if no operation can be redone, or an error if called
+//     within Group.
+func (h *History[T]) Redo() error {
+	if h.depth > 0 {
+		return gcers.NewErrInvalidUsage("cannot redo within a group", "Please call Redo after Group returns")
+	} else if h.pos == len(h.entries) {
+		return NothingToRedo
+	}
+
+	for _, s := range h.entries[h.pos] {
+		h.restore(s, false)
+	}
+
+	h.pos++
+
+	return nil
+}
+
+// Clear forgets every recorded operation. The tree is unchanged.
+func (h *History[T]) Clear() {
+	h.entries = nil
+	h.pos = 0
+}
+
+// Group runs a function whose operations are undone and redone as one.
+//
+// Parameters:
+//   - fn: The function, which calls the operations of the history.
+//
+// Returns:
+//   - error: An error if fn is nil, or the error of fn. In that case, the
+//     operations of fn are undone and not recorded.
+//
+// Groups can be nested; the operations of the inner groups belong to the
+// outermost one.
+func (h *History[T]) Group(fn func() error) error {
+	if fn == nil {
+		return gcers.NewErrNilParameter("fn")
+	}
+
+	h.depth++
+
+	err := fn()
+
+	h.depth--
+
+	if h.depth > 0 {
+		return err
+	}
+
+	group := h.group
+	h.group = nil
+
+	if err != nil {
+		h.rollback(group)
+		return err
+	}
+
+	if len(group) > 0 {
+		h.push(group)
+	}
+
+	return nil
+}
+
+// Do runs and records an arbitrary operation on the tree.
+//
+// Parameters:
+//   - fn: The operation. It must call touch with every node whose children it
+//     is about to change, before changing them, and keep the cached leaves and
+//     size of the tree up to date, as the functions of this package do.
+//
+// Returns:
+//   - error: An error if fn is nil, or the error of fn. In that case, the
+//     operation is undone and not recorded.
+func (h *History[T]) Do(fn func(tree *Tree[T], touch func(node T)) error) error {
+	if fn == nil {
+		return gcers.NewErrNilParameter("fn")
+	}
+
+	return h.record(func(touch func(node T)) error {
+		// fn may modify the cached leaves in place.
+		h.tree.leaves = slices.Clone(h.tree.leaves)
+
+		return fn(h.tree, touch)
+	})
+}
+
+// SetChildren records Tree.SetChildren. Only the root is recorded.
+//
+// Parameters:
+//   - children: The children to set.
+//
+// Returns:
+//   - error: The error of Tree.SetChildren.
+func (h *History[T]) SetChildren(children []*Tree[T]) error {
+	return h.record(func(touch func(node T)) error {
+		touch(h.tree.root)
+
+		return h.tree.SetChildren(children)
+	})
+}
+
+// Cleanup records Tree.Cleanup. Every node that has children is recorded.
+func (h *History[T]) Cleanup() {
+	_ = h.record(func(touch func(node T)) error {
+		touch_subtree(h.tree.root, touch)
+
+		h.tree.Cleanup()
+
+		return nil
+	})
+}
+
+// ProcessLeaves records Tree.ProcessLeaves. Only the leaves that are given
+// children are recorded.
+//
+// Parameters:
+//   - f: The function to apply to the leaves.
+//
+// Returns:
+//   - error: The error of the function. In that case, the tree is left unchanged.
+func (h *History[T]) ProcessLeaves(f func(node T) ([]T, error)) error {
+	if f == nil {
+		return nil
+	}
+
+	return h.record(func(touch func(node T)) error {
+		// Tree.ProcessLeaves modifies the cached leaves in place.
+		h.tree.leaves = slices.Clone(h.tree.leaves)
+
+		return h.tree.ProcessLeaves(func(node T) ([]T, error) {
+			children, err := f(node)
+			if err == nil && len(children) > 0 {
+				touch(node)
+			}
+
+			return children, err
+		})
+	})
+}
+
+// Prune records Prune. Only the branches that are deleted, and the nodes they are
+// deleted from, are recorded.
+//
+// Parameters:
+//   - filter: The filter to use to prune the tree. Must return true iff the node
+//     should be pruned.
+//
+// Returns:
+//   - bool: The result of Prune.
+func (h *History[T]) Prune(filter func(node T) bool) bool {
+	if filter == nil {
+		return Prune(h.tree, filter)
+	}
+
+	var ok bool
+
+	_ = h.record(func(touch func(node T)) error {
+		// Prune deletes the branch of a node as soon as the filter matches it.
+		ok = Prune(h.tree, func(node T) bool {
+			if !filter(node) {
+				return false
+			}
+
+			child, parent, has_branching := FindBranchingPoint(node)
+			if parent == nil || !has_branching {
+				touch_subtree(h.tree.root, touch)
+			} else {
+				touch(*parent)
+				touch_subtree(child, touch)
+			}
+
+			return true
+		})
+
+		return nil
+	})
+
+	return ok
+}
+
+// SkipFilter records SkipFilter. Only the nodes that are removed, and their
+// parents, are recorded.
+//
+// Parameters:
+//   - filter: The filter to apply.
+//
+// Returns:
+//   - []*Tree[T]: The result of SkipFilter. If the root is skipped, the returned
+//     trees are not recorded by the history.
+func (h *History[T]) SkipFilter(filter func(node T) bool) []*Tree[T] {
+	if filter == nil {
+		return SkipFilter(h.tree, filter)
+	}
+
+	var forest []*Tree[T]
+
+	_ = h.record(func(touch func(node T)) error {
+		// SkipFilter removes every node that matches right away.
+		forest = SkipFilter(h.tree, func(node T) bool {
+			if !filter(node) {
+				return false
+			}
+
+			parent, ok := node.GetParent()
+			if ok {
+				touch(parent)
+			}
+
+			touch(node)
+
+			return true
+		})
+
+		return nil
+	})
+
+	return forest
+}
+
+// InsertBranch records InsertBranch. Only the nodes of the branch are recorded.
+//
+// Parameters:
+//   - branch: The branch to insert.
+//
+// Returns:
+//   - error: The error of InsertBranch. In that case, the tree is left unchanged.
+func (h *History[T]) InsertBranch(branch *Branch[T]) error {
+	if branch == nil {
+		return nil
+	}
+
+	return h.record(func(touch func(node T)) error {
+		// InsertBranch only adds children to the nodes of the branch.
+		for _, node := range branch.Path() {
+			touch(node)
+		}
+
+		_, err := InsertBranch(h.tree, branch)
+		return err
+	})
+}
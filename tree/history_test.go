@@ -0,0 +1,508 @@
+package tree_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// history_state describes a tree along with its cached leaves and size.
+func history_state(tr *tree.Tree[*root.StringNode]) string {
+	var builder strings.Builder
+
+	builder.WriteString(tr.String())
+	builder.WriteString("leaves:")
+
+	for _, leaf := range tr.Leaves() {
+		builder.WriteRune(' ')
+		builder.WriteString(leaf.Data)
+	}
+
+	builder.WriteString("\nsize: ")
+	builder.WriteString(strconv.Itoa(tr.Size()))
+
+	return builder.String()
+}
+
+// new_history creates a history for a parsed tree.
+func new_history(t *testing.T, text string, limit int) *tree.History[*root.StringNode] {
+	t.Helper()
+
+	h, err := tree.NewHistory(must_parse(t, text), limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+// find_node returns the first node of a tree with the given label.
+func find_node(t *testing.T, tr *tree.Tree[*root.StringNode], name string) *root.StringNode {
+	t.Helper()
+
+	node, ok := tr.SearchNodes(func(node *root.StringNode) bool {
+		return node.Data == name
+	})
+	if !ok {
+		t.Fatalf("no node %q", name)
+	}
+
+	return node
+}
+
+// move_node is a Do operation that moves a node under another one, as their last
+// child.
+func move_node(node, dst *root.StringNode) func(tr *tree.Tree[*root.StringNode], touch func(node *root.StringNode)) error {
+	return func(tr *tree.Tree[*root.StringNode], touch func(node *root.StringNode)) error {
+		parent, _ := node.GetParent()
+
+		touch(parent)
+		touch(node)
+		touch(dst)
+
+		// DeleteChild also detaches the children of the node.
+		children := slices.Collect(node.Child())
+
+		parent.DeleteChild(node)
+		node.LinkChildren(children)
+		dst.AddChild(node)
+
+		tr.RegenerateLeaves()
+
+		return nil
+	}
+}
+
+func TestHistoryOperations(t *testing.T) {
+	const text = "a\n├── b\n│   ├── c\n│   └── d\n│       └── f\n└── e\n"
+
+	tests := []struct {
+		name string
+		op   func(t *testing.T, h *tree.History[*root.StringNode])
+	}{
+		{
+			name: "SetChildren",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				err := h.SetChildren([]*tree.Tree[*root.StringNode]{
+					tree.NewTree(root.NewStringNode("x")),
+					must_parse(t, "y\n└── z\n"),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "Cleanup",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				h.Cleanup()
+			},
+		},
+		{
+			name: "ProcessLeaves",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				err := h.ProcessLeaves(func(node *root.StringNode) ([]*root.StringNode, error) {
+					if node.Data != "e" {
+						return nil, nil
+					}
+
+					return []*root.StringNode{root.NewStringNode("x"), root.NewStringNode("y")}, nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "Prune",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				ok := h.Prune(func(node *root.StringNode) bool {
+					return node.Data == "c"
+				})
+				if !ok {
+					t.Fatal("the whole tree was pruned")
+				}
+			},
+		},
+		{
+			name: "SkipFilter",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				forest := h.SkipFilter(func(node *root.StringNode) bool {
+					return node.Data == "b" || node.Data == "f"
+				})
+				if len(forest) != 1 || forest[0] != h.Tree() {
+					t.Fatalf("got %d trees, want the tree itself", len(forest))
+				}
+			},
+		},
+		{
+			name: "Do",
+			op: func(t *testing.T, h *tree.History[*root.StringNode]) {
+				tr := h.Tree()
+
+				err := h.Do(move_node(find_node(t, tr, "d"), find_node(t, tr, "e")))
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := new_history(t, text, 0)
+			before := history_state(h.Tree())
+
+			tt.op(t, h)
+
+			after := history_state(h.Tree())
+			if after == before {
+				t.Fatal("the operation changed nothing")
+			}
+
+			for range 2 {
+				err := h.Undo()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := history_state(h.Tree())
+				if got != before {
+					t.Fatalf("after Undo, got:\n%s\nwant:\n%s", got, before)
+				}
+
+				err = tree.Validate(h.Tree())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = h.Redo()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got = history_state(h.Tree())
+				if got != after {
+					t.Fatalf("after Redo, got:\n%s\nwant:\n%s", got, after)
+				}
+			}
+
+			if h.CanRedo() {
+				t.Error("CanRedo is true after redoing everything")
+			}
+		})
+	}
+}
+
+func TestHistoryInsertOwnBranch(t *testing.T) {
+	h := new_history(t, "a\n├── b\n│   └── c\n└── d\n", 0)
+	before := history_state(h.Tree())
+
+	branch, err := tree.NewBranch(find_node(t, h.Tree(), "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.InsertBranch(branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := history_state(h.Tree())
+	if got != before {
+		t.Errorf("got:\n%s\nwant:\n%s", got, before)
+	}
+
+	if h.CanUndo() {
+		t.Error("a branch that is already in the tree was recorded")
+	}
+}
+
+func TestHistoryErrors(t *testing.T) {
+	_, err := tree.NewHistory[*root.StringNode](nil, 0)
+	if err == nil {
+		t.Error("NewHistory accepted a nil tree")
+	}
+
+	h := new_history(t, "a\n├── b\n└── c\n", 0)
+
+	err = h.Undo()
+	if !errors.Is(err, tree.NothingToUndo) {
+		t.Errorf("got %v, want NothingToUndo", err)
+	}
+
+	err = h.Redo()
+	if !errors.Is(err, tree.NothingToRedo) {
+		t.Errorf("got %v, want NothingToRedo", err)
+	}
+
+	before := history_state(h.Tree())
+	boom := errors.New("boom")
+
+	err = h.ProcessLeaves(func(node *root.StringNode) ([]*root.StringNode, error) {
+		if node.Data == "c" {
+			return nil, boom
+		}
+
+		return []*root.StringNode{root.NewStringNode("x")}, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+
+	got := history_state(h.Tree())
+	if got != before {
+		t.Errorf("a failed operation was not undone, got:\n%s\nwant:\n%s", got, before)
+	}
+
+	if h.CanUndo() {
+		t.Error("a failed operation was recorded")
+	}
+
+	err = h.Group(func() error {
+		err := h.Undo()
+		if err == nil {
+			t.Error("Undo succeeded within a group")
+		}
+
+		err = h.Redo()
+		if err == nil {
+			t.Error("Redo succeeded within a group")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistoryGroup(t *testing.T) {
+	h := new_history(t, "a\n├── b\n│   └── c\n├── d\n└── e\n", 0)
+	tr := h.Tree()
+	before := history_state(tr)
+
+	err := h.Group(func() error {
+		err := h.Do(move_node(find_node(t, tr, "c"), find_node(t, tr, "e")))
+		if err != nil {
+			return err
+		}
+
+		return h.Group(func() error {
+			_ = h.SkipFilter(func(node *root.StringNode) bool {
+				return node.Data == "b"
+			})
+
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := history_state(tr)
+
+	err = h.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := history_state(tr); got != before {
+		t.Fatalf("after Undo, got:\n%s\nwant:\n%s", got, before)
+	}
+
+	if h.CanUndo() {
+		t.Error("the group was recorded as several operations")
+	}
+
+	err = h.Redo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := history_state(tr); got != after {
+		t.Fatalf("after Redo, got:\n%s\nwant:\n%s", got, after)
+	}
+
+	// A failing group is undone as a whole.
+	boom := errors.New("boom")
+
+	err = h.Group(func() error {
+		h.Cleanup()
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+
+	if got := history_state(tr); got != after {
+		t.Fatalf("after a failed group, got:\n%s\nwant:\n%s", got, after)
+	}
+
+	err = h.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := history_state(tr); got != before {
+		t.Fatalf("the failed group was recorded, got:\n%s\nwant:\n%s", got, before)
+	}
+
+	err = h.Group(nil)
+	if err == nil {
+		t.Error("Group accepted a nil function")
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	h := new_history(t, "a\n├── b\n├── c\n├── d\n└── e\n", 2)
+	tr := h.Tree()
+
+	var states []string
+
+	for _, name := range []string{"b", "c", "d"} {
+		states = append(states, history_state(tr))
+
+		err := h.Do(move_node(find_node(t, tr, name), find_node(t, tr, "e")))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 2; i > 0; i-- {
+		err := h.Undo()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := history_state(tr); got != states[i] {
+			t.Fatalf("after Undo, got:\n%s\nwant:\n%s", got, states[i])
+		}
+	}
+
+	err := h.Undo()
+	if !errors.Is(err, tree.NothingToUndo) {
+		t.Fatalf("got %v, want NothingToUndo past the limit", err)
+	}
+
+	// A new operation forgets the ones that could be redone.
+	h.Cleanup()
+
+	err = h.Redo()
+	if !errors.Is(err, tree.NothingToRedo) {
+		t.Fatalf("got %v, want NothingToRedo", err)
+	}
+
+	h.Clear()
+
+	if h.CanUndo() || h.CanRedo() {
+		t.Error("Clear kept some operations")
+	}
+}
+
+func TestHistoryRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for trial := range 200 {
+		tr := tree.NewTree(random_tree(r, 4, 6))
+
+		// The labels are made unique, so that the states tell the nodes apart.
+		for i, node := range all_nodes(tr.Root()) {
+			node.Data += strconv.Itoa(i)
+		}
+
+		h, err := tree.NewHistory(tr, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		states := []string{history_state(tr)}
+
+		for range 8 {
+			nodes := all_nodes(tr.Root())
+			name := string(rune('a' + r.Intn(6)))
+
+			switch r.Intn(4) {
+			case 0:
+				_ = h.Prune(func(node *root.StringNode) bool {
+					_, ok := node.GetParent()
+					return ok && strings.HasPrefix(node.Data, name)
+				})
+			case 1:
+				_ = h.SkipFilter(func(node *root.StringNode) bool {
+					_, ok := node.GetParent()
+					return ok && strings.HasPrefix(node.Data, name)
+				})
+			case 2:
+				n := nodes[r.Intn(len(nodes))]
+				dst := nodes[r.Intn(len(nodes))]
+
+				_, ok := n.GetParent()
+				if !ok || slices.Contains(all_nodes(n), dst) {
+					continue
+				}
+
+				err := h.Do(move_node(n, dst))
+				if err != nil {
+					t.Fatal(err)
+				}
+			case 3:
+				err := h.ProcessLeaves(func(node *root.StringNode) ([]*root.StringNode, error) {
+					if !strings.HasPrefix(node.Data, name) {
+						return nil, nil
+					}
+
+					return []*root.StringNode{root.NewStringNode(node.Data + "'")}, nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if h.CanRedo() {
+				t.Fatalf("trial %d: an operation did not forget the undone ones", trial)
+			}
+
+			err := tree.Validate(tr)
+			if err != nil {
+				t.Fatalf("trial %d: %v", trial, err)
+			}
+
+			if state := history_state(tr); state != states[len(states)-1] {
+				states = append(states, state)
+			}
+		}
+
+		for i := len(states) - 2; i >= 0; i-- {
+			err := h.Undo()
+			if err != nil {
+				t.Fatalf("trial %d: %v", trial, err)
+			}
+
+			if got := history_state(tr); got != states[i] {
+				t.Fatalf("trial %d: after Undo, got:\n%s\nwant:\n%s", trial, got, states[i])
+			}
+		}
+
+		if h.CanUndo() {
+			t.Fatalf("trial %d: more operations were recorded than changes made", trial)
+		}
+
+		for i := 1; i < len(states); i++ {
+			err := h.Redo()
+			if err != nil {
+				t.Fatalf("trial %d: %v", trial, err)
+			}
+
+			if got := history_state(tr); got != states[i] {
+				t.Fatalf("trial %d: after Redo, got:\n%s\nwant:\n%s", trial, got, states[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package tree
+
+import (
+	"iter"
+)
+
+// Position is the position of a node within the tree.
+type Position struct {
+	// Depth is the depth of the node. The root is at depth 0.
+	Depth int
+
+	// Index is the index of the node among its siblings. The root is at index 0.
+	Index int
+}
+
+// post_order_frame is a stack element of the post-order traversals.
+type post_order_frame[T TreeNoder] struct {
+	// node is the node of the frame.
+	node T
+
+	// pos is the position of the node.
+	pos Position
+
+	// expanded is the flag that indicates whether the children of the node have
+	// already been pushed onto the stack.
+	expanded bool
+}
+
+// PostOrder applies the post-order traversal logic to the tree. Children are
+// yielded before their parent.
+//
+// Returns:
+//   - iter.Seq[T]: The traversal sequence.
+//
+// Despite being a DFS traversal, this function does not use recursion and is safe to use
+// on very deep trees.
+func (t *Tree[T]) PostOrder() iter.Seq[T] {
+	if t == nil {
+		return func(yield func(T) bool) {}
+	}
+
+	fn := func(yield func(T) bool) {
+		for _, node := range t.post_order(false) {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// ReverseDFS yields the nodes of the tree in the reverse order of DFS. That is,
+// the last node of the pre-order traversal is yielded first and the root is
+// yielded last.
+//
+// Returns:
+//   - iter.Seq[T]: The traversal sequence.
+//
+// This function does not use recursion and is safe to use on very deep trees.
+func (t *Tree[T]) ReverseDFS() iter.Seq[T] {
+	if t == nil {
+		return func(yield func(T) bool) {}
+	}
+
+	fn := func(yield func(T) bool) {
+		for _, node := range t.post_order(true) {
+			if !yield(node) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// DFSWithDepth works like DFS but it also yields the depth of each node.
+//
+// Returns:
+//   - iter.Seq2[int, T]: The traversal sequence where the key is the depth of the node.
+func (t *Tree[T]) DFSWithDepth() iter.Seq2[int, T] {
+	if t == nil {
+		return func(yield func(int, T) bool) {}
+	}
+
+	fn := func(yield func(int, T) bool) {
+		for pos, node := range t.DFSWithPosition() {
+			if !yield(pos.Depth, node) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// BFSWithDepth works like BFS but it also yields the depth of each node.
+//
+// Returns:
+//   - iter.Seq2[int, T]: The traversal sequence where the key is the depth of the node.
+func (t *Tree[T]) BFSWithDepth() iter.Seq2[int, T] {
+	if t == nil {
+		return func(yield func(int, T) bool) {}
+	}
+
+	fn := func(yield func(int, T) bool) {
+		for pos, node := range t.BFSWithPosition() {
+			if !yield(pos.Depth, node) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// PostOrderWithDepth works like PostOrder but it also yields the depth of each node.
+//
+// Returns:
+//   - iter.Seq2[int, T]: The traversal sequence where the key is the depth of the node.
+func (t *Tree[T]) PostOrderWithDepth() iter.Seq2[int, T] {
+	if t == nil {
+		return func(yield func(int, T) bool) {}
+	}
+
+	fn := func(yield func(int, T) bool) {
+		for pos, node := range t.post_order(false) {
+			if !yield(pos.Depth, node) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// DFSWithPosition works like DFS but it also yields the depth and the sibling
+// index of each node.
+//
+// Returns:
+//   - iter.Seq2[Position, T]: The traversal sequence.
+func (t *Tree[T]) DFSWithPosition() iter.Seq2[Position, T] {
+	if t == nil {
+		return func(yield func(Position, T) bool) {}
+	}
+
+	type StackElement struct {
+		node T
+		pos  Position
+	}
+
+	fn := func(yield func(Position, T) bool) {
+		stack := []StackElement{{node: t.root}}
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if !yield(top.pos, top.node) {
+				return
+			}
+
+			count := count_children(top.node)
+
+			for child := range top.node.BackwardChild() {
+				count--
+
+				stack = append(stack, StackElement{
+					node: child,
+					pos:  Position{Depth: top.pos.Depth + 1, Index: count},
+				})
+			}
+		}
+	}
+
+	return fn
+}
+
+// BFSWithPosition works like BFS but it also yields the depth and the sibling
+// index of each node.
+//
+// Returns:
+//   - iter.Seq2[Position, T]: The traversal sequence.
+func (t *Tree[T]) BFSWithPosition() iter.Seq2[Position, T] {
+	if t == nil {
+		return func(yield func(Position, T) bool) {}
+	}
+
+	type QueueElement struct {
+		node T
+		pos  Position
+	}
+
+	fn := func(yield func(Position, T) bool) {
+		queue := []QueueElement{{node: t.root}}
+
+		for len(queue) > 0 {
+			first := queue[0]
+			queue = queue[1:]
+
+			if !yield(first.pos, first.node) {
+				return
+			}
+
+			var idx int
+
+			for child := range first.node.Child() {
+				queue = append(queue, QueueElement{
+					node: child,
+					pos:  Position{Depth: first.pos.Depth + 1, Index: idx},
+				})
+
+				idx++
+			}
+		}
+	}
+
+	return fn
+}
+
+// PostOrderWithPosition works like PostOrder but it also yields the depth and
+// the sibling index of each node.
+//
+// Returns:
+//   - iter.Seq2[Position, T]: The traversal sequence.
+func (t *Tree[T]) PostOrderWithPosition() iter.Seq2[Position, T] {
+	if t == nil {
+		return func(yield func(Position, T) bool) {}
+	}
+
+	return t.post_order(false)
+}
+
+// post_order is a helper function that performs a non-recursive post-order
+// traversal of the tree.
+//
+// Parameters:
+//   - reverse: If true, the children are visited from the last to the first one;
+//     which yields the reverse of the pre-order traversal.
+//
+// Returns:
+//   - iter.Seq2[Position, T]: The traversal sequence.
+//
+// Assumes that the receiver is not nil.
+func (t *Tree[T]) post_order(reverse bool) iter.Seq2[Position, T] {
+	fn := func(yield func(Position, T) bool) {
+		stack := []post_order_frame[T]{{node: t.root}}
+
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+
+			if top.expanded || top.node.IsLeaf() {
+				pos, node := top.pos, top.node
+				stack = stack[:len(stack)-1]
+
+				if !yield(pos, node) {
+					return
+				}
+
+				continue
+			}
+
+			top.expanded = true
+
+			node, depth := top.node, top.pos.Depth+1
+
+			if reverse {
+				var idx int
+
+				for child := range node.Child() {
+					stack = append(stack, post_order_frame[T]{
+						node: child,
+						pos:  Position{Depth: depth, Index: idx},
+					})
+
+					idx++
+				}
+			} else {
+				idx := count_children(node)
+
+				for child := range node.BackwardChild() {
+					idx--
+
+					stack = append(stack, post_order_frame[T]{
+						node: child,
+						pos:  Position{Depth: depth, Index: idx},
+					})
+				}
+			}
+		}
+	}
+
+	return fn
+}
+
+// count_children is a helper function that counts the direct children of the
+// given node.
+//
+// Parameters:
+//   - node: The node whose children are counted.
+//
+// Returns:
+//   - int: The number of children.
+func count_children[T interface {
+	Child() iter.Seq[T]
+}](node T) int {
+	var count int
+
+	for range node.Child() {
+		count++
+	}
+
+	return count
+}
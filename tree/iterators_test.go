@@ -0,0 +1,97 @@
+package tree_test
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// with_depth describes a sequence of nodes along with their depths.
+func with_depth(seq iter.Seq2[int, *root.StringNode]) string {
+	var parts []string
+
+	for depth, node := range seq {
+		parts = append(parts, fmt.Sprintf("%s%d", node.Data, depth))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// with_position describes a sequence of nodes along with their positions.
+func with_position(seq iter.Seq2[tree.Position, *root.StringNode]) string {
+	var parts []string
+
+	for pos, node := range seq {
+		parts = append(parts, fmt.Sprintf("%s%d.%d", node.Data, pos.Depth, pos.Index))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestIterators(t *testing.T) {
+	tr := sample_tree()
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"DFS", labels(tr.DFS()), "a b d e c f"},
+		{"PostOrder", labels(tr.PostOrder()), "d e b f c a"},
+		{"ReverseDFS", labels(tr.ReverseDFS()), "f c e d b a"},
+		{"DFSWithDepth", with_depth(tr.DFSWithDepth()), "a0 b1 d2 e2 c1 f2"},
+		{"BFSWithDepth", with_depth(tr.BFSWithDepth()), "a0 b1 c1 d2 e2 f2"},
+		{"PostOrderWithDepth", with_depth(tr.PostOrderWithDepth()), "d2 e2 b1 f2 c1 a0"},
+		{"DFSWithPosition", with_position(tr.DFSWithPosition()), "a0.0 b1.0 d2.0 e2.1 c1.1 f2.0"},
+		{"BFSWithPosition", with_position(tr.BFSWithPosition()), "a0.0 b1.0 c1.1 d2.0 e2.1 f2.0"},
+		{"PostOrderWithPosition", with_position(tr.PostOrderWithPosition()), "d2.0 e2.1 b1.0 f2.0 c1.1 a0.0"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestIteratorsEarlyStop(t *testing.T) {
+	tr := sample_tree()
+
+	var names []string
+
+	for node := range tr.PostOrder() {
+		names = append(names, node.Data)
+
+		if node.Data == "b" {
+			break
+		}
+	}
+
+	if got := strings.Join(names, " "); got != "d e b" {
+		t.Errorf("got %q, want %q", got, "d e b")
+	}
+}
+
+func TestIteratorsDeep(t *testing.T) {
+	const n = 100000
+
+	tr := chain(n)
+
+	var count int
+
+	for depth, node := range tr.PostOrderWithDepth() {
+		if count == 0 && depth != n-1 {
+			t.Fatalf("the first node %s is at depth %d, want %d", node.Data, depth, n-1)
+		}
+
+		count++
+	}
+
+	if count != n {
+		t.Errorf("got %d nodes, want %d", count, n)
+	}
+}
@@ -0,0 +1,579 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"strconv"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// JSONFormat is the shape of the JSON representation of a tree.
+type JSONFormat int
+
+const (
+	// NestedJSON is the nested shape where every node is an object of the form
+	// {"data": <data>, "children": [<node>, ...]}.
+	//
+	// It goes through encoding/json, which refuses to decode (and, depending on the
+	// Go version, to encode) objects and arrays nested beyond 10000 levels; so trees
+	// deeper than about 5000 nodes cannot be round-tripped in this format. Use
+	// FlatJSON for them.
+	NestedJSON JSONFormat = iota
+
+	// FlatJSON is the flat shape where the tree is an array, in DFS order, of
+	// objects of the form {"id": <id>, "parent": <id or null>, "data": <data>}.
+	// Better suited for very large or very deep trees.
+	FlatJSON
+)
+
+// String implements the fmt.Stringer interface.
+func (f JSONFormat) String() string {
+	switch f {
+	case NestedJSON:
+		return "nested"
+	case FlatJSON:
+		return "flat"
+	default:
+		return "unknown format"
+	}
+}
+
+// json_node is the nested JSON representation of a node.
+type json_node struct {
+	// Data is the payload of the node.
+	Data json.RawMessage `json:"data"`
+
+	// Children are the children of the node.
+	Children []*json_node `json:"children,omitempty"`
+}
+
+// json_entry is the flat JSON representation of a node.
+type json_entry struct {
+	// ID is the identifier of the node.
+	ID int `json:"id"`
+
+	// Parent is the identifier of the parent of the node. Nil for the root.
+	Parent *int `json:"parent"`
+
+	// Data is the payload of the node.
+	Data json.RawMessage `json:"data"`
+}
+
+// EncodeJSON encodes the tree into JSON.
+//
+// Parameters:
+//   - tree: The tree to encode.
+//   - format: The shape of the output.
+//   - data_fn: The function that encodes the payload of a node into JSON.
+//
+// Returns:
+//   - []byte: The JSON representation of the tree.
+//   - error: An error if the encoding fails.
+func EncodeJSON[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](tree *Tree[T], format JSONFormat, data_fn func(node T) ([]byte, error)) ([]byte, error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	} else if data_fn == nil {
+		return nil, gcers.NewErrNilParameter("data_fn")
+	}
+
+	switch format {
+	case NestedJSON:
+		type StackElement struct {
+			node T
+			elem *json_node
+		}
+
+		root := &json_node{}
+		stack := []StackElement{{node: tree.root, elem: root}}
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			data, err := data_fn(top.node)
+			if err != nil {
+				return nil, err
+			}
+
+			top.elem.Data = data
+
+			for child := range top.node.Child() {
+				elem := &json_node{}
+				top.elem.Children = append(top.elem.Children, elem)
+
+				stack = append(stack, StackElement{node: child, elem: elem})
+			}
+		}
+
+		return json.Marshal(root)
+	case FlatJSON:
+		type StackElement struct {
+			node   T
+			parent *int
+		}
+
+		entries := make([]json_entry, 0, tree.size)
+		stack := []StackElement{{node: tree.root}}
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			data, err := data_fn(top.node)
+			if err != nil {
+				return nil, err
+			}
+
+			id := len(entries)
+
+			entries = append(entries, json_entry{
+				ID:     id,
+				Parent: top.parent,
+				Data:   data,
+			})
+
+			for child := range top.node.BackwardChild() {
+				stack = append(stack, StackElement{node: child, parent: &id})
+			}
+		}
+
+		return json.Marshal(entries)
+	default:
+		return nil, gcers.NewErrInvalidParameter("unknown JSON format " + strconv.Itoa(int(format)))
+	}
+}
+
+// DecodeJSON decodes a tree from its JSON representation. The format is detected
+// automatically.
+//
+// Parameters:
+//   - data: The JSON representation of the tree.
+//   - node_fn: The function that creates a new detached node from its JSON payload.
+//
+// Returns:
+//   - *Tree[T]: The decoded tree. The parent and sibling pointers, as well as the
+//     leaves and the size of the tree, are rebuilt.
+//   - error: An error if the decoding fails.
+func DecodeJSON[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](data []byte, node_fn func(data []byte) (T, error)) (*Tree[T], error) {
+	if node_fn == nil {
+		return nil, gcers.NewErrNilParameter("node_fn")
+	}
+
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		return decode_flat_json(data, node_fn)
+	}
+
+	return decode_nested_json(data, node_fn)
+}
+
+// decode_nested_json is a helper function that decodes a tree in the NestedJSON format.
+//
+// Parameters:
+//   - data: The JSON representation of the tree.
+//   - node_fn: The function that creates a new detached node from its JSON payload.
+//
+// Returns:
+//   - *Tree[T]: The decoded tree.
+//   - error: An error if the decoding fails.
+func decode_nested_json[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](data []byte, node_fn func(data []byte) (T, error)) (*Tree[T], error) {
+	var root_elem json_node
+
+	err := json.Unmarshal(data, &root_elem)
+	if err != nil {
+		return nil, err
+	}
+
+	type StackElement struct {
+		elem *json_node
+		node T
+	}
+
+	root, err := node_fn(root_elem.Data)
+	if err != nil {
+		return nil, gcers.NewErrAt("root", err)
+	}
+
+	stack := []StackElement{{elem: &root_elem, node: root}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if len(top.elem.Children) == 0 {
+			continue
+		}
+
+		children := make([]T, 0, len(top.elem.Children))
+
+		for _, elem := range top.elem.Children {
+			if elem == nil {
+				return nil, gcers.NewErrAt(top.node.String(), errors.New("null child"))
+			}
+
+			child, err := node_fn(elem.Data)
+			if err != nil {
+				return nil, gcers.NewErrAt(top.node.String(), err)
+			}
+
+			children = append(children, child)
+			stack = append(stack, StackElement{elem: elem, node: child})
+		}
+
+		top.node.LinkChildren(children)
+	}
+
+	return NewTree(root), nil
+}
+
+// decode_flat_json is a helper function that decodes a tree in the FlatJSON format.
+//
+// Parameters:
+//   - data: The JSON representation of the tree.
+//   - node_fn: The function that creates a new detached node from its JSON payload.
+//
+// Returns:
+//   - *Tree[T]: The decoded tree.
+//   - error: An error if the decoding fails.
+func decode_flat_json[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](data []byte, node_fn func(data []byte) (T, error)) (*Tree[T], error) {
+	var entries []json_entry
+
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("empty tree")
+	}
+
+	ids := make(map[int]struct{}, len(entries))
+
+	for _, entry := range entries {
+		ids[entry.ID] = struct{}{}
+	}
+
+	nodes := make(map[int]T, len(entries))
+	children := make(map[int][]T)
+
+	var root T
+	var has_root bool
+
+	for i, entry := range entries {
+		_, ok := nodes[entry.ID]
+		if ok {
+			return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), fmt.Errorf("duplicate id %d", entry.ID))
+		}
+
+		node, err := node_fn(entry.Data)
+		if err != nil {
+			return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), err)
+		}
+
+		nodes[entry.ID] = node
+
+		if entry.Parent == nil {
+			if has_root {
+				return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), errors.New("more than one root"))
+			}
+
+			root = node
+			has_root = true
+
+			continue
+		}
+
+		if *entry.Parent == entry.ID {
+			return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), fmt.Errorf("node %d is its own parent", entry.ID))
+		}
+
+		_, ok = nodes[*entry.Parent]
+		if !ok {
+			_, ok := ids[*entry.Parent]
+			if !ok {
+				return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), fmt.Errorf("parent %d does not exist", *entry.Parent))
+			}
+
+			return nil, gcers.NewErrAt("entry "+strconv.Itoa(i), fmt.Errorf("parent %d must appear before its children", *entry.Parent))
+		}
+
+		children[*entry.Parent] = append(children[*entry.Parent], node)
+	}
+
+	if !has_root {
+		return nil, errors.New("missing root")
+	}
+
+	for id, nodes_children := range children {
+		nodes[id].LinkChildren(nodes_children)
+	}
+
+	return NewTree(root), nil
+}
+
+// MarshalTreeJSON works like EncodeJSON for nodes that know how to encode their
+// own payload.
+//
+// Parameters:
+//   - tree: The tree to encode.
+//   - format: The shape of the output.
+//
+// Returns:
+//   - []byte: The JSON representation of the tree.
+//   - error: An error if the encoding fails.
+func MarshalTreeJSON[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	MarshalData() ([]byte, error)
+	TreeNoder
+}](tree *Tree[T], format JSONFormat) ([]byte, error) {
+	return EncodeJSON(tree, format, T.MarshalData)
+}
+
+// UnmarshalTreeJSON works like DecodeJSON for nodes that know how to decode their
+// own payload.
+//
+// Parameters:
+//   - data: The JSON representation of the tree.
+//
+// Returns:
+//   - *Tree[T]: The decoded tree.
+//   - error: An error if the decoding fails.
+//
+// Example:
+//
+//	tree, err := UnmarshalTreeJSON[StringNode](data) // *Tree[*StringNode]
+func UnmarshalTreeJSON[N any, T interface {
+	*N
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	UnmarshalData(data []byte) error
+	TreeNoder
+}](data []byte) (*Tree[T], error) {
+	node_fn := func(data []byte) (T, error) {
+		node := T(new(N))
+
+		err := node.UnmarshalData(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+
+	return DecodeJSON(data, node_fn)
+}
+
+// json_float is the JSON representation of a floating-point number. As JSON numbers
+// cannot represent them, NaN and the infinities are encoded as the strings "NaN",
+// "+Inf" and "-Inf".
+type json_float float64
+
+// MarshalJSON implements the json.Marshaler interface.
+func (f json_float) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (f *json_float) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		var v float64
+
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+
+		*f = json_float(v)
+
+		return nil
+	}
+
+	var s string
+
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	switch s {
+	case "NaN":
+		*f = json_float(math.NaN())
+	case "+Inf":
+		*f = json_float(math.Inf(1))
+	case "-Inf":
+		*f = json_float(math.Inf(-1))
+	default:
+		return fmt.Errorf("invalid floating-point number %q", s)
+	}
+
+	return nil
+}
+
+// json_complex is the JSON representation of a complex number.
+type json_complex [2]json_float
+
+// MarshalValue encodes a value into JSON. Unlike json.Marshal, it supports errors
+// (encoded as their message), complex numbers (encoded as [real, imag]) and NaN or
+// infinite floating-point numbers (encoded as "NaN", "+Inf" or "-Inf").
+//
+// Parameters:
+//   - v: The value to encode.
+//
+// Returns:
+//   - []byte: The JSON representation of the value.
+//   - error: An error if the encoding fails.
+func MarshalValue(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case error:
+		if v == nil {
+			return []byte("null"), nil
+		}
+
+		return json.Marshal(v.Error())
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return json.Marshal(json_float(v))
+		}
+
+		return json.Marshal(v)
+	case float64:
+		return json.Marshal(json_float(v))
+	case complex64:
+		return json.Marshal(json_complex{json_float(real(v)), json_float(imag(v))})
+	case complex128:
+		return json.Marshal(json_complex{json_float(real(v)), json_float(imag(v))})
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// UnmarshalValue decodes a value encoded by MarshalValue.
+//
+// Parameters:
+//   - data: The JSON representation of the value.
+//   - dest: A pointer to the value to decode into.
+//
+// Returns:
+//   - error: An error if the decoding fails.
+func UnmarshalValue(data []byte, dest any) error {
+	switch dest := dest.(type) {
+	case *error:
+		var msg *string
+
+		err := json.Unmarshal(data, &msg)
+		if err != nil {
+			return err
+		}
+
+		if msg == nil {
+			*dest = nil
+		} else {
+			*dest = errors.New(*msg)
+		}
+
+		return nil
+	case *float32:
+		if len(data) == 0 || data[0] != '"' {
+			// Decoded directly so that the number is rounded only once.
+			return json.Unmarshal(data, dest)
+		}
+
+		var f json_float
+
+		err := json.Unmarshal(data, &f)
+		if err != nil {
+			return err
+		}
+
+		*dest = float32(f)
+
+		return nil
+	case *float64:
+		var f json_float
+
+		err := json.Unmarshal(data, &f)
+		if err != nil {
+			return err
+		}
+
+		*dest = float64(f)
+
+		return nil
+	case *complex64:
+		var c json_complex
+
+		err := json.Unmarshal(data, &c)
+		if err != nil {
+			return err
+		}
+
+		*dest = complex64(complex(float64(c[0]), float64(c[1])))
+
+		return nil
+	case *complex128:
+		var c json_complex
+
+		err := json.Unmarshal(data, &c)
+		if err != nil {
+			return err
+		}
+
+		*dest = complex(float64(c[0]), float64(c[1]))
+
+		return nil
+	default:
+		return json.Unmarshal(data, dest)
+	}
+}
@@ -0,0 +1,142 @@
+package tree_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	want := sample_tree()
+
+	for _, format := range []tree.JSONFormat{tree.NestedJSON, tree.FlatJSON} {
+		data, err := tree.MarshalTreeJSON(want, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		got, err := tree.UnmarshalTreeJSON[root.StringNode](data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		if !same_tree(got.Root(), want.Root()) {
+			t.Errorf("%s: got\n%s\nwant\n%s", format, got, want)
+		}
+
+		err = tree.Validate(got)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+	}
+}
+
+func TestDecodeFlatJSONErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		reason string
+	}{
+		{"self parent", `[{"id":1,"parent":null,"data":"r"},{"id":2,"parent":2,"data":"a"}]`, "own parent"},
+		{"dangling parent", `[{"id":1,"parent":null,"data":"r"},{"id":2,"parent":7,"data":"a"}]`, "does not exist"},
+		{"parent after child", `[{"id":1,"parent":null,"data":"r"},{"id":2,"parent":3,"data":"a"},{"id":3,"parent":1,"data":"b"}]`, "must appear before"},
+		{"duplicate id", `[{"id":1,"parent":null,"data":"r"},{"id":1,"parent":1,"data":"a"}]`, "duplicate id"},
+		{"two roots", `[{"id":1,"parent":null,"data":"r"},{"id":2,"parent":null,"data":"a"}]`, "more than one root"},
+		{"no root", `[{"id":1,"parent":1,"data":"r"}]`, "own parent"},
+	}
+
+	for _, test := range tests {
+		_, err := tree.UnmarshalTreeJSON[root.StringNode]([]byte(test.input))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+			continue
+		}
+
+		if !strings.Contains(error_chain(err), test.reason) {
+			t.Errorf("%s: got %q, want a reason containing %q", test.name, error_chain(err), test.reason)
+		}
+	}
+}
+
+func TestJSONNonFinite(t *testing.T) {
+	values := []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1.5, -0.25}
+
+	for _, v := range values {
+		want := tree.NewTree(root.NewFloat64Node(v))
+		want.Root().AddChild(root.NewFloat64Node(v))
+		want.RegenerateLeaves()
+
+		data, err := tree.MarshalTreeJSON(want, tree.NestedJSON)
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+
+		got, err := tree.UnmarshalTreeJSON[root.Float64Node](data)
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+
+		for _, node := range []*root.Float64Node{got.Root(), got.Root().FirstChild} {
+			if node.Data != v && !(math.IsNaN(v) && math.IsNaN(node.Data)) {
+				t.Errorf("%v: got %v from %s", v, node.Data, data)
+			}
+		}
+	}
+
+	for _, v := range []float32{float32(math.Inf(-1)), 0.1} {
+		data, err := tree.MarshalValue(v)
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+
+		var got float32
+
+		err = tree.UnmarshalValue(data, &got)
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+
+		if got != v {
+			t.Errorf("%v: got %v from %s", v, got, data)
+		}
+	}
+
+	var got float64
+
+	err := tree.UnmarshalValue([]byte(`"Infinity"`), &got)
+	if err == nil {
+		t.Errorf("got %v, want an error", got)
+	}
+}
+
+func TestJSONDeep(t *testing.T) {
+	deep := chain(6000)
+
+	// The nested format hits the nesting depth limit of encoding/json; either when
+	// encoding or when decoding depending on the Go version.
+	data, err := tree.MarshalTreeJSON(deep, tree.NestedJSON)
+	if err == nil {
+		_, err = tree.UnmarshalTreeJSON[root.StringNode](data)
+	}
+
+	if err == nil {
+		t.Error("nested: expected the nesting depth limit to be hit")
+	}
+
+	data, err = tree.MarshalTreeJSON(deep, tree.FlatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tree.UnmarshalTreeJSON[root.StringNode](data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Size() != deep.Size() {
+		t.Errorf("got %d nodes, want %d", got.Size(), deep.Size())
+	}
+}
@@ -0,0 +1,372 @@
+package tree
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// TopDownPrinter renders small trees as top-down diagrams where every parent is
+// centered above its children:
+//
+//	  +
+//	┌─┴─┐
+//	1   *
+//	   ┌┴─┐
+//	   2  3
+//
+// Columns are measured in runes, so labels with wide characters may be misaligned.
+type TopDownPrinter[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// Label returns the label of a node. Defaults to the String of the node.
+	Label func(node T) string
+
+	// ASCII, if true, draws the connectors with ASCII characters only.
+	ASCII bool
+
+	// Gap is the number of columns between two sibling subtrees. Zero means 2.
+	Gap int
+
+	// MaxWidth is the width, in columns, of the terminal. Trees wider than that are
+	// rendered with Fallback instead. Zero or less means no limit.
+	MaxWidth int
+
+	// Fallback is the printer used when the tree is too wide or is not a tree (i.e.,
+	// it shares nodes or has cycles).
+	Fallback Printer[T]
+}
+
+// layout_node is the layout of a node.
+type layout_node struct {
+	// label is the label of the node.
+	label []rune
+
+	// children are the layouts of the children of the node.
+	children []*layout_node
+
+	// depth is the depth of the node.
+	depth int
+
+	// width is the width of the subtree of the node.
+	width int
+
+	// center is the column of the center of the node, relative to the start of
+	// its subtree.
+	center int
+
+	// start is the column where the label starts, relative to the start of its
+	// subtree.
+	start int
+
+	// offsets are the columns where the subtrees of the children start, relative
+	// to the start of the subtree of the node.
+	offsets []int
+
+	// abs is the absolute column where the subtree of the node starts.
+	abs int
+}
+
+// Junction bits of the connector lines.
+const (
+	junction_up = 1 << iota
+	junction_down
+	junction_left
+	junction_right
+)
+
+// junction_glyph is a helper function that returns the glyph of a junction.
+//
+// Parameters:
+//   - mask: The junction bits.
+//   - ascii: Whether to use ASCII characters only.
+//
+// Returns:
+//   - rune: The glyph.
+func junction_glyph(mask int, ascii bool) rune {
+	const (
+		u = junction_up
+		d = junction_down
+		l = junction_left
+		r = junction_right
+	)
+
+	if ascii {
+		switch mask {
+		case u, d, u | d:
+			return '|'
+		case l, r, l | r:
+			return '-'
+		default:
+			return '+'
+		}
+	}
+
+	switch mask {
+	case u, d, u | d:
+		return '│'
+	case l, r, l | r:
+		return '─'
+	case d | r:
+		return '┌'
+	case d | l:
+		return '┐'
+	case u | r:
+		return '└'
+	case u | l:
+		return '┘'
+	case u | l | r:
+		return '┴'
+	case d | l | r:
+		return '┬'
+	case u | d | r:
+		return '├'
+	case u | d | l:
+		return '┤'
+	default:
+		return '┼'
+	}
+}
+
+// layout is a helper method that computes the layout of the tree.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - []*layout_node: The layouts in DFS order. The first one is the root.
+//   - bool: False if a node is reached more than once.
+func (p TopDownPrinter[T]) layout(root T) ([]*layout_node, bool) {
+	gap := p.Gap
+	if gap <= 0 {
+		gap = 2
+	}
+
+	label_fn := p.Label
+	if label_fn == nil {
+		label_fn = T.String
+	}
+
+	type StackElement struct {
+		node T
+		elem *layout_node
+	}
+
+	seen := make(map[T]struct{})
+
+	var order []*layout_node
+
+	root_elem := &layout_node{}
+	stack := []StackElement{{node: root, elem: root_elem}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		_, ok := seen[top.node]
+		if ok {
+			return nil, false
+		}
+
+		seen[top.node] = struct{}{}
+
+		label := strings.ReplaceAll(label_fn(top.node), "\n", " ")
+		top.elem.label = []rune(label)
+
+		order = append(order, top.elem)
+
+		for range top.node.Child() {
+			elem := &layout_node{depth: top.elem.depth + 1}
+			top.elem.children = append(top.elem.children, elem)
+		}
+
+		i := len(top.elem.children)
+		for child := range top.node.BackwardChild() {
+			i--
+			stack = append(stack, StackElement{node: child, elem: top.elem.children[i]})
+		}
+	}
+
+	// Children come after their parent in DFS order; so, in reverse, every subtree
+	// is measured before its parent.
+	for i := len(order) - 1; i >= 0; i-- {
+		elem := order[i]
+		lw := len(elem.label)
+
+		if len(elem.children) == 0 {
+			elem.width = max(lw, 1)
+			elem.center = lw / 2
+
+			continue
+		}
+
+		var span int
+
+		for j, child := range elem.children {
+			if j > 0 {
+				span += gap
+			}
+
+			elem.offsets = append(elem.offsets, span)
+			span += child.width
+		}
+
+		var shift int
+
+		if lw > span {
+			shift = (lw - span) / 2
+			elem.width = lw
+		} else {
+			elem.width = span
+		}
+
+		for j := range elem.offsets {
+			elem.offsets[j] += shift
+		}
+
+		first, last := elem.children[0], elem.children[len(elem.children)-1]
+
+		elem.center = (elem.offsets[0] + first.center + elem.offsets[len(elem.offsets)-1] + last.center) / 2
+		elem.start = max(0, min(elem.center-lw/2, elem.width-lw))
+	}
+
+	for _, elem := range order {
+		for j, child := range elem.children {
+			child.abs = elem.abs + elem.offsets[j]
+		}
+	}
+
+	return order, true
+}
+
+// Sprint renders the tree.
+//
+// Parameters:
+//   - tree: The tree to render.
+//
+// Returns:
+//   - string: The rendered tree, without a trailing newline. Empty if tree is nil.
+func (p TopDownPrinter[T]) Sprint(tree *Tree[T]) string {
+	if tree == nil {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	// Neither the writer nor the tree is nil here, and writes to a strings.Builder
+	// cannot fail.
+	err := p.Fprint(&builder, tree)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// Fprint writes the tree to w.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tree: The tree to write.
+//
+// Returns:
+//   - error: An error if w or tree is nil, or the first write error.
+//
+// Every line, the last one included, ends with a newline. Unlike Printer, the
+// whole diagram is kept in memory.
+func (p TopDownPrinter[T]) Fprint(w io.Writer, tree *Tree[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tree == nil {
+		return gcers.NewErrNilParameter("tree")
+	}
+
+	order, ok := p.layout(tree.root)
+	if !ok || p.MaxWidth > 0 && order[0].width > p.MaxWidth {
+		return p.Fallback.Fprint(w, tree)
+	}
+
+	var height int
+
+	for _, elem := range order {
+		height = max(height, 2*elem.depth+1)
+	}
+
+	lines := make([][]rune, height)
+	masks := make([][]int, height)
+
+	for i := range lines {
+		lines[i] = []rune(strings.Repeat(" ", order[0].width))
+		masks[i] = make([]int, order[0].width)
+	}
+
+	for _, elem := range order {
+		row := 2 * elem.depth
+
+		copy(lines[row][elem.abs+elem.start:], elem.label)
+
+		if len(elem.children) == 0 {
+			continue
+		}
+
+		row++
+
+		parent := elem.abs + elem.center
+		lo, hi := parent, parent
+
+		masks[row][parent] |= junction_up
+
+		for _, child := range elem.children {
+			col := child.abs + child.center
+
+			masks[row][col] |= junction_down
+
+			lo = min(lo, col)
+			hi = max(hi, col)
+		}
+
+		for col := lo; col <= hi; col++ {
+			if col > lo {
+				masks[row][col] |= junction_left
+			}
+
+			if col < hi {
+				masks[row][col] |= junction_right
+			}
+		}
+	}
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	for i, line := range lines {
+		if i%2 == 1 {
+			for col, mask := range masks[i] {
+				if mask != 0 {
+					line[col] = junction_glyph(mask, p.ASCII)
+				}
+			}
+		}
+
+		str := strings.TrimRight(string(line), " ")
+
+		if str != "" {
+			tw.write(str, "\n")
+		}
+	}
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
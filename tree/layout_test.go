@@ -0,0 +1,111 @@
+package tree_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestTopDownPrinter(t *testing.T) {
+	label := func(node *root.StringNode) string {
+		return node.Data
+	}
+
+	expr := tree.NewTree(make_node("+", make_node("1"), make_node("*", make_node("2"), make_node("3"))))
+	wide := tree.NewTree(make_node("root", make_node("x"), make_node("y"), make_node("z")))
+
+	tests := []struct {
+		name    string
+		printer tree.TopDownPrinter[*root.StringNode]
+		tree    *tree.Tree[*root.StringNode]
+		want    []string
+	}{
+		{
+			name:    "expression",
+			printer: tree.TopDownPrinter[*root.StringNode]{Label: label},
+			tree:    expr,
+			want:    []string{"  +", "┌─┴─┐", "1   *", "   ┌┴─┐", "   2  3"},
+		},
+		{
+			name:    "sample",
+			printer: tree.TopDownPrinter[*root.StringNode]{Label: label},
+			tree:    sample_tree(),
+			want:    []string{"   a", " ┌─┴──┐", " b    c", "┌┴─┐  │", "d  e  f"},
+		},
+		{
+			name:    "ascii",
+			printer: tree.TopDownPrinter[*root.StringNode]{Label: label, ASCII: true, Gap: 1},
+			tree:    wide,
+			want:    []string{"root", "+-+-+", "x y z"},
+		},
+		{
+			name:    "single node",
+			printer: tree.TopDownPrinter[*root.StringNode]{Label: label},
+			tree:    tree.NewTree(make_node("a")),
+			want:    []string{"a"},
+		},
+		{
+			name: "too wide",
+			printer: tree.TopDownPrinter[*root.StringNode]{
+				Label:    label,
+				MaxWidth: 3,
+				Fallback: tree.Printer[*root.StringNode]{Standard: true, Label: label},
+			},
+			tree: sample_tree(),
+			want: []string{"a", "├── b", "│   ├── d", "│   └── e", "└── c", "    └── f"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := strings.Join(tt.want, "\n")
+
+			got := tt.printer.Sprint(tt.tree)
+			if got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestTopDownPrinterFallback(t *testing.T) {
+	label := func(node *root.StringNode) string {
+		return node.Data
+	}
+
+	p := tree.TopDownPrinter[*root.StringNode]{
+		Label:    label,
+		Fallback: tree.Printer[*root.StringNode]{Standard: true, Label: label},
+	}
+
+	// c shares the only child of b, so the nodes no longer form a tree.
+	tr := sample_tree()
+	b, c := tr.Root().FirstChild, tr.Root().LastChild
+	b.FirstChild, b.LastChild = c.FirstChild, c.FirstChild
+
+	got := p.Sprint(tr)
+	want := "a\n├── b\n│   └── f\n└── c\n    └── f"
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	var buf bytes.Buffer
+
+	err := p.Fprint(&buf, sample_tree())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != p.Sprint(sample_tree())+"\n" {
+		t.Errorf("got %q, want the output of Sprint with a trailing newline", buf.String())
+	}
+
+	err = p.Fprint(&buf, nil)
+	if err == nil {
+		t.Error("nil tree: want an error")
+	}
+}
@@ -0,0 +1,88 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Limits are the optional bounds of a context-aware operation. The zero value
+// means no limits.
+type Limits struct {
+	// MaxDepth is the maximum depth a node can have; where the root is at depth 0.
+	// Non-positive values mean no limit; so, it cannot limit an operation to the
+	// root alone. Use a MaxNodes of 1 for that.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes that can be processed. Non-positive
+	// values mean no limit.
+	MaxNodes int
+
+	// Deadline is the wall-clock time after which the operation stops. The zero
+	// value means no deadline.
+	Deadline time.Time
+}
+
+// limiter is the helper that enforces the limits of an operation.
+type limiter struct {
+	// ctx is the context of the operation.
+	ctx context.Context
+
+	// limits are the limits of the operation.
+	limits Limits
+
+	// processed is the number of nodes processed so far.
+	processed int
+}
+
+// new_limiter creates a new limiter.
+//
+// Parameters:
+//   - ctx: The context of the operation. If nil, context.Background() is used.
+//   - limits: The limits of the operation.
+//
+// Returns:
+//   - *limiter: The new limiter. Never returns nil.
+func new_limiter(ctx context.Context, limits Limits) *limiter {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &limiter{
+		ctx:    ctx,
+		limits: limits,
+	}
+}
+
+// visit checks whether a node at the given depth can be processed and, if so,
+// counts it as processed.
+//
+// Parameters:
+//   - depth: The depth of the node.
+//
+// Returns:
+//   - error: An error of type *ErrLimitReached if any limit is hit.
+func (l *limiter) visit(depth int) error {
+	err := l.ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewErrLimitReached(LimitDeadline, l.processed, err)
+	} else if err != nil {
+		return NewErrLimitReached(LimitCanceled, l.processed, err)
+	}
+
+	if !l.limits.Deadline.IsZero() && !time.Now().Before(l.limits.Deadline) {
+		return NewErrLimitReached(LimitDeadline, l.processed, context.DeadlineExceeded)
+	}
+
+	if l.limits.MaxDepth > 0 && depth > l.limits.MaxDepth {
+		return NewErrLimitReached(LimitDepth, l.processed, nil)
+	}
+
+	if l.limits.MaxNodes > 0 && l.processed >= l.limits.MaxNodes {
+		return NewErrLimitReached(LimitNodes, l.processed, nil)
+	}
+
+	l.processed++
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package tree_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// visit_all traverses a tree with DFSContext and returns the labels of the
+// visited nodes along with the error, if any.
+func visit_all(ctx context.Context, tr *tree.Tree[*root.StringNode], limits tree.Limits) (string, error) {
+	var names []string
+
+	for node, err := range tr.DFSContext(ctx, limits) {
+		if err != nil {
+			return join_labels(names), err
+		}
+
+		names = append(names, node.Data)
+	}
+
+	return join_labels(names), nil
+}
+
+func TestDFSContextLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		limits    tree.Limits
+		want      string
+		kind      tree.LimitKind
+		processed int
+		reason    error
+	}{
+		{name: "no limits", ctx: context.Background(), want: "a b d e c f", kind: -1},
+		{name: "max depth", ctx: context.Background(), limits: tree.Limits{MaxDepth: 1}, want: "a b", kind: tree.LimitDepth, processed: 2},
+		{name: "root only", ctx: context.Background(), limits: tree.Limits{MaxNodes: 1}, want: "a", kind: tree.LimitNodes, processed: 1},
+		{name: "max nodes", ctx: context.Background(), limits: tree.Limits{MaxNodes: 4}, want: "a b d e", kind: tree.LimitNodes, processed: 4},
+		{name: "canceled", ctx: canceled, want: "", kind: tree.LimitCanceled, reason: context.Canceled},
+		{name: "context deadline", ctx: expired, want: "", kind: tree.LimitDeadline, reason: context.DeadlineExceeded},
+		{
+			name:   "wall-clock deadline",
+			ctx:    context.Background(),
+			limits: tree.Limits{Deadline: time.Now().Add(-time.Second)},
+			want:   "",
+			kind:   tree.LimitDeadline,
+			reason: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := visit_all(tt.ctx, sample_tree(), tt.limits)
+			if got != tt.want {
+				t.Errorf("visited %q, want %q", got, tt.want)
+			}
+
+			if tt.kind < 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return
+			}
+
+			var lim *tree.ErrLimitReached
+
+			if !errors.As(err, &lim) {
+				t.Fatalf("got %v, want an *ErrLimitReached", err)
+			}
+
+			if lim.Kind != tt.kind || lim.Processed != tt.processed {
+				t.Errorf("got %v after %d nodes, want %v after %d", lim.Kind, lim.Processed, tt.kind, tt.processed)
+			}
+
+			if tt.reason != nil && !errors.Is(err, tt.reason) {
+				t.Errorf("got %v, want it to wrap %v", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestApplyDFSContextLimit(t *testing.T) {
+	var count int
+
+	trav := tree.Traverser[*root.StringNode, struct{}]{
+		InitFn: func(*root.StringNode) struct{} { return struct{}{} },
+		OnEnter: func(node *root.StringNode, info struct{}) ([]tree.Pair[*root.StringNode, struct{}], tree.Action, error) {
+			count++
+
+			var nexts []tree.Pair[*root.StringNode, struct{}]
+
+			for child := range node.Child() {
+				nexts = append(nexts, tree.NewPair(child, info))
+			}
+
+			return nexts, tree.Continue, nil
+		},
+	}
+
+	for _, apply := range []func(context.Context, *tree.Tree[*root.StringNode], tree.Traverser[*root.StringNode, struct{}], tree.Limits) (struct{}, error){
+		tree.ApplyDFSContext[*root.StringNode, struct{}],
+		tree.ApplyBFSContext[*root.StringNode, struct{}],
+	} {
+		count = 0
+
+		_, err := apply(context.Background(), sample_tree(), trav, tree.Limits{MaxNodes: 3})
+
+		var lim *tree.ErrLimitReached
+
+		if !errors.As(err, &lim) || lim.Kind != tree.LimitNodes {
+			t.Fatalf("got %v, want a LimitNodes error", err)
+		}
+
+		if count != 3 {
+			t.Errorf("entered %d nodes, want 3", count)
+		}
+	}
+}
+
+func TestBuildContextInfinite(t *testing.T) {
+	// Every node has two children: the tree is infinite.
+	fn := func(elem *root.StringNode) ([]*root.StringNode, error) {
+		return []*root.StringNode{root.NewStringNode("x"), root.NewStringNode("y")}, nil
+	}
+
+	tr, err := tree.BuildContext(context.Background(), root.NewStringNode("r"), fn, tree.Limits{MaxNodes: 100})
+
+	var lim *tree.ErrLimitReached
+
+	if !errors.As(err, &lim) || lim.Kind != tree.LimitNodes {
+		t.Fatalf("got %v, want a LimitNodes error", err)
+	}
+
+	if tr != nil {
+		t.Error("a tree was returned along with the error")
+	}
+
+	tr, err = tree.BuildContext(context.Background(), root.NewStringNode("r"), func(elem *root.StringNode) ([]*root.StringNode, error) {
+		if elem.Data != "r" {
+			return nil, nil
+		}
+
+		return []*root.StringNode{root.NewStringNode("x")}, nil
+	}, tree.Limits{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr.Size() != 2 {
+		t.Errorf("got %d nodes, want 2", tr.Size())
+	}
+}
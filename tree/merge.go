@@ -0,0 +1,730 @@
+package tree
+
+import (
+	"iter"
+	"slices"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// MergeSide is a version of a tree in a three-way merge.
+type MergeSide int
+
+const (
+	// MergeBase is the common ancestor of both sides.
+	MergeBase MergeSide = iota
+
+	// MergeLeft is the first derived tree (e.g., "ours").
+	MergeLeft
+
+	// MergeRight is the second derived tree (e.g., "theirs").
+	MergeRight
+)
+
+// String implements the fmt.Stringer interface.
+func (s MergeSide) String() string {
+	switch s {
+	case MergeBase:
+		return "base"
+	case MergeLeft:
+		return "left"
+	case MergeRight:
+		return "right"
+	default:
+		return "unknown side"
+	}
+}
+
+// ConflictKind is the kind of a merge conflict.
+type ConflictKind int
+
+const (
+	// ConflictEdit means that both sides changed the content of a node, or added
+	// a node with the same identity, differently.
+	ConflictEdit ConflictKind = iota
+
+	// ConflictDelete means that a side deleted a node that the other side edited,
+	// moved, or added children to.
+	ConflictDelete
+
+	// ConflictMove means that both sides moved a node to different parents, or
+	// that the moves of both sides together would create a cycle.
+	ConflictMove
+
+	// ConflictOrder means that both sides reordered the children of a node
+	// differently.
+	ConflictOrder
+)
+
+// String implements the fmt.Stringer interface.
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictEdit:
+		return "edit/edit"
+	case ConflictDelete:
+		return "delete/edit"
+	case ConflictMove:
+		return "move/move"
+	case ConflictOrder:
+		return "order/order"
+	default:
+		return "unknown conflict"
+	}
+}
+
+// MergeConflict is a conflict found by Merge.
+type MergeConflict[T TreeNoder] struct {
+	// Kind is the kind of the conflict.
+	Kind ConflictKind
+
+	// Base, Left and Right are the versions of the node in the three trees. A
+	// version is the zero value if the node is not part of that tree.
+	Base, Left, Right T
+
+	// Resolution is the side whose version of the node was kept. If the node is
+	// not part of that side, it is left out of the merged tree.
+	Resolution MergeSide
+}
+
+// MergeOptions are the options of Merge.
+type MergeOptions[T TreeNoder] struct {
+	// Equal checks whether two versions of a node have the same content,
+	// regardless of their children. Defaults to comparing their String.
+	Equal func(a, b T) bool
+
+	// Prefer is the side that wins the conflicts when Resolve is nil. Defaults to
+	// MergeBase; that is, neither change is applied.
+	Prefer MergeSide
+
+	// Resolve, if set, chooses the side that wins a conflict. The Resolution of
+	// the conflict is not set yet. Unknown sides are treated as MergeBase.
+	Resolve func(conflict MergeConflict[T]) MergeSide
+}
+
+// merge_index is a tree indexed by identity.
+type merge_index[T TreeNoder, K comparable] struct {
+	// nodes are the nodes, by identity.
+	nodes map[K]T
+
+	// parents are the identities of the parents. The root has none.
+	parents map[K]K
+
+	// children are the identities of the children, in order.
+	children map[K][]K
+
+	// order are the identities in DFS order.
+	order []K
+}
+
+// new_merge_index is a helper function that indexes a tree by identity.
+//
+// Parameters:
+//   - tree: The tree to index.
+//   - id: The identity function.
+//   - name: The name of the parameter, used in error messages.
+//
+// Returns:
+//   - *merge_index[T, K]: The index. Nil if an error occurs.
+//   - error: An error if tree is nil or if two nodes have the same identity.
+func new_merge_index[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, K comparable](tree *Tree[T], id func(node T) K, name string) (*merge_index[T, K], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter(name)
+	}
+
+	idx := &merge_index[T, K]{
+		nodes:    make(map[K]T),
+		parents:  make(map[K]K),
+		children: make(map[K][]K),
+	}
+
+	stack := []T{tree.root}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		key := id(top)
+
+		_, ok := idx.nodes[key]
+		if ok {
+			return nil, gcers.NewErrInvalidParameter(name + " has several nodes with the identity of " + top.String())
+		}
+
+		idx.nodes[key] = top
+		idx.order = append(idx.order, key)
+
+		var children []T
+
+		for child := range top.Child() {
+			ck := id(child)
+
+			idx.parents[ck] = key
+			idx.children[key] = append(idx.children[key], ck)
+
+			children = append(children, child)
+		}
+
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+
+	return idx, nil
+}
+
+// merge_entry is the merged state of a node.
+type merge_entry[T TreeNoder, K comparable] struct {
+	// node is the version whose content is kept.
+	node T
+
+	// parent is the identity of the parent. Unused for the root.
+	parent K
+
+	// deleted is true iff the node is left out of the merged tree.
+	deleted bool
+
+	// final is true iff the node is deleted because its parent is, which cannot
+	// be undone.
+	final bool
+
+	// implied is true iff the node is deleted only because a side deleted it
+	// along with its parent, and the other side did not change it. Such a
+	// deletion is undone if the parent is kept.
+	implied bool
+}
+
+// merger is the state of Merge.
+type merger[T TreeNoder, K comparable] struct {
+	// sides are the indexed trees, by MergeSide.
+	sides [3]*merge_index[T, K]
+
+	// root is the identity of the roots.
+	root K
+
+	// entries are the merged nodes, by identity.
+	entries map[K]*merge_entry[T, K]
+
+	// order are the identities of all the nodes: the ones of the base tree, then
+	// the ones only in the left tree, then the ones only in the right tree.
+	order []K
+
+	// eq is the content equality function.
+	eq func(a, b T) bool
+
+	// resolve chooses the side that wins a conflict.
+	resolve func(conflict MergeConflict[T]) MergeSide
+
+	// conflicts are the conflicts found so far.
+	conflicts []MergeConflict[T]
+}
+
+// version is a helper method that returns the version of a node in a tree.
+//
+// Parameters:
+//   - side: The tree.
+//   - key: The identity of the node.
+//
+// Returns:
+//   - T: The node.
+//   - K: The identity of its parent.
+//   - bool: False if the node is not part of the tree.
+func (m *merger[T, K]) version(side MergeSide, key K) (T, K, bool) {
+	idx := m.sides[side]
+
+	node, ok := idx.nodes[key]
+	parent := idx.parents[key]
+
+	return node, parent, ok
+}
+
+// conflict is a helper method that records a conflict and resolves it.
+//
+// Parameters:
+//   - kind: The kind of the conflict.
+//   - key: The identity of the node.
+//
+// Returns:
+//   - MergeSide: The side that wins.
+func (m *merger[T, K]) conflict(kind ConflictKind, key K) MergeSide {
+	c := MergeConflict[T]{
+		Kind:  kind,
+		Base:  m.sides[MergeBase].nodes[key],
+		Left:  m.sides[MergeLeft].nodes[key],
+		Right: m.sides[MergeRight].nodes[key],
+	}
+
+	c.Resolution = m.resolve(c)
+
+	if c.Resolution != MergeLeft && c.Resolution != MergeRight {
+		c.Resolution = MergeBase
+	}
+
+	m.conflicts = append(m.conflicts, c)
+
+	return c.Resolution
+}
+
+// take is a helper method that keeps the version of a node in a tree.
+//
+// Parameters:
+//   - e: The merged node.
+//   - side: The tree.
+//   - key: The identity of the node.
+//
+// The node is deleted if it is not part of the tree.
+func (m *merger[T, K]) take(e *merge_entry[T, K], side MergeSide, key K) {
+	node, parent, ok := m.version(side, key)
+
+	e.deleted = !ok
+
+	if ok {
+		e.node = node
+		e.parent = parent
+	}
+}
+
+// merge_node is a helper method that merges the content and the place of a node.
+//
+// Parameters:
+//   - key: The identity of the node.
+//
+// Returns:
+//   - *merge_entry[T, K]: The merged node. Never returns nil.
+func (m *merger[T, K]) merge_node(key K) *merge_entry[T, K] {
+	b, bp, in_b := m.version(MergeBase, key)
+	l, lp, in_l := m.version(MergeLeft, key)
+	r, rp, in_r := m.version(MergeRight, key)
+
+	e := &merge_entry[T, K]{}
+
+	switch {
+	case !in_l && !in_r:
+		e.deleted = true
+	case !in_b && in_l != in_r:
+		if in_l {
+			m.take(e, MergeLeft, key)
+		} else {
+			m.take(e, MergeRight, key)
+		}
+	case in_l != in_r:
+		// One side deleted the node: it stays deleted unless the other side
+		// changed it.
+		x, xp, deleter := l, lp, MergeRight
+		if !in_l {
+			x, xp, deleter = r, rp, MergeLeft
+		}
+
+		if m.eq(x, b) && (key == m.root || xp == bp) {
+			_, ok := m.sides[deleter].nodes[xp]
+
+			e.node = x
+			e.parent = xp
+			e.deleted = true
+			e.implied = key != m.root && !ok
+		} else {
+			m.take(e, m.conflict(ConflictDelete, key), key)
+		}
+	default:
+		content := MergeLeft
+
+		switch {
+		case in_b && m.eq(l, b):
+			content = MergeRight
+		case in_b && m.eq(r, b), m.eq(l, r):
+		default:
+			content = m.conflict(ConflictEdit, key)
+		}
+
+		place := MergeLeft
+
+		switch {
+		case key == m.root:
+		case in_b && lp == bp:
+			place = MergeRight
+		case in_b && rp == bp, lp == rp:
+		default:
+			place = m.conflict(ConflictMove, key)
+		}
+
+		m.take(e, place, key)
+
+		if !e.deleted {
+			node, _, ok := m.version(content, key)
+
+			e.node = node
+			e.deleted = !ok
+		}
+	}
+
+	return e
+}
+
+// break_cycle is a helper method that finds a cycle of parents and breaks it by
+// putting one of its nodes back in its base place.
+//
+// Returns:
+//   - bool: True if a cycle was broken.
+func (m *merger[T, K]) break_cycle() bool {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[K]int, len(m.entries))
+	state[m.root] = visited
+
+	for _, key := range m.order {
+		var path []K
+
+		for cur := key; ; {
+			e := m.entries[cur]
+
+			if e.deleted || state[cur] == visited {
+				break
+			}
+
+			if state[cur] == visiting {
+				cycle := path[slices.Index(path, cur):]
+
+				for _, c := range cycle {
+					e := m.entries[c]
+
+					_, bp, in_b := m.version(MergeBase, c)
+					if in_b && e.parent == bp {
+						continue
+					}
+
+					m.conflicts = append(m.conflicts, MergeConflict[T]{
+						Kind:       ConflictMove,
+						Base:       m.sides[MergeBase].nodes[c],
+						Left:       m.sides[MergeLeft].nodes[c],
+						Right:      m.sides[MergeRight].nodes[c],
+						Resolution: MergeBase,
+					})
+
+					if in_b {
+						e.parent = bp
+					} else {
+						e.deleted = true
+						e.final = true
+					}
+
+					return true
+				}
+			}
+
+			state[cur] = visiting
+			path = append(path, cur)
+			cur = e.parent
+		}
+
+		for _, p := range path {
+			state[p] = visited
+		}
+	}
+
+	return false
+}
+
+// fix_orphans is a helper method that handles the nodes whose parent is deleted:
+// either the parent is brought back or the node is deleted too.
+//
+// Parameters:
+//   - asked: The parents whose deletion was already resolved.
+//
+// Returns:
+//   - bool: True if a node changed.
+func (m *merger[T, K]) fix_orphans(asked map[K]struct{}) bool {
+	var changed bool
+
+	for _, key := range m.order {
+		e := m.entries[key]
+		if key == m.root || e.deleted {
+			continue
+		}
+
+		p := m.entries[e.parent]
+		if !p.deleted {
+			continue
+		}
+
+		changed = true
+
+		_, ok := asked[e.parent]
+		if ok || p.final {
+			e.deleted = true
+			e.final = true
+
+			continue
+		}
+
+		asked[e.parent] = struct{}{}
+
+		m.take(p, m.conflict(ConflictDelete, e.parent), e.parent)
+
+		if p.deleted {
+			e.deleted = true
+			e.final = true
+		}
+	}
+
+	return changed
+}
+
+// revive is a helper method that undoes the implied deletions of the nodes whose
+// parent is kept; so that keeping a node that a side deleted, along with its
+// subtree, keeps the descendants that the other side did not change.
+//
+// Returns:
+//   - bool: True if a node changed.
+func (m *merger[T, K]) revive() bool {
+	var changed bool
+
+	// The parents come before their children in the order of the base tree.
+	for _, key := range m.order {
+		e := m.entries[key]
+
+		if e.implied && e.deleted && !m.entries[e.parent].deleted {
+			e.deleted = false
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// relative is a helper function that returns the elements of a sequence that
+// are in another one.
+//
+// Parameters:
+//   - seq: The sequence.
+//   - other: The other sequence.
+//
+// Returns:
+//   - []K: The elements of seq that are in other, in the order of seq.
+func relative[K comparable](seq, other []K) []K {
+	var result []K
+
+	for _, k := range seq {
+		if slices.Contains(other, k) {
+			result = append(result, k)
+		}
+	}
+
+	return result
+}
+
+// insert_position is a helper function that returns where to insert an element
+// of a sequence into another one: after its closest previous element that is
+// already there, else before its closest next one, else at the end.
+//
+// Parameters:
+//   - result: The sequence to insert into.
+//   - seq: The sequence the element comes from.
+//   - i: The index of the element in seq.
+//
+// Returns:
+//   - int: The position in result.
+func insert_position[K comparable](result, seq []K, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		idx := slices.Index(result, seq[j])
+		if idx >= 0 {
+			return idx + 1
+		}
+	}
+
+	for j := i + 1; j < len(seq); j++ {
+		idx := slices.Index(result, seq[j])
+		if idx >= 0 {
+			return idx
+		}
+	}
+
+	return len(result)
+}
+
+// order_children is a helper method that orders the merged children of a node.
+//
+// Parameters:
+//   - key: The identity of the node.
+//   - kids: The identities of its merged children.
+//
+// Returns:
+//   - []K: The children, in order.
+func (m *merger[T, K]) order_children(key K, kids []K) []K {
+	var seqs [3][]K
+
+	for side := range seqs {
+		seqs[side] = relative(m.sides[side].children[key], kids)
+	}
+
+	sb, sl, sr := seqs[MergeBase], seqs[MergeLeft], seqs[MergeRight]
+
+	changed_l := !slices.Equal(relative(sl, sb), relative(sb, sl))
+	changed_r := !slices.Equal(relative(sr, sb), relative(sb, sr))
+
+	primary := sl
+
+	switch {
+	case changed_l && changed_r && !slices.Equal(relative(sl, sr), relative(sr, sl)):
+		primary = seqs[m.conflict(ConflictOrder, key)]
+	case changed_r && !changed_l:
+		primary = sr
+	}
+
+	result := slices.Clone(primary)
+
+	// The other children are placed after their previous sibling in the tree they
+	// come from, or before their next one.
+	for _, seq := range [][]K{sl, sr, sb, kids} {
+		for i, k := range seq {
+			if slices.Contains(result, k) {
+				continue
+			}
+
+			result = slices.Insert(result, insert_position(result, seq, i), k)
+		}
+	}
+
+	return result
+}
+
+// Merge merges two trees derived from a common base tree. Nodes are matched
+// across the three trees by identity; the roots must share theirs.
+//
+// Parameters:
+//   - base: The common ancestor.
+//   - left: The first derived tree.
+//   - right: The second derived tree.
+//   - id: The identity function. Identities must be unique within each tree.
+//   - opts: The merge options.
+//
+// Returns:
+//   - *Tree[T]: The merged tree, made of copies of the nodes. Nil if an error
+//     occurs.
+//   - []MergeConflict[T]: The conflicts, in the order they were resolved.
+//   - error: An error if a tree or id is nil, if a tree has two nodes with the
+//     same identity, or if the roots do not share their identity.
+//
+// Every node takes the content and the parent that changed on either side; when
+// both sides changed them differently, a conflict is resolved. A node is deleted
+// if a side deleted it and the other did not change it; deleting a node deletes
+// its subtree, unless a child is kept, in which case the deletion of the parent
+// is a ConflictDelete too. When a ConflictDelete keeps a node that a side deleted
+// along with its subtree, the descendants that the other side did not change are
+// kept too. The children are ordered like the side that reordered them, and the
+// added ones are placed next to their siblings. The moves of both sides that
+// together would create a cycle are always resolved with MergeBase. The input
+// trees are not modified.
+func Merge[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, K comparable](base, left, right *Tree[T], id func(node T) K, opts MergeOptions[T]) (*Tree[T], []MergeConflict[T], error) {
+	if id == nil {
+		return nil, nil, gcers.NewErrNilParameter("id")
+	}
+
+	m := &merger[T, K]{
+		entries: make(map[K]*merge_entry[T, K]),
+		eq:      opts.Equal,
+		resolve: opts.Resolve,
+	}
+
+	for i, t := range []*Tree[T]{base, left, right} {
+		idx, err := new_merge_index(t, id, [...]string{"base", "left", "right"}[i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m.sides[i] = idx
+	}
+
+	m.root = m.sides[MergeBase].order[0]
+
+	if m.sides[MergeLeft].order[0] != m.root || m.sides[MergeRight].order[0] != m.root {
+		return nil, nil, gcers.NewErrInvalidParameter("the roots must have the same identity")
+	}
+
+	if m.eq == nil {
+		m.eq = func(a, b T) bool {
+			return a.String() == b.String()
+		}
+	}
+
+	if m.resolve == nil {
+		m.resolve = func(MergeConflict[T]) MergeSide {
+			return opts.Prefer
+		}
+	}
+
+	for _, idx := range m.sides {
+		for _, key := range idx.order {
+			_, ok := m.entries[key]
+			if ok {
+				continue
+			}
+
+			m.entries[key] = nil
+			m.order = append(m.order, key)
+		}
+	}
+
+	for _, key := range m.order {
+		m.entries[key] = m.merge_node(key)
+	}
+
+	asked := make(map[K]struct{})
+
+	for changed := true; changed; {
+		changed = m.revive() || m.break_cycle() || m.fix_orphans(asked)
+	}
+
+	kids := make(map[K][]K)
+
+	for _, key := range m.order {
+		e := m.entries[key]
+		if key != m.root && !e.deleted {
+			kids[e.parent] = append(kids[e.parent], key)
+		}
+	}
+
+	copies := make(map[K]T, len(m.order))
+
+	for _, key := range m.order {
+		e := m.entries[key]
+		if !e.deleted {
+			copies[key] = e.node.Copy()
+		}
+	}
+
+	for _, key := range m.order {
+		children := kids[key]
+		if len(children) == 0 {
+			continue
+		}
+
+		children = m.order_children(key, children)
+
+		nodes := make([]T, 0, len(children))
+
+		for _, k := range children {
+			nodes = append(nodes, copies[k])
+		}
+
+		copies[key].LinkChildren(nodes)
+	}
+
+	return NewTree(copies[m.root]), m.conflicts, nil
+}
@@ -0,0 +1,174 @@
+package tree_test
+
+import (
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// merge_id is the identity of a node: its label up to the first "'", so that
+// "a'" is an edit of "a".
+func merge_id(node *root.StringNode) string {
+	id, _, _ := strings.Cut(node.Data, "'")
+	return id
+}
+
+func TestMerge(t *testing.T) {
+	const base = "r\n├── a\n│   ├── a1\n│   └── a2\n├── b\n└── c\n"
+
+	tests := []struct {
+		name        string
+		left, right string
+		prefer      tree.MergeSide
+		want        string
+		conflicts   []tree.ConflictKind
+	}{
+		{
+			name:  "unchanged",
+			left:  base,
+			right: base,
+			want:  base,
+		},
+		{
+			name:  "disjoint edits",
+			left:  "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			right: "r\n├── a\n│   ├── a1\n│   └── a2\n├── b\n└── c'\n",
+			want:  "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c'\n",
+		},
+		{
+			name:  "insert and delete",
+			left:  "r\n├── a\n│   ├── a1\n│   ├── a2\n│   └── a3\n├── b\n└── c\n",
+			right: "r\n├── a\n│   ├── a1\n│   └── a2\n└── c\n",
+			want:  "r\n├── a\n│   ├── a1\n│   ├── a2\n│   └── a3\n└── c\n",
+		},
+		{
+			name:  "move",
+			left:  "r\n├── a\n│   └── a2\n├── b\n│   └── a1\n└── c\n",
+			right: "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			want:  "r\n├── a'\n│   └── a2\n├── b\n│   └── a1\n└── c\n",
+		},
+		{
+			name:      "edit conflict",
+			left:      "r\n├── a\n│   ├── a1\n│   └── a2\n├── b'\n└── c\n",
+			right:     "r\n├── a\n│   ├── a1\n│   └── a2\n├── b''\n└── c\n",
+			prefer:    tree.MergeRight,
+			want:      "r\n├── a\n│   ├── a1\n│   └── a2\n├── b''\n└── c\n",
+			conflicts: []tree.ConflictKind{tree.ConflictEdit},
+		},
+		{
+			name:      "edit kept over subtree delete",
+			left:      "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			right:     "r\n├── b\n└── c\n",
+			prefer:    tree.MergeLeft,
+			want:      "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			conflicts: []tree.ConflictKind{tree.ConflictDelete},
+		},
+		{
+			name:      "subtree delete kept over edit",
+			left:      "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			right:     "r\n├── b\n└── c\n",
+			prefer:    tree.MergeRight,
+			want:      "r\n├── b\n└── c\n",
+			conflicts: []tree.ConflictKind{tree.ConflictDelete},
+		},
+		{
+			name:      "child delete under a kept edit",
+			left:      "r\n├── a'\n│   ├── a1\n│   └── a2\n├── b\n└── c\n",
+			right:     "r\n├── a\n│   └── a2\n├── b\n└── c\n",
+			prefer:    tree.MergeBase,
+			want:      "r\n├── a'\n│   └── a2\n├── b\n└── c\n",
+			conflicts: nil,
+		},
+		{
+			name:      "edited descendant kept over subtree delete",
+			left:      "r\n├── a\n│   ├── a1'\n│   └── a2\n├── b\n└── c\n",
+			right:     "r\n├── b\n└── c\n",
+			prefer:    tree.MergeLeft,
+			want:      "r\n├── a\n│   ├── a1'\n│   └── a2\n├── b\n└── c\n",
+			conflicts: []tree.ConflictKind{tree.ConflictDelete, tree.ConflictDelete},
+		},
+		{
+			name:      "crossed moves",
+			left:      "r\n├── a\n│   ├── a1\n│   ├── a2\n│   └── b\n└── c\n",
+			right:     "r\n├── b\n│   └── a\n│       ├── a1\n│       └── a2\n└── c\n",
+			prefer:    tree.MergeLeft,
+			want:      "r\n├── a\n│   ├── a1\n│   ├── a2\n│   └── b\n└── c\n",
+			conflicts: []tree.ConflictKind{tree.ConflictMove},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, l, r := must_parse(t, base), must_parse(t, tt.left), must_parse(t, tt.right)
+			want := must_parse(t, tt.want)
+
+			before := [3]string{b.String(), l.String(), r.String()}
+
+			got, conflicts, err := tree.Merge(b, l, r, merge_id, tree.MergeOptions[*root.StringNode]{
+				Prefer: tt.prefer,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !same_tree(got.Root(), want.Root()) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+
+			err = tree.Validate(got)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(conflicts) != len(tt.conflicts) {
+				t.Fatalf("got %d conflicts %v, want %v", len(conflicts), conflicts, tt.conflicts)
+			}
+
+			for i, c := range conflicts {
+				if c.Kind != tt.conflicts[i] {
+					t.Errorf("conflict %d: got %v, want %v", i, c.Kind, tt.conflicts[i])
+				}
+			}
+
+			after := [3]string{b.String(), l.String(), r.String()}
+			if after != before {
+				t.Error("the input trees were modified")
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	a := must_parse(t, "r\n└── a\n")
+	other := must_parse(t, "s\n└── a\n")
+	dup := must_parse(t, "r\n├── a\n└── a\n")
+
+	tests := []struct {
+		name    string
+		base    *tree.Tree[*root.StringNode]
+		left    *tree.Tree[*root.StringNode]
+		right   *tree.Tree[*root.StringNode]
+		id      func(node *root.StringNode) string
+		wantErr string
+	}{
+		{name: "nil id", base: a, left: a, right: a, wantErr: "id"},
+		{name: "nil tree", base: a, left: nil, right: a, id: merge_id, wantErr: "left"},
+		{name: "duplicate identity", base: a, left: dup, right: a, id: merge_id, wantErr: "left"},
+		{name: "different roots", base: a, left: a, right: other, id: merge_id, wantErr: "roots"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tree.Merge(tt.base, tt.left, tt.right, tt.id, tree.MergeOptions[*root.StringNode]{})
+			if err == nil {
+				t.Fatalf("got %v, want an error", got)
+			}
+
+			if !strings.Contains(error_chain(err), tt.wantErr) {
+				t.Errorf("got %q, want it to mention %q", error_chain(err), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// connectors are the accepted connectors, Unicode, rounded and ASCII, before the
+// label of a node. The trailing space is required.
+var connectors = []string{"├── ", "└── ", "╰── ", "|-- ", "`-- ", "+-- ", "\\-- "}
+
+// continuations are the accepted indentation segments.
+var continuations = []string{"│   ", "|   ", "    "}
+
+// has_prefix_at is a helper function that checks whether runes starts with one
+// of the prefixes.
+//
+// Parameters:
+//   - runes: The runes to check.
+//   - prefixes: The candidate prefixes.
+//
+// Returns:
+//   - bool: True if one of the prefixes was found, false otherwise.
+func has_prefix_at(runes []rune, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(string(runes), prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// misaligned_connector is a helper function that finds the connector of a line
+// whose indentation is made of spaces that do not add up to a whole level.
+//
+// Parameters:
+//   - runes: The runes of the line.
+//   - pos: The position where the indentation stops being valid.
+//
+// Returns:
+//   - int: The position of the connector.
+//   - bool: True if only spaces separate pos from a connector, false otherwise.
+func misaligned_connector(runes []rune, pos int) (int, bool) {
+	for i := pos; i < len(runes) && runes[i] == ' '; i++ {
+		if has_prefix_at(runes[i+1:], connectors) {
+			return i + 1, true
+		}
+	}
+
+	return 0, false
+}
+
+// ParseTree parses the box-drawing format written by Tree.String back into a tree.
+//
+// Parameters:
+//   - text: The text to parse.
+//   - node_fn: The function that creates a new detached node from its label.
+//
+// Returns:
+//   - *Tree[T]: The parsed tree.
+//   - error: An *ErrSyntax with the line and the column of the error if the text is
+//     malformed or if node_fn fails.
+//
+// Format:
+//
+//	root
+//	├── node1
+//	│   ├── node2
+//	│   └── node3
+//	└── node4
+//	    └── node5
+//
+// The rounded "╰──" corner is accepted too. The ASCII variant uses "|--", "+--",
+// "`--" or "\--" as connectors and "|" as the vertical line. The first non-blank
+// line is the root, and its indentation is removed from every line so that
+// fixtures can be indented in the source code. Blank lines are ignored. Every
+// level is four columns wide and the children of the root may be indented by any
+// multiple of four columns as long as all of them agree.
+func ParseTree[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](text string, node_fn func(label string) (T, error)) (*Tree[T], error) {
+	if node_fn == nil {
+		return nil, gcers.NewErrNilParameter("node_fn")
+	}
+
+	type StackElement struct {
+		node     T
+		children []T
+	}
+
+	var stack []*StackElement
+	var root T
+	var margin string
+	var base int
+
+	pop := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if len(top.children) > 0 {
+			top.node.LinkChildren(top.children)
+		}
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+
+		line_no := i + 1
+
+		if stack == nil {
+			label := strings.TrimLeft(line, " \t")
+			margin = line[:len(line)-len(label)]
+
+			node, err := node_fn(label)
+			if err != nil {
+				return nil, NewErrSyntax(line_no, len([]rune(margin))+1, err)
+			}
+
+			root = node
+			stack = []*StackElement{{node: node}}
+			base = -1
+
+			continue
+		}
+
+		if !strings.HasPrefix(line, margin) {
+			return nil, NewErrSyntax(line_no, 1, errors.New("line is less indented than the root"))
+		}
+
+		offset := len([]rune(margin))
+		runes := []rune(line[len(margin):])
+
+		var pos int
+
+		for !has_prefix_at(runes[pos:], connectors) {
+			ok := has_prefix_at(runes[pos:], continuations)
+			if ok {
+				pos += 4
+			} else if pos == 0 && !strings.ContainsRune("│| ├└╰`+\\", runes[0]) {
+				return nil, NewErrSyntax(line_no, offset+1, errors.New("more than one root"))
+			} else if runes[pos] == '│' || runes[pos] == '|' {
+				return nil, NewErrSyntax(line_no, offset+pos+2, fmt.Errorf("expected 3 spaces after %q", runes[pos]))
+			} else if col, ok := misaligned_connector(runes, pos); ok {
+				return nil, NewErrSyntax(line_no, offset+col+1, errors.New("connector is not aligned on a level of four columns"))
+			} else {
+				return nil, NewErrSyntax(line_no, offset+pos+1, fmt.Errorf("unexpected %q in indentation", runes[pos]))
+			}
+
+			if pos >= len(runes) {
+				return nil, NewErrSyntax(line_no, offset+len(runes), errors.New("missing connector"))
+			}
+		}
+
+		if base == -1 {
+			base = pos
+		} else if pos < base {
+			return nil, NewErrSyntax(line_no, offset+pos+1, fmt.Errorf("connector is left of the first child of the root, at column %d", offset+base+1))
+		}
+
+		depth := (pos-base)/4 + 1
+
+		for len(stack) > depth {
+			pop()
+		}
+
+		if len(stack) < depth {
+			return nil, NewErrSyntax(line_no, offset+pos+1, fmt.Errorf("node at depth %d has no parent at depth %d", depth, depth-1))
+		}
+
+		label := string(runes[pos+4:])
+
+		node, err := node_fn(label)
+		if err != nil {
+			return nil, NewErrSyntax(line_no, offset+pos+5, err)
+		}
+
+		top := stack[len(stack)-1]
+		top.children = append(top.children, node)
+
+		stack = append(stack, &StackElement{node: node})
+	}
+
+	if stack == nil {
+		return nil, NewErrSyntax(1, 1, errors.New("missing root"))
+	}
+
+	for len(stack) > 0 {
+		pop()
+	}
+
+	return NewTree(root), nil
+}
@@ -0,0 +1,105 @@
+package tree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestParseTree(t *testing.T) {
+	want := sample_tree()
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"unicode", "a\n├── b\n│   ├── d\n│   └── e\n└── c\n    └── f\n"},
+		{"ascii", "a\n|-- b\n|   |-- d\n|   `-- e\n\\-- c\n    +-- f\n"},
+		{"rounded", "a\n├── b\n│   ├── d\n│   ╰── e\n╰── c\n    ╰── f\n"},
+		{"margin", "\n\t\ta\n\t\t├── b\n\n\t\t│   ├── d\n\t\t│   └── e\n\t\t└── c\n\t\t    └── f\n\t"},
+		{"indented children", "a\n    ├── b\n    │   ├── d\n    │   └── e\n    └── c\n        └── f"},
+		{"String", want.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := root.ParseStringTree(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !same_tree(got.Root(), want.Root()) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+
+			err = tree.Validate(got)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestParseTreeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		line   int
+		column int
+		reason string
+	}{
+		{"empty", " \n\n", 1, 1, "missing root"},
+		{"two roots", "a\nb", 2, 1, "more than one root"},
+		{"less indented than the root", "  a\n  ├── b\n ├── c", 3, 1, "less indented than the root"},
+		{"over-indented connector", "a\n  ├── b", 2, 3, "not aligned"},
+		{"over-indented nested connector", "a\n├── b\n│     ├── c", 3, 7, "not aligned"},
+		{"over-indented connector with margin", "  a\n  ├── b\n        └── c", 3, 9, "not aligned"},
+		{"short continuation", "a\n├── b\n│   │ ├── c", 3, 6, "expected 3 spaces"},
+		{"left of the first child", "a\n    ├── b\n├── c", 3, 1, "left of the first child"},
+		{"skipped level", "a\n├── b\n        ├── c", 3, 9, "no parent at depth 2"},
+		{"stray text", "a\n├── b\n#   ├── c", 3, 1, "more than one root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := root.ParseStringTree(tt.text)
+
+			var syntax *tree.ErrSyntax
+
+			if !errors.As(err, &syntax) {
+				t.Fatalf("got %v, want an *ErrSyntax", err)
+			}
+
+			if syntax.Line != tt.line || syntax.Column != tt.column {
+				t.Errorf("got line %d, column %d, want line %d, column %d (%v)", syntax.Line, syntax.Column, tt.line, tt.column, err)
+			}
+
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("got %q, want a reason containing %q", err.Error(), tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseTreeNodeError(t *testing.T) {
+	_, err := tree.ParseTree("a\n└── bad", func(label string) (*root.StringNode, error) {
+		if label == "bad" {
+			return nil, errors.New("bad label")
+		}
+
+		return root.NewStringNode(label), nil
+	})
+
+	var syntax *tree.ErrSyntax
+
+	if !errors.As(err, &syntax) || syntax.Line != 2 || syntax.Column != 5 {
+		t.Fatalf("got %v, want an *ErrSyntax at line 2, column 5", err)
+	}
+
+	if syntax.Reason.Error() != "bad label" {
+		t.Errorf("got reason %v, want the error of node_fn", syntax.Reason)
+	}
+}
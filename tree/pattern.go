@@ -0,0 +1,772 @@
+package tree
+
+import (
+	"errors"
+	"iter"
+	"strconv"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// pattern_kind is the kind of a node of a pattern or of a template.
+type pattern_kind int
+
+const (
+	// pattern_label matches a node by name and, if any, by children.
+	pattern_label pattern_kind = iota
+
+	// pattern_any matches any subtree.
+	pattern_any
+
+	// pattern_seq matches any sequence of sibling subtrees.
+	pattern_seq
+)
+
+// pattern_node is a node of a pattern or of a template.
+type pattern_node struct {
+	// kind is the kind of the node.
+	kind pattern_kind
+
+	// name is the name to match or, in templates, the label of the new node.
+	name string
+
+	// any_name is true if the node matches any name ("_(...)").
+	any_name bool
+
+	// has_children is true if the children are given between parentheses.
+	has_children bool
+
+	// children are the patterns of the children.
+	children []*pattern_node
+
+	// min_children is the number of children that are not sequences.
+	min_children int
+
+	// bind is the name of the variable the node is captured in. Empty if none.
+	bind string
+}
+
+// Pattern is a compiled pattern that matches subtrees. Patterns look like the
+// nodes they match:
+//
+//	Add($x, 0)         An Add with two children: anything, captured in x, and a
+//	                   leaf named 0.
+//	Mul($x, $x)        Repeated variables match equal subtrees.
+//	Call(Ident, _...)  "_" matches any subtree and "_..." any number of them.
+//	Block($xs...)      "$xs..." captures any number of children in xs.
+//	Seq($a..., Seq($b...), $c...)
+//	                   Earlier sequences are as short as possible.
+//	$e:Neg(Neg(_))     Captures a subtree that matches a pattern in e.
+//	_(Lit, Lit)        Any node with two leaves named Lit.
+//	'a b'(Lit)         Quoted names may contain any character.
+//
+// A name without parentheses matches leaves only. Names are matched against the
+// name function which, by default, is the String of the node.
+type Pattern[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// text is the source of the pattern.
+	text string
+
+	// root is the root of the pattern.
+	root *pattern_node
+
+	// vars are the variables of the pattern; true for sequences.
+	vars map[string]bool
+
+	// name_fn returns the name of a node.
+	name_fn func(node T) string
+}
+
+// Match is a successful match of a pattern.
+type Match[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// Node is the matched node.
+	Node T
+
+	// vars are the captured subtrees.
+	vars map[string]T
+
+	// seqs are the captured sequences.
+	seqs map[string][]T
+
+	// log are the variables in the order they were captured, to undo captures
+	// when backtracking.
+	log []string
+}
+
+// undo is a helper method that forgets the captures made after a point.
+//
+// Parameters:
+//   - n: The length of the log at that point.
+func (m *Match[T]) undo(n int) {
+	for _, name := range m.log[n:] {
+		delete(m.vars, name)
+		delete(m.seqs, name)
+	}
+
+	m.log = m.log[:n]
+}
+
+// Var returns the subtree captured in a variable.
+//
+// Parameters:
+//   - name: The name of the variable, without the '$'.
+//
+// Returns:
+//   - T: The captured subtree.
+//   - bool: False if the variable is not a subtree variable of the pattern.
+func (m Match[T]) Var(name string) (T, bool) {
+	node, ok := m.vars[name]
+	return node, ok
+}
+
+// Seq returns the sequence captured in a variable.
+//
+// Parameters:
+//   - name: The name of the variable, without the '$' and the "...".
+//
+// Returns:
+//   - []T: The captured subtrees, in order. May be empty.
+//   - bool: False if the variable is not a sequence variable of the pattern.
+func (m Match[T]) Seq(name string) ([]T, bool) {
+	nodes, ok := m.seqs[name]
+	return nodes, ok
+}
+
+// pattern_parser is the parser of patterns and templates.
+type pattern_parser struct {
+	query_parser
+
+	// template is true when parsing a template.
+	template bool
+
+	// vars are the variables found so far; true for sequences.
+	vars map[string]bool
+}
+
+// parse_term parses a node of a pattern or of a template.
+//
+// Parameters:
+//   - in_list: Whether the node is a child, where sequences are allowed.
+//
+// Returns:
+//   - *pattern_node: The node.
+//   - error: An error of type *ErrSyntax if the node is malformed.
+func (p *pattern_parser) parse_term(in_list bool) (*pattern_node, error) {
+	tk := p.next()
+
+	switch {
+	case tk.kind == token_dollar:
+		name := p.next()
+		if name.kind != token_word {
+			return nil, p.unexpected(name, "a variable name")
+		}
+
+		pn := &pattern_node{kind: pattern_any, bind: name.text}
+
+		if p.peek().kind == token_ellipsis {
+			p.next()
+
+			if !in_list && !p.template {
+				return nil, NewErrSyntax(1, tk.column, errors.New("sequences are only allowed among children"))
+			}
+
+			pn.kind = pattern_seq
+		} else if p.peek().kind == token_colon && !p.template {
+			p.next()
+
+			col := p.peek().column
+
+			inner, err := p.parse_term(false)
+			if err != nil {
+				return nil, err
+			}
+
+			if inner.bind != "" {
+				return nil, NewErrSyntax(1, col, errors.New("expected a name or \"_\" after ':'"))
+			}
+
+			inner.bind, pn = name.text, inner
+		}
+
+		is_seq := pn.kind == pattern_seq
+
+		prev, ok := p.vars[name.text]
+		if ok && prev != is_seq {
+			return nil, NewErrSyntax(1, name.column, errors.New("variable "+strconv.Quote(name.text)+" is used both as a subtree and as a sequence"))
+		}
+
+		p.vars[name.text] = is_seq
+
+		return pn, nil
+	case tk.kind == token_word && tk.text == "_" && !p.template:
+		if p.peek().kind == token_ellipsis {
+			p.next()
+
+			if !in_list {
+				return nil, NewErrSyntax(1, tk.column, errors.New("sequences are only allowed among children"))
+			}
+
+			return &pattern_node{kind: pattern_seq}, nil
+		}
+
+		if p.peek().kind != token_lparen {
+			return &pattern_node{kind: pattern_any}, nil
+		}
+
+		pn := &pattern_node{kind: pattern_label, any_name: true}
+
+		err := p.parse_children(pn)
+		if err != nil {
+			return nil, err
+		}
+
+		return pn, nil
+	case tk.kind == token_word || tk.kind == token_string:
+		pn := &pattern_node{kind: pattern_label, name: tk.text}
+
+		if p.peek().kind == token_lparen {
+			err := p.parse_children(pn)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return pn, nil
+	default:
+		return nil, p.unexpected(tk, "a name, \"_\" or a variable")
+	}
+}
+
+// parse_children parses the children of a node, between parentheses.
+//
+// Parameters:
+//   - pn: The node whose children to parse.
+//
+// Returns:
+//   - error: An error of type *ErrSyntax if the children are malformed.
+func (p *pattern_parser) parse_children(pn *pattern_node) error {
+	p.next()
+
+	pn.has_children = true
+
+	if p.peek().kind == token_rparen {
+		p.next()
+		return nil
+	}
+
+	for {
+		child, err := p.parse_term(true)
+		if err != nil {
+			return err
+		}
+
+		if child.kind != pattern_seq {
+			pn.min_children++
+		}
+
+		pn.children = append(pn.children, child)
+
+		tk := p.next()
+
+		switch tk.kind {
+		case token_comma:
+		case token_rparen:
+			return nil
+		default:
+			return p.unexpected(tk, `"," or ")"`)
+		}
+	}
+}
+
+// parse_pattern is a helper function that parses a pattern or a template.
+//
+// Parameters:
+//   - text: The pattern or template.
+//   - template: Whether text is a template.
+//
+// Returns:
+//   - []*pattern_node: The roots; exactly one for a pattern, one or more, separated
+//     by commas, for a template.
+//   - map[string]bool: The variables; true for sequences.
+//   - error: An error of type *ErrSyntax if text is malformed.
+func parse_pattern(text string, template bool) ([]*pattern_node, map[string]bool, error) {
+	tokens, err := lex_query(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &pattern_parser{
+		query_parser: query_parser{tokens: tokens},
+		template:     template,
+		vars:         make(map[string]bool),
+	}
+
+	if p.peek().kind == token_eof {
+		return nil, nil, NewErrSyntax(1, 1, errors.New("empty pattern"))
+	}
+
+	var roots []*pattern_node
+
+	for {
+		root, err := p.parse_term(template)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		roots = append(roots, root)
+
+		tk := p.next()
+
+		switch {
+		case tk.kind == token_eof:
+			return roots, p.vars, nil
+		case tk.kind == token_comma && template:
+		case template:
+			return nil, nil, p.unexpected(tk, `"," or end of input`)
+		default:
+			return nil, nil, p.unexpected(tk, "end of input")
+		}
+	}
+}
+
+// CompilePattern compiles a pattern.
+//
+// Parameters:
+//   - text: The pattern. See Pattern for the syntax.
+//   - name_fn: The function that returns the name of a node. If nil, the String
+//     of the node is used.
+//
+// Returns:
+//   - *Pattern[T]: The compiled pattern. Nil if an error occurs.
+//   - error: An error of type *ErrSyntax, on line 1, if the pattern is malformed.
+func CompilePattern[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](text string, name_fn func(node T) string) (*Pattern[T], error) {
+	roots, vars, err := parse_pattern(text, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if name_fn == nil {
+		name_fn = T.String
+	}
+
+	return &Pattern[T]{
+		text:    text,
+		root:    roots[0],
+		vars:    vars,
+		name_fn: name_fn,
+	}, nil
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Returns:
+//   - string: The source of the pattern.
+func (p Pattern[T]) String() string {
+	return p.text
+}
+
+// Match matches the pattern against a node.
+//
+// Parameters:
+//   - node: The node to match.
+//
+// Returns:
+//   - *Match[T]: The match. Nil if the node does not match.
+//   - bool: True if the node matches.
+func (p *Pattern[T]) Match(node T) (*Match[T], bool) {
+	m := &Match[T]{
+		Node: node,
+		vars: make(map[string]T),
+		seqs: make(map[string][]T),
+	}
+
+	if !p.match(p.root, node, m) {
+		return nil, false
+	}
+
+	return m, true
+}
+
+// match is a helper method that matches a node of the pattern. The recursion is
+// bounded by the depth of the pattern.
+//
+// Parameters:
+//   - pn: The node of the pattern.
+//   - node: The node to match.
+//   - m: The match so far. Updated in place.
+//
+// Returns:
+//   - bool: True if the node matches.
+func (p *Pattern[T]) match(pn *pattern_node, node T, m *Match[T]) bool {
+	if pn.kind == pattern_label {
+		if !pn.any_name && p.name_fn(node) != pn.name {
+			return false
+		}
+
+		var children []T
+
+		for child := range node.Child() {
+			children = append(children, child)
+		}
+
+		if !pn.has_children {
+			if len(children) > 0 {
+				return false
+			}
+		} else if !p.match_children(pn.children, children, pn.min_children, m) {
+			return false
+		}
+	}
+
+	if pn.bind == "" {
+		return true
+	}
+
+	prev, ok := m.vars[pn.bind]
+	if ok {
+		return p.equal(prev, node)
+	}
+
+	m.vars[pn.bind] = node
+	m.log = append(m.log, pn.bind)
+
+	return true
+}
+
+// match_children is a helper method that matches the children of a node. It
+// backtracks over the lengths of the sequences; the recursion is bounded by the
+// number of children in the pattern.
+//
+// Parameters:
+//   - pns: The patterns of the children.
+//   - children: The children to match.
+//   - min_children: The number of patterns in pns that are not sequences.
+//   - m: The match so far. Updated in place; unchanged on failure.
+//
+// Returns:
+//   - bool: True if the children match.
+func (p *Pattern[T]) match_children(pns []*pattern_node, children []T, min_children int, m *Match[T]) bool {
+	if len(pns) == 0 {
+		return len(children) == 0
+	} else if len(children) < min_children {
+		return false
+	}
+
+	pn := pns[0]
+	mark := len(m.log)
+
+	if pn.kind != pattern_seq {
+		if p.match(pn, children[0], m) && p.match_children(pns[1:], children[1:], min_children-1, m) {
+			return true
+		}
+
+		m.undo(mark)
+
+		return false
+	}
+
+	for n := 0; n <= len(children)-min_children; n++ {
+		if p.capture_seq(pn.bind, children[:n], m) && p.match_children(pns[1:], children[n:], min_children, m) {
+			return true
+		}
+
+		m.undo(mark)
+	}
+
+	return false
+}
+
+// capture_seq is a helper method that captures a sequence.
+//
+// Parameters:
+//   - bind: The name of the variable. Empty if none.
+//   - seq: The sequence.
+//   - m: The match so far. Updated in place.
+//
+// Returns:
+//   - bool: False if the variable already holds a different sequence.
+func (p *Pattern[T]) capture_seq(bind string, seq []T, m *Match[T]) bool {
+	if bind == "" {
+		return true
+	}
+
+	prev, ok := m.seqs[bind]
+	if !ok {
+		m.seqs[bind] = seq
+		m.log = append(m.log, bind)
+
+		return true
+	}
+
+	if len(prev) != len(seq) {
+		return false
+	}
+
+	for i := range seq {
+		if !p.equal(prev[i], seq[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equal is a helper method that checks whether two subtrees have the same shape
+// and the same names.
+//
+// Parameters:
+//   - a: The first subtree.
+//   - b: The second subtree.
+//
+// Returns:
+//   - bool: True if the subtrees are equal.
+func (p *Pattern[T]) equal(a, b T) bool {
+	type StackElement struct {
+		a, b T
+	}
+
+	stack := []StackElement{{a: a, b: b}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if top.a == top.b {
+			continue
+		}
+
+		if p.name_fn(top.a) != p.name_fn(top.b) {
+			return false
+		}
+
+		var children []T
+
+		for child := range top.b.Child() {
+			children = append(children, child)
+		}
+
+		var i int
+
+		for child := range top.a.Child() {
+			if i == len(children) {
+				return false
+			}
+
+			stack = append(stack, StackElement{a: child, b: children[i]})
+			i++
+		}
+
+		if i != len(children) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Template is a compiled template that builds subtrees from a match. Templates use
+// the syntax of patterns, without wildcards and "$x:":
+//
+//	Add($y, $x)        A new Add node whose children are the captured subtrees.
+//	Block($xs..., $y)  Sequences are spliced among the children.
+//	$xs..., Nop        A template may build any number of subtrees.
+//
+// Names are passed to the node function of the template. The captured subtrees are
+// moved, not copied, unless they are used more than once.
+type Template[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// text is the source of the template.
+	text string
+
+	// roots are the roots of the template.
+	roots []*pattern_node
+
+	// vars are the variables of the template; true for sequences.
+	vars map[string]bool
+
+	// node_fn creates a node from a name.
+	node_fn func(name string) (T, error)
+}
+
+// CompileTemplate compiles a template.
+//
+// Parameters:
+//   - text: The template. See Template for the syntax.
+//   - node_fn: The function that creates a node from a name.
+//
+// Returns:
+//   - *Template[T]: The compiled template. Nil if an error occurs.
+//   - error: An error if node_fn is nil, or an error of type *ErrSyntax, on line
+//     1, if the template is malformed.
+func CompileTemplate[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](text string, node_fn func(name string) (T, error)) (*Template[T], error) {
+	if node_fn == nil {
+		return nil, gcers.NewErrNilParameter("node_fn")
+	}
+
+	roots, vars, err := parse_pattern(text, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template[T]{
+		text:    text,
+		roots:   roots,
+		vars:    vars,
+		node_fn: node_fn,
+	}, nil
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Returns:
+//   - string: The source of the template.
+func (tp Template[T]) String() string {
+	return tp.text
+}
+
+// Instantiate builds the subtrees of the template.
+//
+// Parameters:
+//   - m: The match whose variables the template uses.
+//
+// Returns:
+//   - []T: The new subtrees.
+//   - error: An error if m is nil, a variable is not captured by m, or the node
+//     function fails.
+//
+// The captured subtrees are relinked into the new ones; so, the matched subtree
+// must be discarded afterwards. On error, nothing is relinked.
+func (tp *Template[T]) Instantiate(m *Match[T]) ([]T, error) {
+	if m == nil {
+		return nil, gcers.NewErrNilParameter("m")
+	}
+
+	used := make(map[T]struct{})
+
+	var nodes []T
+	var links []template_link[T]
+
+	for _, root := range tp.roots {
+		sub, err := tp.build(root, m, used, &links)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, sub...)
+	}
+
+	// Nothing is linked until every node is built; so, the captured subtrees are
+	// left untouched if the node function fails.
+	for _, link := range links {
+		link.node.LinkChildren(link.children)
+	}
+
+	return nodes, nil
+}
+
+// template_link is a node built by a template together with its children.
+type template_link[T TreeNoder] struct {
+	// node is the node.
+	node T
+
+	// children are the children of the node.
+	children []T
+}
+
+// build is a helper method that builds a node of the template. The recursion is
+// bounded by the depth of the template.
+//
+// Parameters:
+//   - pn: The node of the template.
+//   - m: The match.
+//   - used: The captured subtrees used so far. Updated in place.
+//   - links: The children of the built nodes, which are not linked yet. Updated in
+//     place.
+//
+// Returns:
+//   - []T: The built subtrees.
+//   - error: An error if a variable is not captured or the node function fails.
+func (tp *Template[T]) build(pn *pattern_node, m *Match[T], used map[T]struct{}, links *[]template_link[T]) ([]T, error) {
+	var nodes []T
+
+	switch pn.kind {
+	case pattern_any:
+		node, ok := m.vars[pn.bind]
+		if !ok {
+			return nil, errors.New("variable " + strconv.Quote(pn.bind) + " is not captured")
+		}
+
+		nodes = []T{node}
+	case pattern_seq:
+		seq, ok := m.seqs[pn.bind]
+		if !ok {
+			return nil, errors.New("variable " + strconv.Quote(pn.bind) + "... is not captured")
+		}
+
+		nodes = append(nodes, seq...)
+	default:
+		node, err := tp.node_fn(pn.name)
+		if err != nil {
+			return nil, gcers.NewErrAt(strconv.Quote(pn.name), err)
+		}
+
+		var children []T
+
+		for _, child := range pn.children {
+			sub, err := tp.build(child, m, used, links)
+			if err != nil {
+				return nil, err
+			}
+
+			children = append(children, sub...)
+		}
+
+		*links = append(*links, template_link[T]{node: node, children: children})
+
+		return []T{node}, nil
+	}
+
+	for i, node := range nodes {
+		_, ok := used[node]
+		if ok {
+			nodes[i] = DeepCopy(node)
+		} else {
+			used[node] = struct{}{}
+		}
+	}
+
+	return nodes, nil
+}
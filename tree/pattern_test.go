@@ -0,0 +1,177 @@
+package tree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func new_node(name string) (*root.StringNode, error) {
+	return root.NewStringNode(name), nil
+}
+
+func TestPatternMatch(t *testing.T) {
+	tr := must_parse(t, "Add\n├── x\n└── Seq\n    ├── a\n    ├── b\n    └── c")
+
+	tests := []struct {
+		pattern string
+		match   bool
+		vars    map[string]string
+		seqs    map[string]int
+	}{
+		{"Add($x, Seq(_...))", true, map[string]string{"x": "x"}, nil},
+		{"Add(x, Seq($xs..., c))", true, nil, map[string]int{"xs": 2}},
+		{"Add($x, $x)", false, nil, nil},
+		{"_(x, $s:Seq(a, _...))", true, map[string]string{"s": "Seq"}, nil},
+		{"Add(x)", false, nil, nil},
+		{"Add", false, nil, nil},
+	}
+
+	for _, test := range tests {
+		p, err := tree.CompilePattern(test.pattern, node_data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.pattern, err)
+		}
+
+		m, ok := p.Match(tr.Root())
+		if ok != test.match {
+			t.Errorf("%s: got match %t, want %t", test.pattern, ok, test.match)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		for name, want := range test.vars {
+			node, ok := m.Var(name)
+			if !ok || node.Data != want {
+				t.Errorf("%s: $%s is %v, want %s", test.pattern, name, node, want)
+			}
+		}
+
+		for name, want := range test.seqs {
+			seq, ok := m.Seq(name)
+			if !ok || len(seq) != want {
+				t.Errorf("%s: $%s... has %d nodes, want %d", test.pattern, name, len(seq), want)
+			}
+		}
+	}
+}
+
+func TestPatternSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		text   string
+		column int
+		reason string
+	}{
+		{"", 1, "empty pattern"},
+		{"Add(", 5, "end of input"},
+		{"Add($x) Sub", 9, "expected end of input"},
+		{"Add(_", 6, "end of input"},
+	}
+
+	for _, test := range tests {
+		_, err := tree.CompilePattern(test.text, node_data)
+
+		var syntax *tree.ErrSyntax
+
+		if !errors.As(err, &syntax) {
+			t.Errorf("%q: got %v, want an *ErrSyntax", test.text, err)
+			continue
+		}
+
+		if syntax.Column != test.column || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%q: got %v, want column %d and %q", test.text, err, test.column, test.reason)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tr := must_parse(t, "R\n├── Neg\n│   └── Neg\n│       └── x\n└── Add\n    ├── y\n    └── 0")
+
+	rules := make([]tree.Rule[*root.StringNode], 0, 2)
+
+	for _, src := range [][2]string{
+		{"Neg(Neg($x))", "$x"},
+		{"Add($x, 0)", "$x"},
+	} {
+		p, err := tree.CompilePattern(src[0], node_data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tp, err := tree.CompileTemplate(src[1], new_node)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rule, err := tree.NewTemplateRule(src[0], p, tp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	for _, strategy := range []tree.RewriteStrategy{tree.BottomUp, tree.TopDown} {
+		tr := must_parse(t, tr.String())
+
+		count, err := tree.Rewriter[*root.StringNode]{Rules: rules, Strategy: strategy}.Rewrite(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := must_parse(t, "R\n├── x\n└── y")
+
+		if count != 2 || tr.String() != want.String() {
+			t.Errorf("strategy %d: got %d rewrites and\n%s\nwant 2 rewrites and\n%s", strategy, count, tr, want)
+		}
+
+		err = tree.Validate(tr)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRewriteFailingTemplate(t *testing.T) {
+	tr := must_parse(t, "R\n└── Add\n    ├── x\n    └── y")
+	want := tr.String()
+
+	p, err := tree.CompilePattern("Add($a, $b)", node_data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp, err := tree.CompileTemplate("Neg($a), Bad($b)", func(name string) (*root.StringNode, error) {
+		if name == "Bad" {
+			return nil, errors.New("bad node")
+		}
+
+		return root.NewStringNode(name), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule, err := tree.NewTemplateRule("neg", p, tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tree.Rewriter[*root.StringNode]{Rules: []tree.Rule[*root.StringNode]{rule}}.Rewrite(tr)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if tr.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", tr, want)
+	}
+
+	err = tree.Validate(tr)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -1,141 +1,754 @@
 package tree
 
 import (
+	"bufio"
+	"io"
 	"iter"
+	"slices"
+	"strconv"
 	"strings"
+
+	gcers "github.com/PlayerR9/go-errors"
 )
 
-// _TreePrinterTrav is the stack element of the tree stringer.
-type _TreePrinterTrav[T TreeNoder] struct {
-	// seen is the seen map of the tree stringer.
-	seen map[T]struct{}
+// GlyphSet is the set of glyphs used to draw the branches of a tree.
+type GlyphSet struct {
+	// Tee is the connector of a node that has siblings after it (e.g., '├').
+	Tee rune
+
+	// Corner is the connector of the last child of a node (e.g., '└').
+	Corner rune
+
+	// Vertical is the line that continues the branch of a parent (e.g., '│').
+	Vertical rune
+
+	// Horizontal is the line between a connector and a label (e.g., '─').
+	Horizontal rune
+
+	// Ellipsis marks elided nodes (e.g., "…"). Defaults to "...".
+	Ellipsis string
+
+	// Arrow joins the nodes of a collapsed chain (e.g., "→"). Defaults to "->".
+	Arrow string
+}
+
+var (
+	// UnicodeGlyphs draws branches with Unicode box-drawing characters:
+	//
+	//	├── a
+	//	│   └── b
+	//	└── c
+	UnicodeGlyphs GlyphSet
+
+	// ASCIIGlyphs draws branches with ASCII characters only:
+	//
+	//	|-- a
+	//	|   `-- b
+	//	`-- c
+	ASCIIGlyphs GlyphSet
+
+	// RoundedGlyphs is like UnicodeGlyphs with rounded corners:
+	//
+	//	├── a
+	//	│   ╰── b
+	//	╰── c
+	RoundedGlyphs GlyphSet
+)
+
+func init() {
+	UnicodeGlyphs = GlyphSet{Tee: '├', Corner: '└', Vertical: '│', Horizontal: '─', Ellipsis: "…", Arrow: "→"}
+	ASCIIGlyphs = GlyphSet{Tee: '|', Corner: '`', Vertical: '|', Horizontal: '-', Ellipsis: "...", Arrow: "->"}
+	RoundedGlyphs = GlyphSet{Tee: '├', Corner: '╰', Vertical: '│', Horizontal: '─', Ellipsis: "…", Arrow: "→"}
+}
+
+// Annotation is a set of flags that tells which annotations are appended to the
+// label of every node.
+type Annotation int
+
+const (
+	// AnnotateDepth appends the depth of the node; the root is at depth 0.
+	AnnotateDepth Annotation = 1 << iota
+
+	// AnnotateSize appends the number of nodes in the subtree of the node, the node
+	// included.
+	AnnotateSize
+
+	// AnnotateIndex appends the index of the node among its siblings.
+	AnnotateIndex
+)
+
+// ColorByDepth returns a color function, for Printer.Color, that cycles through
+// the given ANSI codes by depth.
+//
+// Parameters:
+//   - codes: The ANSI SGR codes (e.g., "31" for red or "1;34" for bold blue).
+//
+// Returns:
+//   - func(node T, depth int) string: The color function. Nil if no codes are
+//     given.
+func ColorByDepth[T TreeNoder](codes ...string) func(node T, depth int) string {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	return func(node T, depth int) string {
+		return codes[depth%len(codes)]
+	}
+}
+
+// Printer renders trees in the box-drawing format. The zero value is the
+// configuration used by Tree.String:
+//
+//	root
+//	    └── node1
+//	    │   ├── node2
+//	    │   └── node3
+//	    └── node4
+//	        └── node5
+//
+// and, with Standard set:
+//
+//	root
+//	├── node1
+//	│   ├── node2
+//	│   └── node3
+//	└── node4
+//	    └── node5
+type Printer[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// Glyphs are the glyphs used to draw the branches. The zero value means
+	// UnicodeGlyphs.
+	Glyphs GlyphSet
+
+	// IndentWidth is the width, in columns, of every level. Zero means 4 and values
+	// below 2 are treated as 2.
+	IndentWidth int
 
-	// builder is the builder of the tree stringer.
-	builder *strings.Builder
+	// Label returns the label of a node. Defaults to the String of the node.
+	Label func(node T) string
 
-	// indent is the indentation string.
-	indent string
+	// Color returns the ANSI SGR code (e.g., "31" for red) of the label of a node.
+	// An empty code leaves the label uncolored. Optional.
+	Color func(node T, depth int) string
+
+	// Annotations are the annotations appended to every label.
+	Annotations Annotation
+
+	// MaxDepth is the depth, where the root is at depth 0, after which children are
+	// elided into a single "… N more" line. Zero or less means no limit.
+	MaxDepth int
+
+	// MaxSiblings is the number of children shown per node; the remaining ones are
+	// elided into a single "… N more" line. Zero or less means no limit.
+	MaxSiblings int
+
+	// CollapseChains, if true, prints chains of only children on a single line:
+	// "a → b → c".
+	CollapseChains bool
+
+	// Standard, if true, aligns the children of the root with it and draws every
+	// child but the last with the Tee connector. Otherwise, the output is the one
+	// of Tree.String, where the children of the root are indented by one level and
+	// non-leaf nodes are always drawn with the Corner connector.
+	Standard bool
+
+	// margin returns the text written at the start of every line, before the
+	// branches. The node is the zero value for elided lines. Optional.
+	margin func(node T) string
+}
 
-	// is_last is the flag that indicates whether the node is the last node in the level.
+// print_item is a child to print: either a node or a run of elided nodes.
+type print_item[T TreeNoder] struct {
+	// node is the node to print, if more is zero.
+	node T
+
+	// index is the index of the node among its siblings.
+	index int
+
+	// more is the number of elided nodes. Zero for a node.
+	more int
+}
+
+// print_frame is the traversal info of the printer.
+type print_frame[T TreeNoder] struct {
+	// node is the node to print, if more is zero.
+	node T
+
+	// more is the number of elided nodes. Zero for a node.
+	more int
+
+	// depth is the depth of the node.
+	depth int
+
+	// level is the indentation level of the node, which is smaller than its depth
+	// when chains are collapsed.
+	level int
+
+	// index is the index of the node among its siblings.
+	index int
+
+	// is_last is true iff the node is the last child of its parent.
 	is_last bool
 
-	// same_level is the flag that indicates whether the node is in the same level.
-	same_level bool
+	// pushed is the number of nodes printed on the line of the node, which are on
+	// the ancestor path until the node is left. Zero for elided nodes and cycles.
+	pushed int
+}
+
+// label is a helper method that returns the decorated label of a node.
+//
+// Parameters:
+//   - frame: The frame of the node.
+//   - sizes: The subtree sizes, if AnnotateSize is set.
+//
+// Returns:
+//   - string: The label.
+func (p Printer[T]) label(frame print_frame[T], sizes map[T]int) string {
+	var label string
+
+	if p.Label != nil {
+		label = p.Label(frame.node)
+	} else {
+		label = frame.node.String()
+	}
+
+	if p.Color != nil {
+		code := p.Color(frame.node, frame.depth)
+		if code != "" {
+			label = "\x1b[" + code + "m" + label + "\x1b[0m"
+		}
+	}
+
+	if p.Annotations == 0 {
+		return label
+	}
+
+	var annotations []string
+
+	if p.Annotations&AnnotateDepth != 0 {
+		annotations = append(annotations, "depth="+strconv.Itoa(frame.depth))
+	}
+
+	if p.Annotations&AnnotateSize != 0 {
+		annotations = append(annotations, "size="+strconv.Itoa(sizes[frame.node]))
+	}
+
+	if p.Annotations&AnnotateIndex != 0 && frame.depth > 0 {
+		annotations = append(annotations, "index="+strconv.Itoa(frame.index))
+	}
+
+	if len(annotations) == 0 {
+		return label
+	}
+
+	return label + " (" + strings.Join(annotations, ", ") + ")"
 }
 
-// String implements the fmt.Stringer interface.
-func (tse _TreePrinterTrav[T]) String() string {
-	str := tse.builder.String()
-	return strings.TrimSuffix(str, "\n")
+// children is a helper method that returns the children to print of a node.
+//
+// Parameters:
+//   - node: The node.
+//   - depth: The depth of the node.
+//
+// Returns:
+//   - []print_item[T]: The children to print.
+func (p Printer[T]) children(node T, depth int) []print_item[T] {
+	count := count_children(node)
+	if count == 0 {
+		return nil
+	}
+
+	if p.MaxDepth > 0 && depth >= p.MaxDepth {
+		return []print_item[T]{{more: count}}
+	}
+
+	size := count
+	if p.MaxSiblings > 0 {
+		size = min(size, p.MaxSiblings+1)
+	}
+
+	items := make([]print_item[T], 0, size)
+
+	var i int
+
+	for child := range node.Child() {
+		if p.MaxSiblings > 0 && i >= p.MaxSiblings {
+			items = append(items, print_item[T]{more: count - i})
+			break
+		}
+
+		items = append(items, print_item[T]{node: child, index: i})
+		i++
+	}
+
+	return items
 }
 
-// set_is_last is a helper function that sets the is_last flag.
+// elide works like children but on a list of children.
+//
+// Parameters:
+//   - children: The children of the node.
+//   - depth: The depth of the node.
 //
-// Assumes that the receiver is not nil.
-func (tse *_TreePrinterTrav[T]) set_is_last() {
-	tse.is_last = true
+// Returns:
+//   - []print_item[T]: The children to print.
+func (p Printer[T]) elide(children []T, depth int) []print_item[T] {
+	if len(children) == 0 {
+		return nil
+	}
+
+	if p.MaxDepth > 0 && depth >= p.MaxDepth {
+		return []print_item[T]{{more: len(children)}}
+	}
+
+	items := make([]print_item[T], 0, len(children))
+
+	for i, child := range children {
+		if p.MaxSiblings > 0 && i >= p.MaxSiblings {
+			items = append(items, print_item[T]{more: len(children) - i})
+			break
+		}
+
+		items = append(items, print_item[T]{node: child, index: i})
+	}
+
+	return items
 }
 
-// set_same_level is a helper function that sets the same_level flag.
+// Sprint renders the tree.
+//
+// Parameters:
+//   - tree: The tree to render.
 //
-// Assumes that the receiver is not nil.
-func (tse *_TreePrinterTrav[T]) set_same_level() {
-	tse.same_level = true
+// Returns:
+//   - string: The rendered tree, without a trailing newline. Empty if tree is nil.
+//
+// See Fprint for more details.
+func (p Printer[T]) Sprint(tree *Tree[T]) string {
+	if tree == nil {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	// The only errors of fprint are write errors and a strings.Builder never
+	// fails to write.
+	err := p.fprint(&builder, tree.root, p.children)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
 }
 
-// print_fn returns the print function of the tree stringer.
+// Fprint writes the tree to w, one line at a time.
 //
 // Parameters:
-//   - root: The root node of the tree.
+//   - w: The writer to write to.
+//   - tree: The tree to write.
 //
 // Returns:
-//   - Traverser[T]: The print function of the tree stringer.
-func print_fn[T interface {
-	Child() iter.Seq[T]
+//   - error: An error if w or tree is nil, or the first write error.
+//
+// Every line, the last one included, ends with a newline. Besides the output
+// buffer, memory is bounded by the depth of the tree and the number of children
+// waiting to be printed; except with AnnotateSize, which needs the size of every
+// subtree. A node that is one of its own ancestors is printed as
+// "... WARNING: Cycle detected!" and its children are skipped.
+func (p Printer[T]) Fprint(w io.Writer, tree *Tree[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tree == nil {
+		return gcers.NewErrNilParameter("tree")
+	}
+
+	return p.fprint(w, tree.root, p.children)
+}
+
+// Fprint writes the tree to w with the default Printer.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tree: The tree to write.
+//
+// Returns:
+//   - error: An error if w or tree is nil, or the first write error.
+//
+// See Printer.Fprint for more details.
+func Fprint[T interface {
 	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}]() Traverser[T] {
-	init_fn := func(root T) any {
-		var builder strings.Builder
-
-		return &_TreePrinterTrav[T]{
-			seen:       make(map[T]struct{}),
-			builder:    &builder,
-			indent:     "",
-			is_last:    true,
-			same_level: false,
-		}
+}](w io.Writer, tree *Tree[T]) error {
+	return Printer[T]{}.Fprint(w, tree)
+}
+
+// subtree_sizes is a helper function that computes the size of every subtree
+// reachable from root. Like the printer, it only keeps track of the ancestor path:
+// a child that is one of its own ancestors is not counted.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - map[T]int: The size of every subtree. Never returns nil.
+func subtree_sizes[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](root T) map[T]int {
+	type frame struct {
+		node     T
+		children []T
+		next     int
+		size     int
+	}
+
+	sizes := make(map[T]int)
+	on_path := make(map[T]struct{})
+
+	push := func(stack []*frame, node T) []*frame {
+		on_path[node] = struct{}{}
+
+		return append(stack, &frame{
+			node:     node,
+			children: slices.Collect(node.Child()),
+			size:     1,
+		})
 	}
 
-	fn := func(node T, info any) ([]Pair[T], error) {
-		inf := info.(*_TreePrinterTrav[T])
+	stack := push(nil, root)
 
-		if inf.indent != "" {
-			inf.builder.WriteString(inf.indent)
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
 
-			if !node.IsLeaf() || inf.is_last {
-				inf.builder.WriteString("└── ")
+		if top.next < len(top.children) {
+			child := top.children[top.next]
+			top.next++
+
+			_, ok := on_path[child]
+			if ok {
+				continue
+			}
+
+			size, ok := sizes[child]
+			if ok {
+				top.size += size
 			} else {
-				inf.builder.WriteString("├── ")
+				stack = push(stack, child)
 			}
+
+			continue
 		}
 
-		// Prevent cycles.
-		_, ok := inf.seen[node]
-		if ok {
-			inf.builder.WriteString("... WARNING: Cycle detected!\n")
+		stack = stack[:len(stack)-1]
 
-			return nil, nil
+		delete(on_path, top.node)
+		sizes[top.node] = top.size
+
+		if len(stack) > 0 {
+			stack[len(stack)-1].size += top.size
 		}
+	}
+
+	return sizes
+}
+
+// fprint is a helper method that writes the tree rooted at root.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - root: The root of the tree.
+//   - children_fn: The function that returns the children to print of a node.
+//
+// Returns:
+//   - error: The first write error, if any.
+func (p Printer[T]) fprint(w io.Writer, root T, children_fn func(node T, depth int) []print_item[T]) error {
+	glyphs := p.Glyphs
+	if glyphs == (GlyphSet{}) {
+		glyphs = UnicodeGlyphs
+	}
+
+	ellipsis := glyphs.Ellipsis
+	if ellipsis == "" {
+		ellipsis = "..."
+	}
+
+	arrow := glyphs.Arrow
+	if arrow == "" {
+		arrow = "->"
+	}
+
+	width := p.IndentWidth
+	if width == 0 {
+		width = 4
+	} else if width < 2 {
+		width = 2
+	}
+
+	line := strings.Repeat(string(glyphs.Horizontal), width-2) + " "
+	tee := string(glyphs.Tee) + line
+	corner := string(glyphs.Corner) + line
+	vertical := string(glyphs.Vertical) + strings.Repeat(" ", width-1)
+	blank := strings.Repeat(" ", width)
+
+	var sizes map[T]int
+
+	if p.Annotations&AnnotateSize != 0 {
+		sizes = subtree_sizes(root)
+	}
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	// path are the nodes from the root to the current node, and lasts are whether
+	// the printed lines from the root to the current one are the last child of
+	// their parent, by level.
+	var path []T
+	var lasts []bool
+
+	on_path := make(map[T]struct{})
+
+	trav := Traverser[T, *print_frame[T]]{
+		InitFn: func(root T) *print_frame[T] {
+			return &print_frame[T]{node: root, is_last: true}
+		},
+		OnEnter: func(_ T, top *print_frame[T]) ([]Pair[T, *print_frame[T]], Action, error) {
+			if p.margin != nil {
+				tw.write(p.margin(top.node))
+			}
+
+			if top.level > 0 {
+				if !p.Standard {
+					tw.write(blank)
+				}
+
+				for _, is_last := range lasts[1:] {
+					if is_last {
+						tw.write(blank)
+					} else {
+						tw.write(vertical)
+					}
+				}
+
+				if top.is_last || !p.Standard && top.more == 0 && !top.node.IsLeaf() {
+					tw.write(corner)
+				} else {
+					tw.write(tee)
+				}
+			}
+
+			if top.more > 0 {
+				tw.write(ellipsis, " ", strconv.Itoa(top.more), " more\n")
+				return nil, SkipChildren, tw.err
+			}
 
-		inf.builder.WriteString(node.String())
-		inf.builder.WriteRune('\n')
+			_, ok := on_path[top.node]
+			if ok {
+				tw.write("... WARNING: Cycle detected!\n")
+				return nil, SkipChildren, tw.err
+			}
+
+			path = append(path, top.node)
+			on_path[top.node] = struct{}{}
+			lasts = append(lasts, top.is_last)
+			top.pushed = 1
+
+			tw.write(p.label(*top, sizes))
+
+			depth := top.depth
+			items := children_fn(top.node, depth)
+
+			for p.CollapseChains && len(items) == 1 && items[0].more == 0 {
+				child := items[0].node
+
+				_, ok := on_path[child]
+				if ok {
+					break
+				}
+
+				path = append(path, child)
+				on_path[child] = struct{}{}
+				top.pushed++
+				depth++
+
+				tw.write(" ", arrow, " ", p.label(print_frame[T]{node: child, depth: depth, index: items[0].index}, sizes))
+
+				items = children_fn(child, depth)
+			}
+
+			tw.write("\n")
+
+			nexts := make([]Pair[T, *print_frame[T]], 0, len(items))
+
+			for i, item := range items {
+				nexts = append(nexts, NewPair(item.node, &print_frame[T]{
+					node:    item.node,
+					more:    item.more,
+					depth:   depth + 1,
+					level:   top.level + 1,
+					index:   item.index,
+					is_last: i == len(items)-1,
+				}))
+			}
+
+			return nexts, Continue, tw.err
+		},
+		OnLeave: func(_ T, top *print_frame[T]) (Action, error) {
+			if top.pushed == 0 {
+				return Continue, nil
+			}
+
+			for _, node := range path[len(path)-top.pushed:] {
+				delete(on_path, node)
+			}
+
+			path = path[:len(path)-top.pushed]
+			lasts = lasts[:len(lasts)-1]
+
+			return Continue, nil
+		},
+	}
+
+	// The root is not required to be the root of a tree; so, the tree is built
+	// without computing its leaves.
+	_, err := ApplyDFS(&Tree[T]{root: root}, trav)
+	if err != nil {
+		return err
+	}
+
+	return tw.w.Flush()
+}
+
+// Focus renders the part of the tree around the given node: its ancestors, its
+// siblings and the given number of levels of its descendants. Everything else is
+// elided into "… N more" lines.
+//
+// Parameters:
+//   - p: The printer to use. MaxDepth, MaxSiblings and CollapseChains still apply;
+//     MaxSiblings windows the siblings of the node around it.
+//   - tree: The tree to render.
+//   - node: The node to focus on.
+//   - levels: The number of levels of descendants to show. Zero or less shows none.
+//
+// Returns:
+//   - string: The rendered view.
+//   - error: NodeNotPartOfTree if the node is not part of the tree, or an error if
+//     tree is nil.
+func Focus[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}](p Printer[T], tree *Tree[T], node T, levels int) (string, error) {
+	if tree == nil {
+		return "", gcers.NewErrNilParameter("tree")
+	}
 
-		inf.seen[node] = struct{}{}
+	path := append(GetNodeAncestors(node), node)
+	if path[0] != tree.root {
+		return "", NodeNotPartOfTree
+	}
 
-		if node.IsLeaf() {
-			return nil, nil
+	focus_depth := len(path) - 1
+
+	// window returns the children of n as a window of size children around the
+	// child at index idx.
+	window := func(n T, idx, size int) []print_item[T] {
+		count := count_children(n)
+
+		start := max(0, min(idx-size/2, count-size))
+		end := start + size
+
+		var items []print_item[T]
+
+		if start > 0 {
+			items = append(items, print_item[T]{more: start})
 		}
 
-		var indent strings.Builder
+		var i int
 
-		indent.WriteString(inf.indent)
+		for child := range n.Child() {
+			if i >= start && i < end {
+				items = append(items, print_item[T]{node: child, index: i})
+			}
 
-		if inf.same_level && !inf.is_last {
-			indent.WriteString("│   ")
-		} else {
-			indent.WriteString("    ")
+			i++
 		}
 
-		var elems []Pair[T]
+		if end < count {
+			items = append(items, print_item[T]{more: count - end})
+		}
 
-		for c := range node.Child() {
-			se := &_TreePrinterTrav[T]{
-				seen:       inf.seen,
-				builder:    inf.builder,
-				indent:     indent.String(),
-				is_last:    false,
-				same_level: false,
+		return items
+	}
+
+	children_fn := func(n T, depth int) []print_item[T] {
+		if depth < focus_depth && path[depth] == n {
+			next := path[depth+1]
+
+			var idx int
+
+			for child := range n.Child() {
+				if child == next {
+					break
+				}
+
+				idx++
+			}
+
+			size := 1
+
+			if depth == focus_depth-1 {
+				size = count_children(n)
+
+				if p.MaxSiblings > 0 {
+					size = min(size, p.MaxSiblings)
+				}
 			}
 
-			elems = append(elems, NewPair(c, se))
+			return window(n, idx, size)
 		}
 
-		if len(elems) >= 2 {
-			for i := 0; i < len(elems); i++ {
-				elems[i].Info.(*_TreePrinterTrav[T]).set_same_level()
+		if depth == focus_depth && n != node {
+			count := count_children(n)
+			if count == 0 {
+				return nil
 			}
+
+			return []print_item[T]{{more: count}}
 		}
 
-		elems[len(elems)-1].Info.(*_TreePrinterTrav[T]).set_is_last()
+		if depth-focus_depth >= levels {
+			count := count_children(n)
+			if count == 0 {
+				return nil
+			}
 
-		return elems, nil
+			return []print_item[T]{{more: count}}
+		}
+
+		return p.children(n, depth)
 	}
 
-	return Traverser[T]{
-		InitFn: init_fn,
-		DoFn:   fn,
+	var builder strings.Builder
+
+	err := p.fprint(&builder, tree.root, children_fn)
+	if err != nil {
+		return "", err
 	}
+
+	return strings.TrimSuffix(builder.String(), "\n"), nil
 }
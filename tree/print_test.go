@@ -0,0 +1,308 @@
+package tree_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+const print_input = "a\n├── b\n│   ├── c\n│   └── d\n├── e\n└── f\n    └── g"
+
+func TestTreeString(t *testing.T) {
+	tr := must_parse(t, print_input)
+
+	want := strings.Join([]string{
+		"StringNode[a]",
+		"    └── StringNode[b]",
+		"    │   ├── StringNode[c]",
+		"    │   └── StringNode[d]",
+		"    ├── StringNode[e]",
+		"    └── StringNode[f]",
+		"        └── StringNode[g]",
+	}, "\n")
+
+	got := tr.String()
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrinterStandard(t *testing.T) {
+	tr := must_parse(t, print_input)
+
+	p := tree.Printer[*root.StringNode]{
+		Standard: true,
+		Label: func(node *root.StringNode) string {
+			return node.Data
+		},
+	}
+
+	got := p.Sprint(tr)
+	if got != print_input {
+		t.Errorf("got\n%s\nwant\n%s", got, print_input)
+	}
+
+	p.Glyphs = tree.ASCIIGlyphs
+	p.MaxSiblings = 2
+	p.Annotations = tree.AnnotateSize
+
+	want := strings.Join([]string{
+		"a (size=7)",
+		"|-- b (size=3)",
+		"|   |-- c (size=1)",
+		"|   `-- d (size=1)",
+		"|-- e (size=1)",
+		"`-- ... 1 more",
+	}, "\n")
+
+	got = p.Sprint(tr)
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParsePrintRoundTrip(t *testing.T) {
+	tr := must_parse(t, print_input)
+
+	for _, p := range []tree.Printer[*root.StringNode]{
+		{},
+		{Standard: true},
+		{Standard: true, Glyphs: tree.ASCIIGlyphs},
+		{Standard: true, Glyphs: tree.RoundedGlyphs},
+	} {
+		text := p.Sprint(tr)
+
+		got, err := root.ParseStringTree(text)
+		if err != nil {
+			t.Fatalf("parse %q: %v", text, err)
+		}
+
+		if got.String() != tr.String() {
+			t.Errorf("got\n%s\nwant\n%s", got, tr)
+		}
+
+		err = tree.Validate(got)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"a\nb",
+		"a\n│  └── b",
+		"a\n├── b\n        └── c",
+	} {
+		_, err := root.ParseStringTree(text)
+
+		var syntax *tree.ErrSyntax
+
+		if !errors.As(err, &syntax) {
+			t.Errorf("parse %q: got %v, want an *ErrSyntax", text, err)
+		}
+	}
+}
+
+type error_writer struct {
+	n int
+}
+
+func (w *error_writer) Write(p []byte) (int, error) {
+	if w.n < len(p) {
+		return 0, errors.New("disk full")
+	}
+
+	w.n -= len(p)
+
+	return len(p), nil
+}
+
+func TestFprint(t *testing.T) {
+	tr := must_parse(t, print_input)
+
+	var buf bytes.Buffer
+
+	err := tree.Fprint(&buf, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != tr.String()+"\n" {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), tr.String())
+	}
+
+	err = tree.Fprint(&error_writer{n: 10}, tr)
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("got %v, want the write error", err)
+	}
+}
+
+func TestPrinterCycle(t *testing.T) {
+	tr := must_parse(t, "a\n└── b\n    └── c")
+
+	// Make b a child of c behind the back of the links.
+	b := tr.Root().FirstChild
+	c := b.FirstChild
+	c.FirstChild, c.LastChild = b, b
+
+	p := tree.Printer[*root.StringNode]{
+		Standard:    true,
+		Annotations: tree.AnnotateSize,
+		Label: func(node *root.StringNode) string {
+			return node.Data
+		},
+	}
+
+	want := strings.Join([]string{
+		"a (size=3)",
+		"└── b (size=2)",
+		"    └── c (size=1)",
+		"        └── ... WARNING: Cycle detected!",
+	}, "\n")
+
+	got := p.Sprint(tr)
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+const truncate_input = "a\n├── b\n│   └── c\n│       └── d\n├── e\n│   ├── e1\n│   ├── e2\n│   ├── e3\n│   ├── e4\n│   └── e5\n└── f\n    └── g"
+
+func TestPrinterTruncation(t *testing.T) {
+	tr := must_parse(t, truncate_input)
+
+	label := func(node *root.StringNode) string {
+		return node.Data
+	}
+
+	tests := []struct {
+		name    string
+		printer tree.Printer[*root.StringNode]
+		want    []string
+	}{
+		{
+			name:    "max depth",
+			printer: tree.Printer[*root.StringNode]{Standard: true, Label: label, MaxDepth: 1},
+			want: []string{
+				"a",
+				"├── b",
+				"│   └── … 1 more",
+				"├── e",
+				"│   └── … 5 more",
+				"└── f",
+				"    └── … 1 more",
+			},
+		},
+		{
+			name:    "collapsed chains",
+			printer: tree.Printer[*root.StringNode]{Standard: true, Label: label, CollapseChains: true},
+			want: []string{
+				"a",
+				"├── b → c → d",
+				"├── e",
+				"│   ├── e1",
+				"│   ├── e2",
+				"│   ├── e3",
+				"│   ├── e4",
+				"│   └── e5",
+				"└── f → g",
+			},
+		},
+		{
+			name: "max siblings",
+			printer: tree.Printer[*root.StringNode]{
+				Standard:       true,
+				Label:          label,
+				Glyphs:         tree.ASCIIGlyphs,
+				MaxSiblings:    2,
+				CollapseChains: true,
+			},
+			want: []string{
+				"a",
+				"|-- b -> c -> d",
+				"|-- e",
+				"|   |-- e1",
+				"|   |-- e2",
+				"|   `-- ... 3 more",
+				"`-- ... 1 more",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := strings.Join(tt.want, "\n")
+
+			got := tt.printer.Sprint(tr)
+			if got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFocus(t *testing.T) {
+	tr := must_parse(t, truncate_input)
+	b := tr.Root().FirstChild
+	e3 := b.NextSibling.FirstChild.NextSibling.NextSibling
+
+	p := tree.Printer[*root.StringNode]{
+		Standard: true,
+		Label: func(node *root.StringNode) string {
+			return node.Data
+		},
+	}
+
+	got, err := tree.Focus(p, tr, b, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"a",
+		"├── b",
+		"│   └── c",
+		"│       └── … 1 more",
+		"├── e",
+		"│   └── … 5 more",
+		"└── f",
+		"    └── … 1 more",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	p.MaxSiblings = 3
+
+	got, err = tree.Focus(p, tr, e3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = strings.Join([]string{
+		"a",
+		"├── … 1 more",
+		"├── e",
+		"│   ├── … 1 more",
+		"│   ├── e2",
+		"│   ├── e3",
+		"│   ├── e4",
+		"│   └── … 1 more",
+		"└── … 1 more",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	_, err = tree.Focus(p, tr, root.NewStringNode("z"), 1)
+	if !errors.Is(err, tree.NodeNotPartOfTree) {
+		t.Errorf("got %v, want NodeNotPartOfTree", err)
+	}
+}
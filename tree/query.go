@@ -0,0 +1,1040 @@
+package tree
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strconv"
+	"unicode"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// query_axis is the direction of a step of a query.
+type query_axis int
+
+const (
+	// axis_child selects the children of the node.
+	axis_child query_axis = iota
+
+	// axis_descendant selects the descendants of the node.
+	axis_descendant
+
+	// axis_descendant_or_self selects the node and its descendants.
+	axis_descendant_or_self
+
+	// axis_parent selects the parent of the node.
+	axis_parent
+
+	// axis_ancestor selects the ancestors of the node, the nearest first.
+	axis_ancestor
+
+	// axis_ancestor_or_self selects the node and its ancestors, the nearest first.
+	axis_ancestor_or_self
+
+	// axis_self selects the node.
+	axis_self
+)
+
+// query_axes are the axes by name.
+var query_axes map[string]query_axis
+
+func init() {
+	query_axes = map[string]query_axis{
+		"child":              axis_child,
+		"descendant":         axis_descendant,
+		"descendant-or-self": axis_descendant_or_self,
+		"parent":             axis_parent,
+		"ancestor":           axis_ancestor,
+		"ancestor-or-self":   axis_ancestor_or_self,
+		"self":               axis_self,
+	}
+}
+
+// query_predicate_kind is the kind of a predicate.
+type query_predicate_kind int
+
+const (
+	// predicate_position keeps the node at a position.
+	predicate_position query_predicate_kind = iota
+
+	// predicate_last keeps the last node.
+	predicate_last
+
+	// predicate_has_attr keeps the nodes that have an attribute.
+	predicate_has_attr
+
+	// predicate_attr keeps the nodes whose attribute is (or is not) a value.
+	predicate_attr
+
+	// predicate_text keeps the nodes whose String is (or is not) a value.
+	predicate_text
+)
+
+// query_predicate is a predicate of a step, between brackets.
+type query_predicate struct {
+	// kind is the kind of the predicate.
+	kind query_predicate_kind
+
+	// position is the 1-based position to keep. Only for predicate_position.
+	position int
+
+	// attr is the name of the attribute.
+	attr string
+
+	// value is the value to compare to.
+	value string
+
+	// negate is true if the comparison is "!=".
+	negate bool
+}
+
+// query_step is a step of a query; that is, what is between two slashes.
+type query_step struct {
+	// axis is the axis of the step.
+	axis query_axis
+
+	// name is the name to match. Ignored if wildcard is true.
+	name string
+
+	// wildcard is true if the step matches any node.
+	wildcard bool
+
+	// node is true if the step also matches the document, like node() in XPath.
+	// Only set for the steps that "//" stands for.
+	node bool
+
+	// predicates are the predicates of the step, applied in order.
+	predicates []query_predicate
+}
+
+// Query is a compiled query that selects nodes of a tree. Queries are written in
+// a small subset of XPath:
+//
+//	/Module//Call[2]/Ident     Absolute path; "//" reaches any descendant.
+//	Ident/..                   Relative path; ".." is the parent and "." the node.
+//	ancestor::Func[1]          Axes: child (default), descendant, descendant-or-self,
+//	                           parent, ancestor, ancestor-or-self and self.
+//	*[last()]                  "*" matches any node; last() is the last one.
+//	Call[@kind='method']       Attributes registered with QueryCompiler.RegisterAttr.
+//	Call[@async]               True if the attribute exists.
+//	*[text()!='nil']           Compares the String of the node.
+//	'IntNode[1]'               Quoted names may contain any character.
+//
+// Names are matched against the name function of the compiler which, by default,
+// is the String of the node. Positions start at 1 and count the nodes selected by
+// the step from each node, the nearest first for the ancestor axes.
+type Query[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// text is the source of the query.
+	text string
+
+	// absolute is true if the query starts from the root.
+	absolute bool
+
+	// steps are the steps of the query.
+	steps []query_step
+
+	// name_fn returns the name of a node.
+	name_fn func(node T) string
+
+	// attrs are the attribute functions by name.
+	attrs map[string]func(node T) (string, bool)
+}
+
+// QueryCompiler compiles queries for a type of node.
+type QueryCompiler[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}] struct {
+	// name_fn returns the name of a node.
+	name_fn func(node T) string
+
+	// attrs are the attribute functions by name.
+	attrs map[string]func(node T) (string, bool)
+}
+
+// NewQueryCompiler creates a new query compiler that matches names against the
+// String of the nodes and that has no attribute.
+//
+// Returns:
+//   - *QueryCompiler[T]: The new compiler. Never returns nil.
+func NewQueryCompiler[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}]() *QueryCompiler[T] {
+	return &QueryCompiler[T]{
+		name_fn: T.String,
+		attrs:   make(map[string]func(node T) (string, bool)),
+	}
+}
+
+// SetNameFunc sets the function that returns the name matched by the steps of the
+// queries (e.g., the kind of an AST node).
+//
+// Parameters:
+//   - fn: The name function. If nil, the String of the node is used.
+//
+// Queries that are already compiled are not affected.
+func (qc *QueryCompiler[T]) SetNameFunc(fn func(node T) string) {
+	if fn == nil {
+		fn = T.String
+	}
+
+	qc.name_fn = fn
+}
+
+// RegisterAttr registers an attribute that queries can test with "@name".
+//
+// Parameters:
+//   - name: The name of the attribute. Letters, digits, '_' and '-' only.
+//   - fn: The function that returns the value of the attribute and whether the
+//     node has it.
+//
+// Returns:
+//   - error: An error if fn is nil or the name is invalid.
+//
+// Registering a name again replaces its function. Queries that are already
+// compiled are not affected.
+func (qc *QueryCompiler[T]) RegisterAttr(name string, fn func(node T) (string, bool)) error {
+	if fn == nil {
+		return gcers.NewErrNilParameter("fn")
+	}
+
+	if name == "" {
+		return gcers.NewErrInvalidParameter("attribute name must not be empty")
+	}
+
+	for _, r := range name {
+		if !is_query_word(r) {
+			return gcers.NewErrInvalidParameter("invalid attribute name " + strconv.Quote(name))
+		}
+	}
+
+	qc.attrs[name] = fn
+
+	return nil
+}
+
+// Compile compiles a query.
+//
+// Parameters:
+//   - text: The query. See Query for the syntax.
+//
+// Returns:
+//   - *Query[T]: The compiled query. Nil if an error occurs.
+//   - error: An error of type *ErrSyntax, on line 1, if the query is malformed or
+//     uses an attribute that is not registered.
+func (qc QueryCompiler[T]) Compile(text string) (*Query[T], error) {
+	tokens, err := lex_query(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &query_parser{
+		tokens: tokens,
+		attrs:  make(map[string]struct{}, len(qc.attrs)),
+	}
+
+	for name := range qc.attrs {
+		p.attrs[name] = struct{}{}
+	}
+
+	absolute, steps, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]func(node T) (string, bool), len(qc.attrs))
+	for name, fn := range qc.attrs {
+		attrs[name] = fn
+	}
+
+	return &Query[T]{
+		text:     text,
+		absolute: absolute,
+		steps:    steps,
+		name_fn:  qc.name_fn,
+		attrs:    attrs,
+	}, nil
+}
+
+// CompileQuery compiles a query that matches names against the String of the
+// nodes and that has no attribute. Use a QueryCompiler for other configurations.
+//
+// Parameters:
+//   - text: The query. See Query for the syntax.
+//
+// Returns:
+//   - *Query[T]: The compiled query. Nil if an error occurs.
+//   - error: An error of type *ErrSyntax if the query is malformed.
+func CompileQuery[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	TreeNoder
+}](text string) (*Query[T], error) {
+	return NewQueryCompiler[T]().Compile(text)
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Returns:
+//   - string: The source of the query.
+func (q Query[T]) String() string {
+	return q.text
+}
+
+// Select evaluates the query from the root of the tree.
+//
+// Parameters:
+//   - tree: The tree to query.
+//
+// Returns:
+//   - iter.Seq[T]: The selected nodes, each once, in DFS order. Empty if tree is
+//     nil.
+func (q *Query[T]) Select(tree *Tree[T]) iter.Seq[T] {
+	if tree == nil {
+		return func(yield func(T) bool) {}
+	}
+
+	return q.SelectFrom(tree, tree.root)
+}
+
+// SelectFrom evaluates the query from a node of the tree. Absolute queries ignore
+// the node and start from the root.
+//
+// Parameters:
+//   - tree: The tree to query.
+//   - node: The node relative queries start from.
+//
+// Returns:
+//   - iter.Seq[T]: The selected nodes, each once, in DFS order. Empty if tree is
+//     nil or the node is not part of the tree.
+func (q *Query[T]) SelectFrom(tree *Tree[T], node T) iter.Seq[T] {
+	if tree == nil {
+		return func(yield func(T) bool) {}
+	}
+
+	fn := func(yield func(T) bool) {
+		rank := make(map[T]int, tree.size)
+
+		for n := range tree.DFS() {
+			rank[n] = len(rank)
+		}
+
+		_, ok := rank[node]
+		if !ok {
+			return
+		}
+
+		// Like in XPath, absolute queries start from the document: a virtual node
+		// whose only child is the root.
+		current := []T{node}
+		document := q.absolute
+
+		if document {
+			current = nil
+		}
+
+		for _, step := range q.steps {
+			current, document = q.eval_step(step, current, document, tree.root, rank)
+			if len(current) == 0 && !document {
+				return
+			}
+		}
+
+		if document && len(q.steps) == 0 {
+			current = []T{tree.root}
+		}
+
+		for _, n := range current {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+
+	return fn
+}
+
+// eval_step is a helper method that evaluates a step.
+//
+// Parameters:
+//   - step: The step to evaluate.
+//   - context: The nodes the step starts from, in DFS order.
+//   - document: Whether the document is part of the context, before the nodes.
+//   - root: The root of the tree.
+//   - rank: The position of every node of the tree in DFS order.
+//
+// Returns:
+//   - []T: The selected nodes, each once, in DFS order.
+//   - bool: Whether the document is selected.
+func (q *Query[T]) eval_step(step query_step, context []T, document bool, root T, rank map[T]int) ([]T, bool) {
+	var result []T
+
+	selected := make(map[T]struct{})
+
+	// Without predicates, a node whose descendants were already walked adds nothing
+	// new on the descendant axes.
+	var covered map[T]struct{}
+
+	if len(step.predicates) == 0 && (step.axis == axis_descendant || step.axis == axis_descendant_or_self) {
+		covered = make(map[T]struct{})
+	}
+
+	if document {
+		switch step.axis {
+		case axis_child:
+			result = q.collect(step, func(yield func(T) bool) { yield(root) }, covered, selected, result)
+		case axis_descendant, axis_descendant_or_self:
+			result = q.collect(step, q.axis(axis_descendant_or_self, root), covered, selected, result)
+		}
+
+		document = step.node && step.axis == axis_descendant_or_self
+	}
+
+	for _, node := range context {
+		if covered != nil {
+			_, ok := covered[node]
+			if ok {
+				continue
+			}
+		}
+
+		result = q.collect(step, q.axis(step.axis, node), covered, selected, result)
+	}
+
+	slices.SortFunc(result, func(a, b T) int {
+		return rank[a] - rank[b]
+	})
+
+	return result, document
+}
+
+// collect is a helper method that applies the node test and the predicates of a
+// step to the nodes of an axis.
+//
+// Parameters:
+//   - step: The step.
+//   - nodes: The nodes of the axis.
+//   - covered: The nodes walked so far, if needed. Updated in place.
+//   - selected: The nodes selected so far. Updated in place.
+//   - result: The nodes selected so far, in order.
+//
+// Returns:
+//   - []T: The result with the newly selected nodes appended.
+func (q *Query[T]) collect(step query_step, nodes iter.Seq[T], covered, selected map[T]struct{}, result []T) []T {
+	var candidates []T
+
+	for n := range nodes {
+		if covered != nil {
+			covered[n] = struct{}{}
+		}
+
+		if step.wildcard || q.name_fn(n) == step.name {
+			candidates = append(candidates, n)
+		}
+	}
+
+	for _, pred := range step.predicates {
+		candidates = q.filter(pred, candidates)
+	}
+
+	for _, n := range candidates {
+		_, ok := selected[n]
+		if ok {
+			continue
+		}
+
+		selected[n] = struct{}{}
+		result = append(result, n)
+	}
+
+	return result
+}
+
+// axis is a helper method that scans the nodes of an axis.
+//
+// Parameters:
+//   - axis: The axis.
+//   - node: The node the axis starts from.
+//
+// Returns:
+//   - iter.Seq[T]: The nodes of the axis, in DFS order or, for the ancestor axes,
+//     the nearest first.
+func (q *Query[T]) axis(axis query_axis, node T) iter.Seq[T] {
+	switch axis {
+	case axis_child:
+		return node.Child()
+	case axis_descendant, axis_descendant_or_self:
+		return func(yield func(T) bool) {
+			stack := []T{node}
+
+			for len(stack) > 0 {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				if (top != node || axis == axis_descendant_or_self) && !yield(top) {
+					return
+				}
+
+				for child := range top.BackwardChild() {
+					stack = append(stack, child)
+				}
+			}
+		}
+	case axis_parent:
+		return func(yield func(T) bool) {
+			parent, ok := node.GetParent()
+			if ok {
+				yield(parent)
+			}
+		}
+	case axis_ancestor, axis_ancestor_or_self:
+		return func(yield func(T) bool) {
+			if axis == axis_ancestor_or_self && !yield(node) {
+				return
+			}
+
+			for parent, ok := node.GetParent(); ok; parent, ok = parent.GetParent() {
+				if !yield(parent) {
+					return
+				}
+			}
+		}
+	default:
+		return func(yield func(T) bool) {
+			yield(node)
+		}
+	}
+}
+
+// filter is a helper method that applies a predicate.
+//
+// Parameters:
+//   - pred: The predicate.
+//   - nodes: The nodes to filter, in axis order.
+//
+// Returns:
+//   - []T: The nodes that satisfy the predicate.
+func (q *Query[T]) filter(pred query_predicate, nodes []T) []T {
+	switch pred.kind {
+	case predicate_position:
+		if pred.position > len(nodes) {
+			return nil
+		}
+
+		return nodes[pred.position-1 : pred.position]
+	case predicate_last:
+		if len(nodes) == 0 {
+			return nil
+		}
+
+		return nodes[len(nodes)-1:]
+	}
+
+	var kept []T
+
+	for _, n := range nodes {
+		var ok bool
+
+		switch pred.kind {
+		case predicate_has_attr:
+			_, ok = q.attrs[pred.attr](n)
+		case predicate_attr:
+			var value string
+
+			value, ok = q.attrs[pred.attr](n)
+			ok = ok && (value == pred.value) != pred.negate
+		case predicate_text:
+			ok = (n.String() == pred.value) != pred.negate
+		}
+
+		if ok {
+			kept = append(kept, n)
+		}
+	}
+
+	return kept
+}
+
+// query_token_kind is the kind of a token of a query.
+type query_token_kind int
+
+const (
+	token_eof query_token_kind = iota
+	token_slash
+	token_double_slash
+	token_double_colon
+	token_lbracket
+	token_rbracket
+	token_lparen
+	token_rparen
+	token_at
+	token_eq
+	token_neq
+	token_dot
+	token_double_dot
+	token_star
+	token_word
+	token_string
+	token_comma
+	token_dollar
+	token_ellipsis
+	token_colon
+)
+
+// query_token is a token of a query.
+type query_token struct {
+	// kind is the kind of the token.
+	kind query_token_kind
+
+	// text is the text of a word or the unquoted text of a string.
+	text string
+
+	// column is the 1-based column, in runes, where the token starts.
+	column int
+}
+
+// describe returns the token as shown in error messages.
+func (tk query_token) describe() string {
+	switch tk.kind {
+	case token_eof:
+		return "end of input"
+	case token_word:
+		return strconv.Quote(tk.text)
+	case token_string:
+		return "string " + strconv.Quote(tk.text)
+	default:
+		return strconv.Quote(query_symbols[tk.kind])
+	}
+}
+
+// query_symbols are the texts of the punctuation tokens.
+var query_symbols map[query_token_kind]string
+
+func init() {
+	query_symbols = map[query_token_kind]string{
+		token_slash:        "/",
+		token_double_slash: "//",
+		token_double_colon: "::",
+		token_lbracket:     "[",
+		token_rbracket:     "]",
+		token_lparen:       "(",
+		token_rparen:       ")",
+		token_at:           "@",
+		token_eq:           "=",
+		token_neq:          "!=",
+		token_dot:          ".",
+		token_double_dot:   "..",
+		token_star:         "*",
+		token_comma:        ",",
+		token_dollar:       "$",
+		token_ellipsis:     "...",
+		token_colon:        ":",
+	}
+}
+
+// is_query_word checks whether a rune can be part of a name.
+//
+// Parameters:
+//   - r: The rune to check.
+//
+// Returns:
+//   - bool: True if r is a letter, a digit, '_' or '-'.
+func is_query_word(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// lex_query is a helper function that splits a query into tokens. Patterns and
+// templates use the same tokens.
+//
+// Parameters:
+//   - text: The query.
+//
+// Returns:
+//   - []query_token: The tokens, ending with a token_eof.
+//   - error: An error of type *ErrSyntax if a character is unexpected or a string
+//     is not closed.
+func lex_query(text string) ([]query_token, error) {
+	runes := []rune(text)
+
+	var tokens []query_token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		col := i + 1
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		var next rune
+
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		var kind query_token_kind
+
+		if r == '.' && next == '.' && i+2 < len(runes) && runes[i+2] == '.' {
+			tokens = append(tokens, query_token{kind: token_ellipsis, column: col})
+			i += 3
+
+			continue
+		}
+
+		switch {
+		case r == '/' && next == '/':
+			kind = token_double_slash
+		case r == ':' && next == ':':
+			kind = token_double_colon
+		case r == '!' && next == '=':
+			kind = token_neq
+		case r == '.' && next == '.':
+			kind = token_double_dot
+		}
+
+		if kind != token_eof {
+			tokens = append(tokens, query_token{kind: kind, column: col})
+			i += 2
+
+			continue
+		}
+
+		switch r {
+		case '/':
+			kind = token_slash
+		case '[':
+			kind = token_lbracket
+		case ']':
+			kind = token_rbracket
+		case '(':
+			kind = token_lparen
+		case ')':
+			kind = token_rparen
+		case '@':
+			kind = token_at
+		case '=':
+			kind = token_eq
+		case '.':
+			kind = token_dot
+		case '*':
+			kind = token_star
+		case ',':
+			kind = token_comma
+		case '$':
+			kind = token_dollar
+		case ':':
+			kind = token_colon
+		}
+
+		if kind != token_eof {
+			tokens = append(tokens, query_token{kind: kind, column: col})
+			i++
+
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"':
+			end := slices.Index(runes[i+1:], r)
+			if end < 0 {
+				return nil, NewErrSyntax(1, col, errors.New("string is not closed"))
+			}
+
+			tokens = append(tokens, query_token{
+				kind:   token_string,
+				text:   string(runes[i+1 : i+1+end]),
+				column: col,
+			})
+
+			i += end + 2
+		case is_query_word(r):
+			j := i + 1
+
+			for j < len(runes) && is_query_word(runes[j]) {
+				j++
+			}
+
+			tokens = append(tokens, query_token{
+				kind:   token_word,
+				text:   string(runes[i:j]),
+				column: col,
+			})
+
+			i = j
+		default:
+			return nil, NewErrSyntax(1, col, errors.New("unexpected character "+strconv.QuoteRune(r)))
+		}
+	}
+
+	tokens = append(tokens, query_token{kind: token_eof, column: len(runes) + 1})
+
+	return tokens, nil
+}
+
+// query_parser is the parser of queries.
+type query_parser struct {
+	// tokens are the tokens of the query, ending with a token_eof.
+	tokens []query_token
+
+	// pos is the index of the current token.
+	pos int
+
+	// attrs are the names of the registered attributes.
+	attrs map[string]struct{}
+}
+
+// peek returns the current token.
+func (p *query_parser) peek() query_token {
+	return p.tokens[p.pos]
+}
+
+// next returns the current token and moves to the next one.
+func (p *query_parser) next() query_token {
+	tk := p.tokens[p.pos]
+
+	if tk.kind != token_eof {
+		p.pos++
+	}
+
+	return tk
+}
+
+// unexpected returns the error for an unexpected token.
+func (p *query_parser) unexpected(tk query_token, expected string) error {
+	return NewErrSyntax(1, tk.column, errors.New("expected "+expected+", got "+tk.describe()))
+}
+
+// expect consumes a token of the given kind.
+func (p *query_parser) expect(kind query_token_kind) error {
+	tk := p.next()
+	if tk.kind != kind {
+		return p.unexpected(tk, strconv.Quote(query_symbols[kind]))
+	}
+
+	return nil
+}
+
+// parse parses the whole query.
+//
+// Returns:
+//   - bool: True if the query is absolute.
+//   - []query_step: The steps of the query.
+//   - error: An error of type *ErrSyntax if the query is malformed.
+func (p *query_parser) parse() (bool, []query_step, error) {
+	var absolute bool
+	var steps []query_step
+
+	descendants := query_step{axis: axis_descendant_or_self, wildcard: true, node: true}
+
+	switch p.peek().kind {
+	case token_eof:
+		return false, nil, NewErrSyntax(1, 1, errors.New("empty query"))
+	case token_slash:
+		p.next()
+		absolute = true
+
+		if p.peek().kind == token_eof {
+			return true, nil, nil
+		}
+	case token_double_slash:
+		p.next()
+		absolute = true
+		steps = append(steps, descendants)
+	}
+
+	for {
+		step, err := p.parse_step()
+		if err != nil {
+			return false, nil, err
+		}
+
+		steps = append(steps, step)
+
+		tk := p.next()
+
+		switch tk.kind {
+		case token_eof:
+			return absolute, steps, nil
+		case token_slash:
+		case token_double_slash:
+			steps = append(steps, descendants)
+		default:
+			return false, nil, p.unexpected(tk, `"/" or end of input`)
+		}
+	}
+}
+
+// parse_step parses a step and its predicates.
+//
+// Returns:
+//   - query_step: The step.
+//   - error: An error of type *ErrSyntax if the step is malformed.
+func (p *query_parser) parse_step() (query_step, error) {
+	step := query_step{axis: axis_child}
+
+	tk := p.next()
+
+	switch tk.kind {
+	case token_dot:
+		step.axis = axis_self
+		step.wildcard = true
+
+		return step, nil
+	case token_double_dot:
+		step.axis = axis_parent
+		step.wildcard = true
+
+		return step, nil
+	case token_word:
+		if p.peek().kind != token_double_colon {
+			break
+		}
+
+		axis, ok := query_axes[tk.text]
+		if !ok {
+			return step, NewErrSyntax(1, tk.column, errors.New("unknown axis "+strconv.Quote(tk.text)))
+		}
+
+		step.axis = axis
+
+		p.next()
+		tk = p.next()
+	}
+
+	switch tk.kind {
+	case token_star:
+		step.wildcard = true
+	case token_word, token_string:
+		step.name = tk.text
+	default:
+		return step, p.unexpected(tk, "a name or \"*\"")
+	}
+
+	for p.peek().kind == token_lbracket {
+		p.next()
+
+		pred, err := p.parse_predicate()
+		if err != nil {
+			return step, err
+		}
+
+		err = p.expect(token_rbracket)
+		if err != nil {
+			return step, err
+		}
+
+		step.predicates = append(step.predicates, pred)
+	}
+
+	return step, nil
+}
+
+// parse_predicate parses what is between the brackets of a predicate.
+//
+// Returns:
+//   - query_predicate: The predicate.
+//   - error: An error of type *ErrSyntax if the predicate is malformed.
+func (p *query_parser) parse_predicate() (query_predicate, error) {
+	var pred query_predicate
+
+	tk := p.next()
+
+	switch tk.kind {
+	case token_at:
+		name := p.next()
+		if name.kind != token_word {
+			return pred, p.unexpected(name, "an attribute name")
+		}
+
+		_, ok := p.attrs[name.text]
+		if !ok {
+			return pred, NewErrSyntax(1, name.column, errors.New("unknown attribute "+strconv.Quote(name.text)))
+		}
+
+		pred.attr = name.text
+
+		kind := p.peek().kind
+		if kind != token_eq && kind != token_neq {
+			pred.kind = predicate_has_attr
+			return pred, nil
+		}
+
+		pred.kind = predicate_attr
+	case token_word:
+		n, err := strconv.Atoi(tk.text)
+		if err == nil {
+			if n < 1 {
+				return pred, NewErrSyntax(1, tk.column, errors.New("position must be at least 1"))
+			}
+
+			pred.kind = predicate_position
+			pred.position = n
+
+			return pred, nil
+		}
+
+		switch tk.text {
+		case "last":
+			pred.kind = predicate_last
+		case "text":
+			pred.kind = predicate_text
+		default:
+			return pred, p.unexpected(tk, "a position, last(), text() or an attribute")
+		}
+
+		err = p.expect(token_lparen)
+		if err == nil {
+			err = p.expect(token_rparen)
+		}
+
+		if err != nil || pred.kind == predicate_last {
+			return pred, err
+		}
+	default:
+		return pred, p.unexpected(tk, "a position, last(), text() or an attribute")
+	}
+
+	op := p.next()
+
+	switch op.kind {
+	case token_eq:
+	case token_neq:
+		pred.negate = true
+	default:
+		return pred, p.unexpected(op, `"=" or "!="`)
+	}
+
+	value := p.next()
+	if value.kind != token_string {
+		return pred, p.unexpected(value, "a quoted string")
+	}
+
+	pred.value = value.text
+
+	return pred, nil
+}
@@ -0,0 +1,77 @@
+package tree_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// node_data returns the label of a node.
+func node_data(node *root.StringNode) string {
+	return node.Data
+}
+
+func TestQuerySelect(t *testing.T) {
+	tr := must_parse(t, "Module\n├── Func\n│   ├── Call\n│   │   └── Ident\n│   └── Call\n└── Call\n    └── Ident")
+
+	qc := tree.NewQueryCompiler[*root.StringNode]()
+	qc.SetNameFunc(node_data)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"/Module//Call", []string{"Call", "Call", "Call"}},
+		{"//Call/Ident", []string{"Ident", "Ident"}},
+		{"/Module/Func/Call[2]", []string{"Call"}},
+		{"//Ident/..", []string{"Call", "Call"}},
+		{"//Ident/ancestor::Func", []string{"Func"}},
+		{"/Module/*[last()]", []string{"Call"}},
+		{"//Nope", nil},
+	}
+
+	for _, test := range tests {
+		q, err := qc.Compile(test.query)
+		if err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		}
+
+		var got []string
+
+		for node := range q.Select(tr) {
+			got = append(got, node.Data)
+		}
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestQuerySyntaxErrors(t *testing.T) {
+	tests := []struct {
+		text   string
+		reason string
+	}{
+		{"Call]", `expected "/" or end of input, got "]"`},
+		{"Call[", "got end of input"},
+		{"Call[@kind]", "kind"},
+		{"foo::Call", "foo"},
+	}
+
+	for _, test := range tests {
+		_, err := tree.CompileQuery[*root.StringNode](test.text)
+
+		var syntax *tree.ErrSyntax
+
+		if !errors.As(err, &syntax) {
+			t.Errorf("%q: got %v, want an *ErrSyntax", test.text, err)
+		} else if !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%q: got %v, want %q", test.text, err, test.reason)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package tree
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strconv"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// Rule is a rewrite rule: the subtrees that match the pattern are replaced with
+// the result of the rewrite function.
+type Rule[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	DeleteChild(child T) []T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	RemoveNode() []T
+	TreeNoder
+}] struct {
+	// Name is the name of the rule, used in error messages.
+	Name string
+
+	// Pattern is the pattern of the rule.
+	Pattern *Pattern[T]
+
+	// Rewrite returns the subtrees that replace the matched one: none deletes it,
+	// several are spliced among the siblings. It may reuse the captured subtrees.
+	// If it returns false or an error, the rule does not apply and the tree must be
+	// unchanged.
+	Rewrite func(m *Match[T]) ([]T, bool, error)
+}
+
+// NewTemplateRule creates a rule whose rewrite function is a template.
+//
+// Parameters:
+//   - name: The name of the rule.
+//   - pattern: The pattern of the rule.
+//   - template: The template that builds the replacement.
+//
+// Returns:
+//   - Rule[T]: The new rule.
+//   - error: An error if pattern or template is nil, or if the template uses a
+//     variable that the pattern does not capture.
+func NewTemplateRule[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	DeleteChild(child T) []T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	RemoveNode() []T
+	TreeNoder
+}](name string, pattern *Pattern[T], template *Template[T]) (Rule[T], error) {
+	if pattern == nil {
+		return Rule[T]{}, gcers.NewErrNilParameter("pattern")
+	} else if template == nil {
+		return Rule[T]{}, gcers.NewErrNilParameter("template")
+	}
+
+	for v, is_seq := range template.vars {
+		seq, ok := pattern.vars[v]
+		if !ok || seq != is_seq {
+			if is_seq {
+				v += "..."
+			}
+
+			return Rule[T]{}, gcers.NewErrInvalidParameter("template uses $" + v + " which the pattern does not capture")
+		}
+	}
+
+	rule := Rule[T]{
+		Name:    name,
+		Pattern: pattern,
+		Rewrite: func(m *Match[T]) ([]T, bool, error) {
+			nodes, err := template.Instantiate(m)
+			return nodes, err == nil, err
+		},
+	}
+
+	return rule, nil
+}
+
+// RewriteStrategy is the order in which a Rewriter visits the nodes.
+type RewriteStrategy int
+
+const (
+	// BottomUp visits the children before their parent; so, the subtrees are
+	// simplified before the nodes that contain them.
+	BottomUp RewriteStrategy = iota
+
+	// TopDown visits the parent before its children; a rewritten node is visited
+	// again before its children.
+	TopDown
+)
+
+// Rewriter applies a set of rules to a tree until none applies.
+type Rewriter[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	DeleteChild(child T) []T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+	RemoveNode() []T
+	TreeNoder
+}] struct {
+	// Rules are the rules, tried in order at every node. The first that applies
+	// wins.
+	Rules []Rule[T]
+
+	// Strategy is the order in which the nodes are visited.
+	Strategy RewriteStrategy
+
+	// MaxSteps is the maximum number of rewrites. Zero or less means no limit,
+	// which never ends if the rules do not terminate.
+	MaxSteps int
+}
+
+// Rewrite applies the rules to the tree until none applies (i.e., a fixpoint is
+// reached). The cached leaves and size of the tree are kept up to date.
+//
+// Parameters:
+//   - tree: The tree to rewrite.
+//
+// Returns:
+//   - int: The number of rewrites applied.
+//   - error: An error if tree, a pattern or a rewrite function is nil, if a rule
+//     fails or deletes the root, or an *ErrLimitReached of kind LimitSteps if
+//     the step limit is hit.
+//
+// The nodes are visited in passes, in the order of the strategy, until a pass
+// rewrites nothing. On error, the tree stays consistent with the rewrites that
+// were applied so far.
+func (rw Rewriter[T]) Rewrite(tree *Tree[T]) (int, error) {
+	if tree == nil {
+		return 0, gcers.NewErrNilParameter("tree")
+	}
+
+	for i, rule := range rw.Rules {
+		if rule.Pattern == nil {
+			return 0, gcers.NewErrNilParameter("Rules[" + strconv.Itoa(i) + "].Pattern")
+		} else if rule.Rewrite == nil {
+			return 0, gcers.NewErrNilParameter("Rules[" + strconv.Itoa(i) + "].Rewrite")
+		}
+	}
+
+	var steps int
+
+	for {
+		var count int
+		var err error
+
+		if rw.Strategy == TopDown {
+			count, err = rw.top_down(tree, steps)
+		} else {
+			count, err = rw.bottom_up(tree, steps)
+		}
+
+		steps += count
+
+		if err != nil || count == 0 {
+			return steps, err
+		}
+	}
+}
+
+// bottom_up is a helper method that runs a bottom-up pass.
+//
+// Parameters:
+//   - tree: The tree to rewrite.
+//   - steps: The number of rewrites applied before the pass.
+//
+// Returns:
+//   - int: The number of rewrites applied by the pass.
+//   - error: An error if a rewrite fails or the step limit is hit.
+func (rw Rewriter[T]) bottom_up(tree *Tree[T], steps int) (int, error) {
+	var count int
+
+	// The nodes after a node in post-order are its ancestors and the subtrees
+	// that follow it; so, they are not touched when the node is rewritten.
+	for _, node := range slices.Collect(tree.PostOrder()) {
+		_, ok, err := rw.apply(tree, node, steps+count)
+		if err != nil {
+			return count, err
+		}
+
+		if ok {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// top_down is a helper method that runs a top-down pass.
+//
+// Parameters:
+//   - tree: The tree to rewrite.
+//   - steps: The number of rewrites applied before the pass.
+//
+// Returns:
+//   - int: The number of rewrites applied by the pass.
+//   - error: An error if a rewrite fails or the step limit is hit.
+func (rw Rewriter[T]) top_down(tree *Tree[T], steps int) (int, error) {
+	var count int
+
+	stack := []T{tree.root}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		nodes, ok, err := rw.apply(tree, top, steps+count)
+		if err != nil {
+			return count, err
+		}
+
+		if ok {
+			count++
+
+			for i := len(nodes) - 1; i >= 0; i-- {
+				stack = append(stack, nodes[i])
+			}
+
+			continue
+		}
+
+		for child := range top.BackwardChild() {
+			stack = append(stack, child)
+		}
+	}
+
+	return count, nil
+}
+
+// apply is a helper method that applies the first rule that matches a node.
+//
+// Parameters:
+//   - tree: The tree the node is part of.
+//   - node: The node to rewrite.
+//   - steps: The number of rewrites applied so far.
+//
+// Returns:
+//   - []T: The subtrees that replaced the node.
+//   - bool: True if a rule applied.
+//   - error: An error if the rule fails or the step limit is hit.
+func (rw Rewriter[T]) apply(tree *Tree[T], node T, steps int) ([]T, bool, error) {
+	for _, rule := range rw.Rules {
+		m, ok := rule.Pattern.Match(node)
+		if !ok {
+			continue
+		}
+
+		if rw.MaxSteps > 0 && steps >= rw.MaxSteps {
+			return nil, false, NewErrLimitReached(LimitSteps, steps, nil)
+		}
+
+		// The siblings and the leaves are read before the rewrite function relinks
+		// the captured subtrees.
+		old_leaves, old_size, err := leaves_and_size_safe(node)
+		if err != nil {
+			return nil, false, err
+		}
+
+		parent, has_parent := node.GetParent()
+
+		var siblings []T
+		var children []T
+
+		if has_parent {
+			for child := range parent.Child() {
+				siblings = append(siblings, child)
+			}
+		}
+
+		for child := range node.Child() {
+			children = append(children, child)
+		}
+
+		nodes, ok, err := rule.Rewrite(m)
+		if err != nil {
+			return nil, false, gcers.NewErrAt("rule "+strconv.Quote(rule.Name), err)
+		} else if !ok {
+			continue
+		}
+
+		if !has_parent && len(nodes) != 1 {
+			return nil, false, gcers.NewErrAt("rule "+strconv.Quote(rule.Name), errors.New("the root must be replaced with exactly one node"))
+		}
+
+		var leaves []T
+		var size int
+
+		switch {
+		case slices.Equal(nodes, children) && len(nodes) > 0:
+			// The node is unwrapped: its children take its place.
+			node.RemoveNode()
+		case !has_parent:
+			detach_from_parent(nodes[0])
+		case len(nodes) == 0:
+			parent.DeleteChild(node)
+
+			if parent.IsLeaf() {
+				leaves = []T{parent}
+			}
+		default:
+			idx := slices.Index(siblings, node)
+			parent.LinkChildren(slices.Concat(siblings[:idx], nodes, siblings[idx+1:]))
+		}
+
+		if !has_parent {
+			tree.root = nodes[0]
+		}
+
+		for _, n := range nodes {
+			l, s, err := leaves_and_size_safe(n)
+			if err != nil {
+				return nil, false, err
+			}
+
+			leaves = append(leaves, l...)
+			size += s
+		}
+
+		tree.splice_leaves(old_leaves, leaves, nil)
+		tree.size += size - old_size
+
+		return nodes, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// detach_from_parent is a helper function that unlinks a node from its parent, if
+// any, while keeping its subtree.
+//
+// Parameters:
+//   - node: The node to detach.
+func detach_from_parent[T interface {
+	DeleteChild(child T) []T
+	GetParent() (T, bool)
+	LinkChildren(children []T)
+}](node T) {
+	parent, ok := node.GetParent()
+	if !ok {
+		return
+	}
+
+	children := parent.DeleteChild(node)
+	node.LinkChildren(children)
+}
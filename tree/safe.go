@@ -0,0 +1,338 @@
+package tree
+
+import (
+	"iter"
+)
+
+// visited is a set of nodes that have already been reached during a traversal.
+type visited[T TreeNoder] map[T]struct{}
+
+// mark marks the node as visited. Since only the visited nodes are tracked, a
+// node that is shared by two parents is reported just like a cycle.
+//
+// Parameters:
+//   - node: The node to mark.
+//
+// Returns:
+//   - error: An error of type *ErrCycle if the node was already visited.
+func (v visited[T]) mark(node T) error {
+	_, ok := v[node]
+	if ok {
+		return NewErrCycle(node)
+	}
+
+	v[node] = struct{}{}
+
+	return nil
+}
+
+// DFSSafe works like DFS but it keeps track of the visited nodes so that it
+// terminates even if the tree contains a cycle. A shared node is reported as a
+// cycle too; see ErrCycle.
+//
+// Returns:
+//   - iter.Seq2[T, error]: The traversal sequence. If a cycle is found, a zero node and
+//     an error of type *ErrCycle are yielded last.
+func (t *Tree[T]) DFSSafe() iter.Seq2[T, error] {
+	if t == nil {
+		return func(yield func(T, error) bool) {}
+	}
+
+	fn := func(yield func(T, error) bool) {
+		seen := make(visited[T])
+
+		stack := []T{t.root}
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			err := seen.mark(top)
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+
+			if !yield(top, nil) {
+				return
+			}
+
+			for child := range top.BackwardChild() {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	return fn
+}
+
+// BFSSafe works like BFS but it keeps track of the visited nodes so that it
+// terminates even if the tree contains a cycle. A shared node is reported as a
+// cycle too; see ErrCycle.
+//
+// Returns:
+//   - iter.Seq2[T, error]: The traversal sequence. If a cycle is found, a zero node and
+//     an error of type *ErrCycle are yielded last.
+func (t *Tree[T]) BFSSafe() iter.Seq2[T, error] {
+	if t == nil {
+		return func(yield func(T, error) bool) {}
+	}
+
+	fn := func(yield func(T, error) bool) {
+		seen := make(visited[T])
+
+		queue := []T{t.root}
+
+		for len(queue) > 0 {
+			first := queue[0]
+			queue = queue[1:]
+
+			err := seen.mark(first)
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+
+			if !yield(first, nil) {
+				return
+			}
+
+			for child := range first.Child() {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return fn
+}
+
+// NewTreeSafe works like NewTree but it fails if the given root is part of a cycle
+// or if a node is reachable through more than one parent.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - *Tree[T]: A pointer to the newly created tree. Nil if an error occurs.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func NewTreeSafe[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](root T) (*Tree[T], error) {
+	leaves, size, err := leaves_and_size_safe(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tree[T]{
+		root:   root,
+		leaves: leaves,
+		size:   size,
+	}, nil
+}
+
+// GetNodeLeavesSafe works like GetNodeLeaves but it fails if a cycle is found.
+//
+// Parameters:
+//   - node: The node to get the leaves of.
+//
+// Returns:
+//   - []T: The leaves of the node. Nil if an error occurs.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func GetNodeLeavesSafe[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](node T) ([]T, error) {
+	leaves, _, err := leaves_and_size_safe(node)
+	return leaves, err
+}
+
+// GetNodeSizeSafe works like GetNodeSize but it fails if a cycle is found.
+//
+// Parameters:
+//   - node: The node to get the size of.
+//
+// Returns:
+//   - int: The size of the node. 0 if an error occurs.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func GetNodeSizeSafe[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](node T) (int, error) {
+	_, size, err := leaves_and_size_safe(node)
+	return size, err
+}
+
+// leaves_and_size_safe is a helper function that computes the leaves and the size
+// of the given node while keeping track of the visited nodes.
+//
+// Parameters:
+//   - node: The node to compute the leaves and the size of.
+//
+// Returns:
+//   - []T: The leaves of the node in DFS order.
+//   - int: The size of the node.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func leaves_and_size_safe[T interface {
+	Child() iter.Seq[T]
+	TreeNoder
+}](node T) ([]T, int, error) {
+	seen := make(visited[T])
+
+	var leaves []T
+	var size int
+
+	stack := []T{node}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		err := seen.mark(top)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		size++
+
+		if top.IsLeaf() {
+			leaves = append(leaves, top)
+			continue
+		}
+
+		var children []T
+
+		for child := range top.Child() {
+			children = append(children, child)
+		}
+
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+
+	return leaves, size, nil
+}
+
+// DeepCopySafe works like DeepCopy but it does not use recursion and it fails if
+// a cycle is found.
+//
+// Parameters:
+//   - node: The node to copy.
+//
+// Returns:
+//   - T: The copied node. A zero value if an error occurs.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func DeepCopySafe[T interface {
+	Child() iter.Seq[T]
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](node T) (T, error) {
+	type StackElement struct {
+		original T
+		copy     T
+	}
+
+	seen := make(visited[T])
+
+	_ = seen.mark(node)
+
+	root := node.Copy()
+
+	stack := []StackElement{{original: node, copy: root}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var children []T
+
+		for child := range top.original.Child() {
+			err := seen.mark(child)
+			if err != nil {
+				return *new(T), err
+			}
+
+			child_copy := child.Copy()
+			children = append(children, child_copy)
+
+			stack = append(stack, StackElement{original: child, copy: child_copy})
+		}
+
+		top.copy.LinkChildren(children)
+	}
+
+	return root, nil
+}
+
+// CleanupSafe works like Cleanup but it never cleans up the same node twice.
+//
+// Parameters:
+//   - node: The node to delete the children of.
+//
+// Returns:
+//   - error: An error of type *ErrCycle if a cycle was found. Even then, every
+//     reachable node is cleaned up.
+func CleanupSafe[T interface {
+	Cleanup() []T
+	TreeNoder
+}](node T) error {
+	seen := make(visited[T])
+
+	_ = seen.mark(node)
+
+	queue := node.Cleanup()
+
+	var reason error
+
+	for len(queue) > 0 {
+		first := queue[0]
+		queue = queue[1:]
+
+		err := seen.mark(first)
+		if err != nil {
+			if reason == nil {
+				reason = err
+			}
+
+			continue
+		}
+
+		queue = append(queue, first.Cleanup()...)
+	}
+
+	return reason
+}
+
+// DeepCopySafe works like DeepCopy but it fails if a cycle is found.
+//
+// Returns:
+//   - *Tree: A copy of the tree. Nil if an error occurs.
+//   - error: An error of type *ErrCycle if a cycle is found.
+func (t *Tree[T]) DeepCopySafe() (*Tree[T], error) {
+	root_copy, err := DeepCopySafe(t.root)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTreeSafe(root_copy)
+}
+
+// CleanupSafe works like Cleanup but it never cleans up the same node twice.
+//
+// Returns:
+//   - error: An error of type *ErrCycle if a cycle was found. Even then, every
+//     reachable node is cleaned up.
+func (t *Tree[T]) CleanupSafe() error {
+	err := CleanupSafe(t.root)
+
+	t.size = 1
+	t.leaves = []T{t.root}
+
+	return err
+}
@@ -0,0 +1,167 @@
+package tree_test
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// safe_labels describes a safe traversal: the labels of the yielded nodes followed
+// by the node of the *tree.ErrCycle, if any.
+func safe_labels(t *testing.T, seq iter.Seq2[*root.StringNode, error]) string {
+	t.Helper()
+
+	var names []string
+
+	for node, err := range seq {
+		if err == nil {
+			names = append(names, node.Data)
+			continue
+		}
+
+		var cycle *tree.ErrCycle
+
+		if !errors.As(err, &cycle) {
+			t.Fatalf("got %v, want an *ErrCycle", err)
+		}
+
+		names = append(names, "!"+cycle.Node.(*root.StringNode).Data)
+	}
+
+	return join_labels(names)
+}
+
+// link_back makes the target the only child of the node without going through
+// AddChild, so that cycles and shared nodes can be built.
+func link_back(node, target *root.StringNode) {
+	node.FirstChild = target
+	node.LastChild = target
+}
+
+func TestSafeTraversal(t *testing.T) {
+	tr := sample_tree()
+
+	if got := safe_labels(t, tr.DFSSafe()); got != "a b d e c f" {
+		t.Errorf("DFSSafe: got %q", got)
+	}
+
+	if got := safe_labels(t, tr.BFSSafe()); got != "a b c d e f" {
+		t.Errorf("BFSSafe: got %q", got)
+	}
+
+	copied, err := tr.DeepCopySafe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !same_tree(copied.Root(), tr.Root()) || copied.Root() == tr.Root() {
+		t.Error("DeepCopySafe did not copy the tree")
+	}
+
+	if copied.Size() != 6 || labels(slices.Values(copied.Leaves())) != "d e f" {
+		t.Errorf("got size %d and leaves %q", copied.Size(), labels(slices.Values(copied.Leaves())))
+	}
+}
+
+func TestSafeCycle(t *testing.T) {
+	c := make_node("c")
+	a := make_node("a", make_node("b", c), make_node("d"))
+
+	// The tree is built before the cycle is closed as NewTree does not terminate
+	// on a cycle.
+	tr := tree.NewTree(a)
+	link_back(c, a)
+
+	if got := safe_labels(t, tr.DFSSafe()); got != "a b c !a" {
+		t.Errorf("DFSSafe: got %q", got)
+	}
+
+	if got := safe_labels(t, tr.BFSSafe()); got != "a b d c !a" {
+		t.Errorf("BFSSafe: got %q", got)
+	}
+
+	_, err := tree.NewTreeSafe(a)
+	if !strings.Contains(error_chain(err), `cycle detected at node "StringNode[a]"`) {
+		t.Errorf("NewTreeSafe: got %v, want a cycle at a", err)
+	}
+
+	_, err = tree.GetNodeSizeSafe(a)
+	if err == nil {
+		t.Error("GetNodeSizeSafe: want a cycle")
+	}
+
+	_, err = tree.DeepCopySafe(a)
+	if err == nil {
+		t.Error("DeepCopySafe: want a cycle")
+	}
+
+	err = tree.CleanupSafe(a)
+	if err == nil {
+		t.Error("CleanupSafe: want a cycle")
+	}
+}
+
+func TestSafeSharedNode(t *testing.T) {
+	// A node shared by two parents is not a cycle but it is reported as one.
+	shared := make_node("s")
+	b, c := make_node("b"), make_node("c")
+	tr := tree.NewTree(make_node("a", b, c))
+
+	link_back(b, shared)
+	link_back(c, shared)
+
+	if got := safe_labels(t, tr.DFSSafe()); got != "a b s c !s" {
+		t.Errorf("DFSSafe: got %q", got)
+	}
+
+	_, err := tree.GetNodeLeavesSafe(tr.Root())
+	if err == nil {
+		t.Error("GetNodeLeavesSafe: want an error")
+	}
+}
+
+func TestSafeLinks(t *testing.T) {
+	c := make_node("c")
+	b := make_node("b", c)
+	a := make_node("a", b)
+
+	err := c.AddChildSafe(a)
+	if err == nil {
+		t.Fatal("AddChildSafe: want a cycle")
+	}
+
+	err = c.AddChildSafe(c)
+	if err == nil {
+		t.Fatal("AddChildSafe: want a cycle on the node itself")
+	}
+
+	d := make_node("d")
+
+	err = c.LinkChildrenSafe([]*root.StringNode{d, d})
+	if err == nil {
+		t.Fatal("LinkChildrenSafe: want an error on a repeated child")
+	}
+
+	if !c.IsLeaf() {
+		t.Error("a refused link modified the node")
+	}
+
+	err = c.AddChildSafe(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := tree.GetNodeSizeSafe(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size != 4 {
+		t.Errorf("got size %d, want 4", size)
+	}
+}
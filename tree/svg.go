@@ -0,0 +1,239 @@
+package tree
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"math"
+	"strconv"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// SVGShape is the shape of a node in an SVG picture.
+type SVGShape int
+
+const (
+	// SVGRoundedRect is a rectangle with rounded corners.
+	SVGRoundedRect SVGShape = iota
+
+	// SVGRect is a rectangle.
+	SVGRect
+
+	// SVGEllipse is an ellipse.
+	SVGEllipse
+)
+
+// SVGStyle is the style of a node or an edge. Empty fields keep the default style.
+type SVGStyle struct {
+	// Fill is the fill color (e.g., "#fff" or "lightblue"). Ignored for edges.
+	Fill string
+
+	// Stroke is the color of the outline or of the edge.
+	Stroke string
+
+	// StrokeWidth is the width of the outline or of the edge.
+	StrokeWidth float64
+
+	// TextColor is the color of the label. Ignored for edges.
+	TextColor string
+
+	// Shape is the shape of the node. Ignored for edges.
+	Shape SVGShape
+
+	// Class is the class attribute of the element, for external style sheets.
+	Class string
+}
+
+// SVGOptions are the options of the SVG writer.
+type SVGOptions[T TreeNoder] struct {
+	// Label returns the label of a node. Defaults to the String of the node.
+	Label func(node T) string
+
+	// NodeStyle returns the style of a node. Optional.
+	NodeStyle func(node T) SVGStyle
+
+	// EdgeStyle returns the style of the edge from parent to child. Optional.
+	EdgeStyle func(parent, child T) SVGStyle
+
+	// Margin is the space around the picture. Zero means 8.
+	Margin float64
+
+	// FontSize is the size of the labels. Zero means 12.
+	FontSize float64
+}
+
+// svg_num is a helper function that formats a number for SVG.
+//
+// Parameters:
+//   - v: The number.
+//
+// Returns:
+//   - string: The number with at most two decimals.
+func svg_num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// svg_attr is a helper function that formats an attribute, if its value is not
+// empty.
+//
+// Parameters:
+//   - name: The name of the attribute.
+//   - value: The value of the attribute.
+//
+// Returns:
+//   - string: The attribute, with a leading space. Empty if value is empty.
+func svg_attr(name, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return " " + name + "=\"" + html.EscapeString(value) + "\""
+}
+
+// WriteSVG writes a tidy layout as a self-contained SVG picture.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - layout: The layout to draw, as computed by Tidy.
+//   - opts: The options of the writer.
+//
+// Returns:
+//   - error: An error if w or layout is nil, or the first write error.
+//
+// Edges are drawn from the bottom of the parent to the top of the child, below
+// the nodes. Labels are centered in their nodes.
+func WriteSVG[T TreeNoder](w io.Writer, layout *TidyLayout[T], opts SVGOptions[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if layout == nil {
+		return gcers.NewErrNilParameter("layout")
+	}
+
+	margin := opts.Margin
+	if margin == 0 {
+		margin = 8
+	}
+
+	font_size := opts.FontSize
+	if font_size == 0 {
+		font_size = 12
+	}
+
+	label_fn := opts.Label
+	if label_fn == nil {
+		label_fn = T.String
+	}
+
+	width := layout.Width + 2*margin
+	height := layout.Height + 2*margin
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	tw.write(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="`, svg_num(width),
+		`" height="`, svg_num(height),
+		`" viewBox="0 0 `, svg_num(width), " ", svg_num(height), "\">\n",
+	)
+
+	tw.write(`<g transform="translate(`, svg_num(margin), ",", svg_num(margin), `)">`, "\n")
+	tw.write(`<g fill="none" stroke="#555" stroke-width="1.5">`, "\n")
+
+	for _, n := range layout.Nodes {
+		if n.Parent < 0 {
+			continue
+		}
+
+		p := layout.Nodes[n.Parent]
+
+		var style SVGStyle
+
+		if opts.EdgeStyle != nil {
+			style = opts.EdgeStyle(p.Node, n.Node)
+		}
+
+		x1, y1 := p.X, p.Y+p.Height/2
+		x2, y2 := n.X, n.Y-n.Height/2
+		ym := (y1 + y2) / 2
+
+		tw.write(
+			`<path d="M`, svg_num(x1), ",", svg_num(y1),
+			" C", svg_num(x1), ",", svg_num(ym),
+			" ", svg_num(x2), ",", svg_num(ym),
+			" ", svg_num(x2), ",", svg_num(y2), `"`,
+			svg_attr("stroke", style.Stroke),
+		)
+
+		if style.StrokeWidth > 0 {
+			tw.write(svg_attr("stroke-width", svg_num(style.StrokeWidth)))
+		}
+
+		tw.write(svg_attr("class", style.Class), "/>\n")
+	}
+
+	tw.write("</g>\n")
+	tw.write(
+		`<g font-family="monospace" font-size="`, svg_num(font_size),
+		`" text-anchor="middle" dominant-baseline="central">`, "\n",
+	)
+
+	for _, n := range layout.Nodes {
+		var style SVGStyle
+
+		if opts.NodeStyle != nil {
+			style = opts.NodeStyle(n.Node)
+		}
+
+		fill := style.Fill
+		if fill == "" {
+			fill = "#fff"
+		}
+
+		stroke := style.Stroke
+		if stroke == "" {
+			stroke = "#333"
+		}
+
+		stroke_width := style.StrokeWidth
+		if stroke_width == 0 {
+			stroke_width = 1
+		}
+
+		tw.write("<g", svg_attr("class", style.Class), ">")
+
+		switch style.Shape {
+		case SVGEllipse:
+			tw.write(
+				`<ellipse cx="`, svg_num(n.X), `" cy="`, svg_num(n.Y),
+				`" rx="`, svg_num(n.Width/2), `" ry="`, svg_num(n.Height/2), `"`,
+			)
+		default:
+			tw.write(
+				`<rect x="`, svg_num(n.X-n.Width/2), `" y="`, svg_num(n.Y-n.Height/2),
+				`" width="`, svg_num(n.Width), `" height="`, svg_num(n.Height), `"`,
+			)
+
+			if style.Shape == SVGRoundedRect {
+				tw.write(` rx="6"`)
+			}
+		}
+
+		tw.write(
+			svg_attr("fill", fill), svg_attr("stroke", stroke),
+			svg_attr("stroke-width", svg_num(stroke_width)), "/>",
+		)
+
+		tw.write(`<text x="`, svg_num(n.X), `" y="`, svg_num(n.Y), `"`, svg_attr("fill", style.TextColor), ">")
+		tw.write(html.EscapeString(label_fn(n.Node)), "</text></g>\n")
+	}
+
+	tw.write("</g>\n</g>\n</svg>\n")
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
@@ -0,0 +1,72 @@
+package tree_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestWriteSVG(t *testing.T) {
+	tr := sample_tree()
+	tr.Root().FirstChild.Data = "<b&>"
+
+	layout, err := tree.Tidy(tr, tree.TidyOptions[*root.StringNode]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	err = tree.WriteSVG(&buf, layout, tree.SVGOptions[*root.StringNode]{
+		Label: func(node *root.StringNode) string {
+			return node.Data
+		},
+		NodeStyle: func(node *root.StringNode) tree.SVGStyle {
+			if node.Data != "c" {
+				return tree.SVGStyle{}
+			}
+
+			return tree.SVGStyle{Shape: tree.SVGEllipse, Fill: "red", Class: "hot"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	checks := []struct {
+		what  string
+		text  string
+		count int
+	}{
+		{"header", `<svg xmlns="http://www.w3.org/2000/svg" width="416" height="180" viewBox="0 0 416 180">`, 1},
+		{"edges", "<path ", 5},
+		{"nodes", "<rect ", 5},
+		{"styled node", `<g class="hot"><ellipse cx="340" cy="82" rx="60" ry="14" fill="red"`, 1},
+		{"escaped label", `<text x="128" y="82">&lt;b&amp;&gt;</text>`, 1},
+	}
+
+	for _, c := range checks {
+		if n := strings.Count(got, c.text); n != c.count {
+			t.Errorf("%s: got %d occurrences of %q, want %d", c.what, n, c.text, c.count)
+		}
+	}
+
+	if !strings.HasSuffix(got, "</svg>\n") {
+		t.Errorf("got %q, want it to end the picture", got[len(got)-20:])
+	}
+
+	err = tree.WriteSVG(failing_writer{}, layout, tree.SVGOptions[*root.StringNode]{})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("failing writer: got %v, want the write error", err)
+	}
+
+	err = tree.WriteSVG[*root.StringNode](&buf, nil, tree.SVGOptions[*root.StringNode]{})
+	if err == nil {
+		t.Error("nil layout: want an error")
+	}
+}
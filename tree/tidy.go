@@ -0,0 +1,424 @@
+package tree
+
+import (
+	"iter"
+	"unicode/utf8"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// TidyOptions are the options of the tidy tree layout. All distances are in the
+// same, arbitrary, unit; SVG renders them as pixels.
+type TidyOptions[T TreeNoder] struct {
+	// NodeSize returns the width and the height of a node. Defaults to a box that
+	// fits the String of the node: 8 units per rune plus 16, by 28.
+	NodeSize func(node T) (float64, float64)
+
+	// SiblingSeparation is the horizontal gap between two siblings. Zero means 16.
+	SiblingSeparation float64
+
+	// SubtreeSeparation is the horizontal gap between two cousins; that is, between
+	// neighbouring subtrees. Zero means 24.
+	SubtreeSeparation float64
+
+	// LevelSeparation is the vertical gap between two levels. Zero means 40.
+	LevelSeparation float64
+}
+
+// NodeLayout is the position of a node in a TidyLayout.
+type NodeLayout[T TreeNoder] struct {
+	// Node is the node.
+	Node T
+
+	// Parent is the index, in TidyLayout.Nodes, of the parent of the node. -1 for
+	// the root.
+	Parent int
+
+	// Depth is the depth of the node.
+	Depth int
+
+	// X and Y are the coordinates of the center of the node.
+	X, Y float64
+
+	// Width and Height are the size of the node.
+	Width, Height float64
+}
+
+// TidyLayout is the result of the tidy tree layout.
+type TidyLayout[T TreeNoder] struct {
+	// Nodes are the positions of the nodes, in DFS order. The first one is the root.
+	Nodes []NodeLayout[T]
+
+	// Width and Height are the size of the bounding box of the layout, whose top-left
+	// corner is at (0, 0).
+	Width, Height float64
+}
+
+// tidy_node is the state of a node during the tidy tree layout.
+type tidy_node struct {
+	// parent is the parent of the node. Nil for the root.
+	parent *tidy_node
+
+	// children are the children of the node.
+	children []*tidy_node
+
+	// number is the index of the node among its siblings.
+	number int
+
+	// width is the width of the node.
+	width float64
+
+	// prelim is the preliminary x coordinate, relative to the parent.
+	prelim float64
+
+	// mod is the modifier applied to the subtree of the node.
+	mod float64
+
+	// midpoint is the middle of the first and the last child.
+	midpoint float64
+
+	// shift and change are the pending shifts of the subtree of the node.
+	shift, change float64
+
+	// thread is the next node of the contour, if the node is a leaf.
+	thread *tidy_node
+
+	// ancestor is the greatest uncommon ancestor used by apportion.
+	ancestor *tidy_node
+}
+
+// left_sibling returns the sibling before the node, if any.
+func (v *tidy_node) left_sibling() *tidy_node {
+	if v.parent == nil || v.number == 0 {
+		return nil
+	}
+
+	return v.parent.children[v.number-1]
+}
+
+// next_left returns the next node of the left contour, if any.
+func (v *tidy_node) next_left() *tidy_node {
+	if len(v.children) > 0 {
+		return v.children[0]
+	}
+
+	return v.thread
+}
+
+// next_right returns the next node of the right contour, if any.
+func (v *tidy_node) next_right() *tidy_node {
+	if len(v.children) > 0 {
+		return v.children[len(v.children)-1]
+	}
+
+	return v.thread
+}
+
+// tidy_walker holds the separations of the tidy tree layout.
+type tidy_walker struct {
+	// sibling is the separation between siblings.
+	sibling float64
+
+	// subtree is the separation between neighbouring subtrees.
+	subtree float64
+}
+
+// distance returns the minimum distance between the centers of two neighbouring
+// nodes of the same level.
+func (tw tidy_walker) distance(left, right *tidy_node) float64 {
+	sep := tw.subtree
+	if left.parent == right.parent {
+		sep = tw.sibling
+	}
+
+	return (left.width+right.width)/2 + sep
+}
+
+// place computes the preliminary coordinate of a node once its left sibling is
+// placed.
+func (tw tidy_walker) place(v *tidy_node) {
+	w := v.left_sibling()
+
+	if len(v.children) == 0 {
+		if w != nil {
+			v.prelim = w.prelim + tw.distance(w, v)
+		}
+
+		return
+	}
+
+	if w != nil {
+		v.prelim = w.prelim + tw.distance(w, v)
+		v.mod = v.prelim - v.midpoint
+	} else {
+		v.prelim = v.midpoint
+	}
+}
+
+// move_subtree shifts the subtree of wr and spreads the shift over the subtrees
+// between wl and wr.
+func move_subtree(wl, wr *tidy_node, shift float64) {
+	subtrees := float64(wr.number - wl.number)
+
+	wr.change -= shift / subtrees
+	wr.shift += shift
+	wl.change += shift / subtrees
+	wr.prelim += shift
+	wr.mod += shift
+}
+
+// execute_shifts applies the pending shifts to the children of the node.
+func execute_shifts(v *tidy_node) {
+	var shift, change float64
+
+	for i := len(v.children) - 1; i >= 0; i-- {
+		w := v.children[i]
+
+		w.prelim += shift
+		w.mod += shift
+
+		change += w.change
+		shift += w.shift + change
+	}
+}
+
+// apportion pushes the subtree of v to the right of the subtrees of its left
+// siblings.
+func (tw tidy_walker) apportion(v, default_ancestor *tidy_node) *tidy_node {
+	w := v.left_sibling()
+	if w == nil {
+		return default_ancestor
+	}
+
+	vip, vop := v, v
+	vim, vom := w, v.parent.children[0]
+
+	sip, sop := vip.mod, vop.mod
+	sim, som := vim.mod, vom.mod
+
+	for vim.next_right() != nil && vip.next_left() != nil {
+		vim = vim.next_right()
+		vip = vip.next_left()
+		vom = vom.next_left()
+		vop = vop.next_right()
+
+		vop.ancestor = v
+
+		shift := (vim.prelim + sim) - (vip.prelim + sip) + tw.distance(vim, vip)
+		if shift > 0 {
+			anc := default_ancestor
+			if vim.ancestor.parent == v.parent {
+				anc = vim.ancestor
+			}
+
+			move_subtree(anc, v, shift)
+
+			sip += shift
+			sop += shift
+		}
+
+		sim += vim.mod
+		sip += vip.mod
+		som += vom.mod
+		sop += vop.mod
+	}
+
+	if vim.next_right() != nil && vop.next_right() == nil {
+		vop.thread = vim.next_right()
+		vop.mod += sim - sop
+	}
+
+	if vip.next_left() != nil && vom.next_left() == nil {
+		vom.thread = vip.next_left()
+		vom.mod += sip - som
+		default_ancestor = v
+	}
+
+	return default_ancestor
+}
+
+// Tidy computes a tidy layout of the tree, where parents are centered above their
+// children, identical subtrees are drawn identically and nodes never overlap.
+//
+// Parameters:
+//   - tree: The tree to lay out.
+//   - opts: The options of the layout.
+//
+// Returns:
+//   - *TidyLayout[T]: The layout. Never returns nil on success.
+//   - error: An error if tree is nil, or an *ErrCycle if a node is reached more
+//     than once.
+//
+// The layout is the linear-time variant of Walker's algorithm by Buchheim, Jünger
+// and Leipert, extended to nodes of different sizes. It does not use recursion.
+func Tidy[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](tree *Tree[T], opts TidyOptions[T]) (*TidyLayout[T], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	}
+
+	size_fn := opts.NodeSize
+	if size_fn == nil {
+		size_fn = func(node T) (float64, float64) {
+			return float64(8*utf8.RuneCountInString(node.String()) + 16), 28
+		}
+	}
+
+	tw := tidy_walker{
+		sibling: opts.SiblingSeparation,
+		subtree: opts.SubtreeSeparation,
+	}
+
+	if tw.sibling == 0 {
+		tw.sibling = 16
+	}
+
+	if tw.subtree == 0 {
+		tw.subtree = 24
+	}
+
+	level_sep := opts.LevelSeparation
+	if level_sep == 0 {
+		level_sep = 40
+	}
+
+	type StackElement struct {
+		node   T
+		elem   *tidy_node
+		parent int
+		depth  int
+	}
+
+	layout := &TidyLayout[T]{}
+
+	var order []*tidy_node
+	var heights []float64
+
+	seen := make(map[T]struct{})
+	stack := []StackElement{{node: tree.root, elem: &tidy_node{}, parent: -1}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		_, ok := seen[top.node]
+		if ok {
+			return nil, NewErrCycle(top.node)
+		}
+
+		seen[top.node] = struct{}{}
+
+		w, h := size_fn(top.node)
+
+		top.elem.width = w
+		top.elem.ancestor = top.elem
+
+		if top.depth == len(heights) {
+			heights = append(heights, 0)
+		}
+
+		heights[top.depth] = max(heights[top.depth], h)
+
+		idx := len(order)
+
+		order = append(order, top.elem)
+		layout.Nodes = append(layout.Nodes, NodeLayout[T]{
+			Node:   top.node,
+			Parent: top.parent,
+			Depth:  top.depth,
+			Width:  w,
+			Height: h,
+		})
+
+		for range top.node.Child() {
+			elem := &tidy_node{
+				parent: top.elem,
+				number: len(top.elem.children),
+			}
+
+			top.elem.children = append(top.elem.children, elem)
+		}
+
+		i := len(top.elem.children)
+		for child := range top.node.BackwardChild() {
+			i--
+
+			stack = append(stack, StackElement{
+				node:   child,
+				elem:   top.elem.children[i],
+				parent: idx,
+				depth:  top.depth + 1,
+			})
+		}
+	}
+
+	// First walk: in reverse DFS order, every subtree is done before its parent.
+	// The placement of a node depends on its left sibling, so children are placed
+	// by their parent, in order.
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+
+		if len(v.children) == 0 {
+			continue
+		}
+
+		default_ancestor := v.children[0]
+
+		for _, w := range v.children {
+			tw.place(w)
+			default_ancestor = tw.apportion(w, default_ancestor)
+		}
+
+		execute_shifts(v)
+
+		v.midpoint = (v.children[0].prelim + v.children[len(v.children)-1].prelim) / 2
+	}
+
+	tw.place(order[0])
+
+	// Second walk: the modifiers of the ancestors are summed in DFS order.
+	sums := make(map[*tidy_node]float64, len(order))
+
+	tops := make([]float64, len(heights))
+	for d := 1; d < len(heights); d++ {
+		tops[d] = tops[d-1] + heights[d-1] + level_sep
+	}
+
+	min_x := 0.0
+
+	for i, v := range order {
+		var m float64
+
+		if v.parent != nil {
+			m = sums[v.parent] + v.parent.mod
+		}
+
+		sums[v] = m
+
+		n := &layout.Nodes[i]
+		n.X = v.prelim + m
+		n.Y = tops[n.Depth] + heights[n.Depth]/2
+
+		if i == 0 || n.X-n.Width/2 < min_x {
+			min_x = n.X - n.Width/2
+		}
+	}
+
+	for i := range layout.Nodes {
+		n := &layout.Nodes[i]
+		n.X -= min_x
+
+		layout.Width = max(layout.Width, n.X+n.Width/2)
+	}
+
+	last := len(heights) - 1
+	layout.Height = tops[last] + heights[last]
+
+	return layout, nil
+}
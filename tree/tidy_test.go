@@ -0,0 +1,125 @@
+package tree_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// describe_layout describes the nodes of a layout as "<label>(<x>,<y>)".
+func describe_layout(layout *tree.TidyLayout[*root.StringNode]) string {
+	var parts []string
+
+	for _, n := range layout.Nodes {
+		parts = append(parts, fmt.Sprintf("%s(%g,%g)", n.Node.Data, n.X, n.Y))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestTidy(t *testing.T) {
+	layout, err := tree.Tidy(sample_tree(), tree.TidyOptions[*root.StringNode]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "a(234,14) b(128,82) d(60,150) e(196,150) c(340,82) f(340,150)"
+
+	if got := describe_layout(layout); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if layout.Width != 400 || layout.Height != 164 {
+		t.Errorf("got a %gx%g bounding box, want 400x164", layout.Width, layout.Height)
+	}
+}
+
+func TestTidyInvariants(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	opts := tree.TidyOptions[*root.StringNode]{
+		NodeSize: func(node *root.StringNode) (float64, float64) {
+			return float64(10 + 7*len(node.Data)), 20
+		},
+		SiblingSeparation: 5,
+		SubtreeSeparation: 9,
+	}
+
+	const eps = 1e-9
+
+	for i := 0; i < 200; i++ {
+		node := random_tree(r, 5, 26)
+
+		for j, n := range all_nodes(node) {
+			n.Data = strings.Repeat(n.Data, 1+j%3)
+		}
+
+		layout, err := tree.Tidy(tree.NewTree(node), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := make(map[int][]tree.NodeLayout[*root.StringNode])
+		children := make(map[int][]tree.NodeLayout[*root.StringNode])
+
+		for _, n := range layout.Nodes {
+			rows[n.Depth] = append(rows[n.Depth], n)
+
+			if n.Parent >= 0 {
+				children[n.Parent] = append(children[n.Parent], n)
+			}
+
+			if n.X-n.Width/2 < -eps || n.X+n.Width/2 > layout.Width+eps || n.Y+n.Height/2 > layout.Height+eps {
+				t.Fatalf("tree %d: %s is outside of the bounding box", i, n.Node.Data)
+			}
+		}
+
+		for parent, kids := range children {
+			p := layout.Nodes[parent]
+
+			center := (kids[0].X + kids[len(kids)-1].X) / 2
+			if math.Abs(p.X-center) > eps {
+				t.Fatalf("tree %d: %s is at %g, want it centered at %g", i, p.Node.Data, p.X, center)
+			}
+		}
+
+		for depth, row := range rows {
+			for k := 1; k < len(row); k++ {
+				a, b := row[k-1], row[k]
+
+				if b.Y != a.Y {
+					t.Fatalf("tree %d: depth %d is not aligned", i, depth)
+				}
+
+				if gap := (b.X - b.Width/2) - (a.X + a.Width/2); gap < opts.SiblingSeparation-eps {
+					t.Fatalf("tree %d: %s and %s are %g apart", i, a.Node.Data, b.Node.Data, gap)
+				}
+			}
+		}
+	}
+}
+
+func TestTidyErrors(t *testing.T) {
+	_, err := tree.Tidy[*root.StringNode](nil, tree.TidyOptions[*root.StringNode]{})
+	if err == nil {
+		t.Error("nil tree: want an error")
+	}
+
+	tr := sample_tree()
+	f := tr.Root().LastChild.FirstChild
+	f.FirstChild, f.LastChild = tr.Root(), tr.Root()
+
+	_, err = tree.Tidy(tr, tree.TidyOptions[*root.StringNode]{})
+
+	var cycle *tree.ErrCycle
+
+	if !errors.As(err, &cycle) {
+		t.Errorf("got %v, want an *ErrCycle", err)
+	}
+}
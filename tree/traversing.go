@@ -1,10 +1,41 @@
 package tree
 
 import (
+	"context"
 	"iter"
 	"slices"
 )
 
+// Action is the signal returned by the callbacks of a Traverser to control
+// the traversal.
+type Action int
+
+const (
+	// Continue continues the traversal normally.
+	Continue Action = iota
+
+	// SkipChildren does not visit the children of the current node. When returned
+	// by OnLeave, it has the same effect as Continue.
+	SkipChildren
+
+	// Stop ends the traversal as soon as possible. No error is returned.
+	Stop
+)
+
+// String implements the fmt.Stringer interface.
+func (a Action) String() string {
+	switch a {
+	case Continue:
+		return "continue"
+	case SkipChildren:
+		return "skip children"
+	case Stop:
+		return "stop"
+	default:
+		return "unknown action"
+	}
+}
+
 // Pair is a pair of a node and its info.
 type Pair[T interface {
 	Child() iter.Seq[T]
@@ -12,12 +43,12 @@ type Pair[T interface {
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}] struct {
+}, I any] struct {
 	// Node is the node of the pair.
 	Node T
 
 	// Info is the info of the pair.
-	Info any
+	Info I
 }
 
 // NewPair creates a new pair of a node and its info.
@@ -27,15 +58,15 @@ type Pair[T interface {
 //   - info: The info of the pair.
 //
 // Returns:
-//   - Pair[T]: The new pair.
+//   - Pair[T, I]: The new pair.
 func NewPair[T interface {
 	Child() iter.Seq[T]
 	BackwardChild() iter.Seq[T]
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}](node T, info any) Pair[T] {
-	return Pair[T]{
+}, I any](node T, info I) Pair[T, I] {
+	return Pair[T, I]{
 		Node: node,
 		Info: info,
 	}
@@ -48,26 +79,58 @@ type Traverser[T interface {
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}] struct {
+}, I any] struct {
 	// InitFn is the function that initializes the traversal info.
 	//
 	// Parameters:
 	//   - root: The root node of the tree.
 	//
 	// Returns:
-	//   - any: The initial traversal info.
-	InitFn func(root T) any
+	//   - I: The initial traversal info.
+	InitFn func(root T) I
 
-	// DoFn is the function that performs the traversal logic.
+	// OnEnter is the function that is called when a node is first visited.
 	//
 	// Parameters:
 	//   - node: The current node of the tree.
-	//   - info: The traversal info.
+	//   - info: The traversal info of the node.
 	//
 	// Returns:
-	//   - []Pair[T]: The next traversal info.
+	//   - []Pair[T, I]: The next nodes to visit together with their info.
+	//   - Action: The action to take. If SkipChildren, the returned pairs are ignored.
 	//   - error: The error that might occur during the traversal.
-	DoFn func(node T, info any) ([]Pair[T], error)
+	OnEnter func(node T, info I) ([]Pair[T, I], Action, error)
+
+	// OnLeave is the optional function that is called once the node is done. In a DFS,
+	// this happens after all of the node's descendants have been left; in a BFS, this
+	// happens right after OnEnter.
+	//
+	// Parameters:
+	//   - node: The current node of the tree.
+	//   - info: The traversal info of the node.
+	//
+	// Returns:
+	//   - Action: The action to take. Only Stop has an effect.
+	//   - error: The error that might occur during the traversal.
+	OnLeave func(node T, info I) (Action, error)
+}
+
+// dfs_frame is a stack element of ApplyDFS.
+type dfs_frame[T interface {
+	Child() iter.Seq[T]
+	BackwardChild() iter.Seq[T]
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, I any] struct {
+	// pair is the node and its info.
+	pair Pair[T, I]
+
+	// depth is the depth of the node.
+	depth int
+
+	// entered is the flag that indicates whether OnEnter has already been called.
+	entered bool
 }
 
 // ApplyDFS applies the DFS traversal logic to the tree.
@@ -79,6 +142,8 @@ type Traverser[T interface {
 // Returns:
 //   - I: The final traversal info.
 //   - error: The error that might occur during the traversal.
+//
+// This function does not use recursion and is safe to use on very deep trees.
 func ApplyDFS[T interface {
 	BackwardChild() iter.Seq[T]
 	Child() iter.Seq[T]
@@ -86,27 +151,81 @@ func ApplyDFS[T interface {
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}](t *Tree[T], trav Traverser[T]) (any, error) {
-	if t == nil {
-		return nil, nil
+}, I any](t *Tree[T], trav Traverser[T, I]) (I, error) {
+	return ApplyDFSContext(context.Background(), t, trav, Limits{})
+}
+
+// ApplyDFSContext works like ApplyDFS but it stops as soon as the context is done
+// or one of the limits is hit.
+//
+// Parameters:
+//   - ctx: The context of the traversal.
+//   - t: The tree to apply the traversal logic to.
+//   - trav: The traverser that holds the traversal logic.
+//   - limits: The limits of the traversal.
+//
+// Returns:
+//   - I: The final traversal info.
+//   - error: The error that might occur during the traversal. If a limit is hit, an
+//     error of type *ErrLimitReached is returned.
+func ApplyDFSContext[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, I any](ctx context.Context, t *Tree[T], trav Traverser[T, I], limits Limits) (I, error) {
+	if t == nil || trav.InitFn == nil || trav.OnEnter == nil {
+		return *new(I), nil
 	}
 
+	lim := new_limiter(ctx, limits)
+
 	info := trav.InitFn(t.root)
 
-	stack := []Pair[T]{NewPair(t.root, info)}
+	stack := []dfs_frame[T, I]{{pair: NewPair(t.root, info)}}
 
 	for len(stack) > 0 {
 		top := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
 
-		nexts, err := trav.DoFn(top.Node, top.Info)
+		if top.entered {
+			stack = stack[:len(stack)-1]
+
+			if trav.OnLeave == nil {
+				continue
+			}
+
+			act, err := trav.OnLeave(top.pair.Node, top.pair.Info)
+			if err != nil {
+				return info, err
+			} else if act == Stop {
+				break
+			}
+
+			continue
+		}
+
+		err := lim.visit(top.depth)
+		if err != nil {
+			return info, err
+		}
+
+		stack[len(stack)-1].entered = true
+
+		nexts, act, err := trav.OnEnter(top.pair.Node, top.pair.Info)
 		if err != nil {
 			return info, err
 		}
 
-		if len(nexts) > 0 {
-			slices.Reverse(nexts)
-			stack = append(stack, nexts...)
+		if act == Stop {
+			break
+		} else if act == SkipChildren || len(nexts) == 0 {
+			continue
+		}
+
+		for _, next := range slices.Backward(nexts) {
+			stack = append(stack, dfs_frame[T, I]{pair: next, depth: top.depth + 1})
 		}
 	}
 
@@ -129,26 +248,77 @@ func ApplyBFS[T interface {
 	Copy() T
 	LinkChildren(children []T)
 	TreeNoder
-}](t *Tree[T], trav Traverser[T]) (any, error) {
-	if t == nil {
-		return nil, nil
+}, I any](t *Tree[T], trav Traverser[T, I]) (I, error) {
+	return ApplyBFSContext(context.Background(), t, trav, Limits{})
+}
+
+// ApplyBFSContext works like ApplyBFS but it stops as soon as the context is done
+// or one of the limits is hit.
+//
+// Parameters:
+//   - ctx: The context of the traversal.
+//   - t: The tree to apply the traversal logic to.
+//   - trav: The traverser that holds the traversal logic.
+//   - limits: The limits of the traversal.
+//
+// Returns:
+//   - I: The final traversal info.
+//   - error: The error that might occur during the traversal. If a limit is hit, an
+//     error of type *ErrLimitReached is returned.
+func ApplyBFSContext[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}, I any](ctx context.Context, t *Tree[T], trav Traverser[T, I], limits Limits) (I, error) {
+	if t == nil || trav.InitFn == nil || trav.OnEnter == nil {
+		return *new(I), nil
 	}
 
+	type QueueElement struct {
+		pair  Pair[T, I]
+		depth int
+	}
+
+	lim := new_limiter(ctx, limits)
+
 	info := trav.InitFn(t.root)
 
-	queue := []Pair[T]{NewPair(t.root, info)}
+	queue := []QueueElement{{pair: NewPair(t.root, info)}}
 
 	for len(queue) > 0 {
-		top := queue[0]
+		first := queue[0]
 		queue = queue[1:]
 
-		nexts, err := trav.DoFn(top.Node, top.Info)
+		err := lim.visit(first.depth)
 		if err != nil {
 			return info, err
 		}
 
-		if len(nexts) > 0 {
-			queue = append(queue, nexts...)
+		nexts, act, err := trav.OnEnter(first.pair.Node, first.pair.Info)
+		if err != nil {
+			return info, err
+		}
+
+		if act == Stop {
+			break
+		} else if act != SkipChildren {
+			for _, next := range nexts {
+				queue = append(queue, QueueElement{pair: next, depth: first.depth + 1})
+			}
+		}
+
+		if trav.OnLeave == nil {
+			continue
+		}
+
+		act, err = trav.OnLeave(first.pair.Node, first.pair.Info)
+		if err != nil {
+			return info, err
+		} else if act == Stop {
+			break
 		}
 	}
 
@@ -0,0 +1,176 @@
+package tree_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+// recorder returns a traverser that logs its calls and gives every child the
+// depth of its parent plus one. The action of OnEnter is chosen by act.
+func recorder(log *[]string, act func(node *root.StringNode) tree.Action) tree.Traverser[*root.StringNode, int] {
+	return tree.Traverser[*root.StringNode, int]{
+		InitFn: func(root *root.StringNode) int {
+			return 0
+		},
+		OnEnter: func(node *root.StringNode, depth int) ([]tree.Pair[*root.StringNode, int], tree.Action, error) {
+			*log = append(*log, "+"+node.Data+strings.Repeat("'", depth))
+
+			var nexts []tree.Pair[*root.StringNode, int]
+
+			for child := range node.Child() {
+				nexts = append(nexts, tree.NewPair(child, depth+1))
+			}
+
+			return nexts, act(node), nil
+		},
+		OnLeave: func(node *root.StringNode, depth int) (tree.Action, error) {
+			*log = append(*log, "-"+node.Data)
+			return tree.Continue, nil
+		},
+	}
+}
+
+func TestApplyDFS(t *testing.T) {
+	tests := []struct {
+		name string
+		act  func(node *root.StringNode) tree.Action
+		want string
+	}{
+		{
+			name: "continue",
+			act:  func(*root.StringNode) tree.Action { return tree.Continue },
+			want: "+a +b' +d'' -d +e'' -e -b +c' +f'' -f -c -a",
+		},
+		{
+			name: "skip children",
+			act: func(node *root.StringNode) tree.Action {
+				if node.Data == "b" {
+					return tree.SkipChildren
+				}
+
+				return tree.Continue
+			},
+			want: "+a +b' -b +c' +f'' -f -c -a",
+		},
+		{
+			name: "stop",
+			act: func(node *root.StringNode) tree.Action {
+				if node.Data == "e" {
+					return tree.Stop
+				}
+
+				return tree.Continue
+			},
+			want: "+a +b' +d'' -d +e''",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+
+			_, err := tree.ApplyDFS(sample_tree(), recorder(&log, tt.act))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(log, " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBFS(t *testing.T) {
+	var log []string
+
+	_, err := tree.ApplyBFS(sample_tree(), recorder(&log, func(*root.StringNode) tree.Action {
+		return tree.Continue
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "+a -a +b' -b +c' -c +d'' -d +e'' -e +f'' -f"
+	if got := strings.Join(log, " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestApplyDFSErrors(t *testing.T) {
+	boom := errors.New("boom")
+
+	trav := tree.Traverser[*root.StringNode, int]{
+		InitFn: func(*root.StringNode) int { return 0 },
+		OnEnter: func(node *root.StringNode, _ int) ([]tree.Pair[*root.StringNode, int], tree.Action, error) {
+			var nexts []tree.Pair[*root.StringNode, int]
+
+			for child := range node.Child() {
+				nexts = append(nexts, tree.NewPair(child, 0))
+			}
+
+			return nexts, tree.Continue, nil
+		},
+	}
+
+	var left []string
+
+	trav.OnLeave = func(node *root.StringNode, _ int) (tree.Action, error) {
+		left = append(left, node.Data)
+
+		if node.Data == "b" {
+			return tree.Continue, boom
+		}
+
+		return tree.Continue, nil
+	}
+
+	_, err := tree.ApplyDFS(sample_tree(), trav)
+	if !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+
+	if !slices.Equal(left, []string{"d", "e", "b"}) {
+		t.Errorf("got %v, want the traversal to stop at b", left)
+	}
+
+	// A traverser without OnEnter does nothing.
+	_, err = tree.ApplyDFS(sample_tree(), tree.Traverser[*root.StringNode, int]{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplyDFSDeep(t *testing.T) {
+	const n = 100000
+
+	var count int
+
+	trav := tree.Traverser[*root.StringNode, struct{}]{
+		InitFn: func(*root.StringNode) struct{} { return struct{}{} },
+		OnEnter: func(node *root.StringNode, info struct{}) ([]tree.Pair[*root.StringNode, struct{}], tree.Action, error) {
+			count++
+
+			child := node.FirstChild
+			if child == nil {
+				return nil, tree.Continue, nil
+			}
+
+			return []tree.Pair[*root.StringNode, struct{}]{tree.NewPair(child, info)}, tree.Continue, nil
+		},
+	}
+
+	_, err := tree.ApplyDFS(chain(n), trav)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != n {
+		t.Errorf("got %d nodes, want %d", count, n)
+	}
+}
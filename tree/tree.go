@@ -1,7 +1,9 @@
 package tree
 
 import (
+	"context"
 	"iter"
+	"slices"
 
 	gcslc "github.com/PlayerR9/go-commons/slices"
 )
@@ -56,24 +58,15 @@ func (t *Tree[T]) DeepCopy() *Tree[T] {
 // Format:
 //
 //	root
-//	├── node1
-//	│   ├── node2
-//	│   └── node3
-//	└── node4
-//	|   └── node5
-//	|
-//	| // ...
-//	// ...
+//	    └── node1
+//	    │   ├── node2
+//	    │   └── node3
+//	    └── node4
+//	        └── node5
+//
+// Use a Printer for other configurations.
 func (t Tree[T]) String() string {
-	trav := print_fn[T]()
-
-	info, err := ApplyDFS(&t, trav)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	inf := info.(*_TreePrinterTrav[T])
-	return inf.String()
+	return Printer[T]{}.Sprint(&t)
 }
 
 // NewTree creates a new tree from the given root.
@@ -92,8 +85,8 @@ func NewTree[T interface {
 	TreeNoder
 }](root T) *Tree[T] {
 	stack := []T{root}
-	size := 1
 
+	var size int
 	var leaves []T
 
 	for len(stack) > 0 {
@@ -176,6 +169,55 @@ func (t *Tree[T]) SetChildren(children []*Tree[T]) error {
 	return nil
 }
 
+// splice_leaves is a helper method that replaces leaves in the cached leaves. The
+// new leaves take the place of the first old leaf or, if none is cached, they are
+// put right after the leaf returned by prev_fn.
+//
+// Parameters:
+//   - old: The leaves to remove.
+//   - added: The leaves to add, in DFS order.
+//   - prev_fn: The function that returns the leaf that comes before the new ones,
+//     or false if none does. If nil, the new leaves are appended.
+func (t *Tree[T]) splice_leaves(old, added []T, prev_fn func() (T, bool)) {
+	leaves := t.leaves
+	pos := -1
+
+	if len(old) > 0 {
+		set := make(map[T]struct{}, len(old))
+		for _, leaf := range old {
+			set[leaf] = struct{}{}
+		}
+
+		kept := make([]T, 0, len(leaves))
+
+		for _, leaf := range leaves {
+			_, ok := set[leaf]
+			if !ok {
+				kept = append(kept, leaf)
+			} else if pos < 0 {
+				pos = len(kept)
+			}
+		}
+
+		leaves = kept
+	}
+
+	if pos < 0 && prev_fn == nil {
+		pos = len(leaves)
+	} else if pos < 0 {
+		prev, ok := prev_fn()
+		if !ok {
+			pos = 0
+		} else if idx := slices.Index(leaves, prev); idx >= 0 {
+			pos = idx + 1
+		} else {
+			pos = len(leaves)
+		}
+	}
+
+	t.leaves = slices.Concat(leaves[:pos], added, leaves[pos:])
+}
+
 // GetDirectChildren returns the direct children of the root of the tree.
 //
 // Children are never nil.
@@ -221,6 +263,54 @@ func (t *Tree[T]) DFS() iter.Seq[T] {
 	return fn
 }
 
+// DFSContext works like DFS but it stops as soon as the context is done or one of
+// the limits is hit.
+//
+// Parameters:
+//   - ctx: The context of the traversal.
+//   - limits: The limits of the traversal.
+//
+// Returns:
+//   - iter.Seq2[T, error]: The traversal sequence. If the traversal is stopped, a
+//     zero node and an error of type *ErrLimitReached are yielded last.
+func (t *Tree[T]) DFSContext(ctx context.Context, limits Limits) iter.Seq2[T, error] {
+	if t == nil {
+		return func(yield func(T, error) bool) {}
+	}
+
+	type StackElement struct {
+		node  T
+		depth int
+	}
+
+	fn := func(yield func(T, error) bool) {
+		lim := new_limiter(ctx, limits)
+
+		stack := []StackElement{{node: t.root}}
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			err := lim.visit(top.depth)
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+
+			if !yield(top.node, nil) {
+				return
+			}
+
+			for child := range top.node.BackwardChild() {
+				stack = append(stack, StackElement{node: child, depth: top.depth + 1})
+			}
+		}
+	}
+
+	return fn
+}
+
 // BFS applies the BFS traversal logic to the tree.
 //
 // Returns:
@@ -462,8 +552,8 @@ func (tree *Tree[T]) replaceLeafWithTree(at int, values []T) {
 	// Make the subtree
 	leaf.LinkChildren(values)
 
-	// Update the size of the tree
-	tree.size += len(values) - 1
+	// Update the size of the tree. The leaf itself was already counted.
+	tree.size += GetNodeSize(leaf) - 1
 
 	// Replace the current leaf with the leaf's children
 	sub_leaves := GetNodeLeaves(leaf)
@@ -490,13 +580,16 @@ func (tree *Tree[T]) replaceLeafWithTree(at int, values []T) {
 //   - The function is applied to the leaves in order.
 //   - The function must return a slice of values of type T.
 //   - If the function returns an error, the process stops and the error is returned.
-//   - The leaves are replaced with the children returned by the function.
+//   - The leaves are replaced with the children returned by the function. The
+//     function is not applied to the new leaves.
 func (tree *Tree[T]) ProcessLeaves(f func(node T) ([]T, error)) error {
 	if f == nil {
 		return nil
 	}
 
-	for i, leaf := range tree.leaves {
+	for i := 0; i < len(tree.leaves); i++ {
+		leaf := tree.leaves[i]
+
 		children, err := f(leaf)
 		if err != nil {
 			return err
@@ -509,7 +602,12 @@ func (tree *Tree[T]) ProcessLeaves(f func(node T) ([]T, error)) error {
 				conv = append(conv, child)
 			}
 
+			n := len(tree.leaves)
+
 			tree.replaceLeafWithTree(i, conv)
+
+			// Skip the new leaves as they were not leaves of the tree.
+			i += len(tree.leaves) - n
 		}
 	}
 
@@ -0,0 +1,100 @@
+package tree_test
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestNewTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		root   *root.StringNode
+		size   int
+		leaves string
+	}{
+		{"single node", make_node("a"), 1, "a"},
+		{"sample", sample_tree().Root(), 6, "d e f"},
+		{"chain", chain(4).Root(), 4, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := tree.NewTree(tt.root)
+
+			if tr.Size() != tt.size {
+				t.Errorf("got size %d, want %d", tr.Size(), tt.size)
+			}
+
+			leaves := slices.Clone(tr.Leaves())
+			slices.SortFunc(leaves, func(a, b *root.StringNode) int {
+				return strings.Compare(a.Data, b.Data)
+			})
+
+			if got := labels(slices.Values(leaves)); got != tt.leaves {
+				t.Errorf("got leaves %q, want %q", got, tt.leaves)
+			}
+		})
+	}
+}
+
+func TestProcessLeaves(t *testing.T) {
+	tr := sample_tree()
+
+	var visited []string
+
+	err := tr.ProcessLeaves(func(node *root.StringNode) ([]*root.StringNode, error) {
+		visited = append(visited, node.Data)
+
+		if node.Data == "f" {
+			return nil, nil
+		}
+
+		return []*root.StringNode{make_node(node.Data+"1", make_node(node.Data+"2")), make_node(node.Data + "3")}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(visited)
+
+	if got := join_labels(visited); got != "d e f" {
+		t.Errorf("visited %q, want only the former leaves", got)
+	}
+
+	if tr.Size() != 12 {
+		t.Errorf("got size %d, want 12", tr.Size())
+	}
+
+	err = tree.Validate(tr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	for trial := range 500 {
+		tr := tree.NewTree(random_tree(r, 4, 6))
+		name := string(rune('a' + r.Intn(6)))
+
+		err := tr.ProcessLeaves(func(node *root.StringNode) ([]*root.StringNode, error) {
+			if node.Data != name {
+				return nil, nil
+			}
+
+			return []*root.StringNode{random_tree(r, 2, 6)}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = tree.Validate(tr)
+		if err != nil {
+			t.Fatalf("trial %d: %v", trial, err)
+		}
+	}
+}
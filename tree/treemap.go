@@ -0,0 +1,485 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	gcers "github.com/PlayerR9/go-errors"
+)
+
+// TreemapOptions are the options of the treemap layout.
+type TreemapOptions[T TreeNoder] struct {
+	// Weight returns the weight of a leaf. Weights must be finite and not negative.
+	// The weight of a non-leaf node is the sum of the weights of its children.
+	Weight func(leaf T) float64
+
+	// Width and Height are the size of the treemap. Both zero means 800 by 600;
+	// otherwise, both must be positive and finite.
+	Width, Height float64
+
+	// Padding is the space between a node and its children. Optional.
+	Padding float64
+
+	// Header is the space reserved at the top of every non-leaf node, for its label.
+	// Optional.
+	Header float64
+}
+
+// TreemapRect is the rectangle of a node in a Treemap.
+type TreemapRect[T TreeNoder] struct {
+	// Node is the node.
+	Node T
+
+	// Parent is the index, in Treemap.Rects, of the parent of the node. -1 for the
+	// root.
+	Parent int
+
+	// Depth is the depth of the node.
+	Depth int
+
+	// Weight is the weight of the node.
+	Weight float64
+
+	// X and Y are the coordinates of the top-left corner of the rectangle.
+	X, Y float64
+
+	// Width and Height are the size of the rectangle.
+	Width, Height float64
+}
+
+// Treemap is the result of the treemap layout.
+type Treemap[T TreeNoder] struct {
+	// Rects are the rectangles of the nodes, in DFS order. The first one is the root.
+	Rects []TreemapRect[T]
+
+	// Width and Height are the size of the treemap.
+	Width, Height float64
+}
+
+// worst_ratio is a helper function that returns the worst aspect ratio of a row
+// of areas laid along a side.
+//
+// Parameters:
+//   - sum: The sum of the areas of the row.
+//   - lo: The smallest area of the row.
+//   - hi: The largest area of the row.
+//   - side: The length of the side.
+//
+// Returns:
+//   - float64: The worst aspect ratio; always at least 1.
+func worst_ratio(sum, lo, hi, side float64) float64 {
+	s2 := side * side
+	sum2 := sum * sum
+
+	return max(s2*hi/sum2, sum2/(s2*lo))
+}
+
+// squarify is a helper function that lays out areas in a rectangle with the
+// squarified algorithm of Bruls, Huizing and van Wijk.
+//
+// Parameters:
+//   - areas: The areas, sorted in decreasing order. Their sum is the area of the
+//     rectangle.
+//   - x, y, w, h: The rectangle.
+//
+// Returns:
+//   - [][4]float64: The rectangles (x, y, width, height) of the areas, in order.
+func squarify(areas []float64, x, y, w, h float64) [][4]float64 {
+	rects := make([][4]float64, 0, len(areas))
+
+	for start := 0; start < len(areas); {
+		side := min(w, h)
+
+		sum, lo, hi := areas[start], areas[start], areas[start]
+		end := start + 1
+
+		for end < len(areas) && side > 0 {
+			a := areas[end]
+
+			if worst_ratio(sum+a, min(lo, a), max(hi, a), side) > worst_ratio(sum, lo, hi, side) {
+				break
+			}
+
+			sum += a
+			lo = min(lo, a)
+			hi = max(hi, a)
+			end++
+		}
+
+		if w >= h {
+			var col_w float64
+
+			if h > 0 {
+				col_w = sum / h
+			}
+
+			cy := y
+
+			for _, a := range areas[start:end] {
+				var ah float64
+
+				if col_w > 0 {
+					ah = a / col_w
+				}
+
+				rects = append(rects, [4]float64{x, cy, col_w, ah})
+				cy += ah
+			}
+
+			x += col_w
+			w -= col_w
+		} else {
+			var row_h float64
+
+			if w > 0 {
+				row_h = sum / w
+			}
+
+			cx := x
+
+			for _, a := range areas[start:end] {
+				var aw float64
+
+				if row_h > 0 {
+					aw = a / row_h
+				}
+
+				rects = append(rects, [4]float64{cx, y, aw, row_h})
+				cx += aw
+			}
+
+			y += row_h
+			h -= row_h
+		}
+
+		w = max(w, 0)
+		h = max(h, 0)
+		start = end
+	}
+
+	return rects
+}
+
+// NewTreemap computes a squarified treemap of the tree, where the area of every
+// node is proportional to its weight.
+//
+// Parameters:
+//   - tree: The tree to lay out.
+//   - opts: The options of the layout.
+//
+// Returns:
+//   - *Treemap[T]: The treemap. Never returns nil on success.
+//   - error: An error if tree or opts.Weight is nil, if only one of opts.Width and
+//     opts.Height is set or one of them is not positive, or if a weight is
+//     negative, infinite or not a number.
+//
+// Nodes of zero weight get empty rectangles.
+func NewTreemap[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	TreeNoder
+}](tree *Tree[T], opts TreemapOptions[T]) (*Treemap[T], error) {
+	if tree == nil {
+		return nil, gcers.NewErrNilParameter("tree")
+	} else if opts.Weight == nil {
+		return nil, gcers.NewErrNilParameter("opts.Weight")
+	}
+
+	width, height := opts.Width, opts.Height
+
+	if width == 0 && height == 0 {
+		width, height = 800, 600
+	} else if !(width > 0) || math.IsInf(width, 0) {
+		return nil, gcers.NewErrInvalidParameter("opts.Width must be positive, got " + strconv.FormatFloat(width, 'g', -1, 64))
+	} else if !(height > 0) || math.IsInf(height, 0) {
+		return nil, gcers.NewErrInvalidParameter("opts.Height must be positive, got " + strconv.FormatFloat(height, 'g', -1, 64))
+	}
+
+	_, weights, err := FoldMemo(tree.root, func(node T, children []float64) (float64, error) {
+		if len(children) == 0 {
+			w := opts.Weight(node)
+			if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return 0, fmt.Errorf("invalid weight %v", w)
+			}
+
+			return w, nil
+		}
+
+		var sum float64
+
+		for _, c := range children {
+			sum += c
+		}
+
+		if math.IsInf(sum, 0) {
+			return 0, fmt.Errorf("weight of %s overflows", node.String())
+		}
+
+		return sum, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tm := &Treemap[T]{
+		Width:  width,
+		Height: height,
+	}
+
+	stack := []TreemapRect[T]{{
+		Node:   tree.root,
+		Parent: -1,
+		Weight: weights[tree.root],
+		Width:  width,
+		Height: height,
+	}}
+
+	for len(stack) > 0 {
+		r := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		idx := len(tm.Rects)
+		tm.Rects = append(tm.Rects, r)
+
+		if r.Node.IsLeaf() {
+			continue
+		}
+
+		// Padding and header are clamped so that children never leave their parent.
+		px := max(min(opts.Padding, r.Width/2), 0)
+		py := max(min(opts.Padding, r.Height/2), 0)
+		header := max(min(opts.Header, r.Height-2*py), 0)
+
+		x := r.X + px
+		y := r.Y + py + header
+		w := r.Width - 2*px
+		h := r.Height - 2*py - header
+
+		var children []TreemapRect[T]
+
+		for child := range r.Node.Child() {
+			children = append(children, TreemapRect[T]{
+				Node:   child,
+				Parent: idx,
+				Depth:  r.Depth + 1,
+				Weight: weights[child],
+			})
+		}
+
+		// The squarified algorithm lays out the heaviest children first.
+		order := make([]int, len(children))
+		for j := range order {
+			order[j] = j
+		}
+
+		slices.SortStableFunc(order, func(a, b int) int {
+			return -compare_floats(children[a].Weight, children[b].Weight)
+		})
+
+		areas := make([]float64, len(order))
+
+		if r.Weight > 0 {
+			for j, k := range order {
+				areas[j] = children[k].Weight / r.Weight * w * h
+			}
+		}
+
+		for j, rect := range squarify(areas, x, y, w, h) {
+			c := &children[order[j]]
+			c.X, c.Y, c.Width, c.Height = rect[0], rect[1], rect[2], rect[3]
+		}
+
+		for j := len(children) - 1; j >= 0; j-- {
+			stack = append(stack, children[j])
+		}
+	}
+
+	return tm, nil
+}
+
+// compare_floats is a helper function that compares two floats.
+//
+// Parameters:
+//   - a: The first float.
+//   - b: The second float.
+//
+// Returns:
+//   - int: -1 if a < b, 1 if a > b, 0 otherwise.
+func compare_floats(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+// TreemapStyle are the drawing options of a Treemap.
+type TreemapStyle[T TreeNoder] struct {
+	// Label returns the label of a node. Defaults to the String of the node.
+	Label func(node T) string
+
+	// Fill returns the fill color of a node. Defaults to a palette by depth.
+	Fill func(node T, depth int) string
+
+	// Title is the title of the HTML page. Ignored by SVG.
+	Title string
+}
+
+// treemap_palette is the default palette of the treemaps, by depth.
+var treemap_palette = []string{"#e8eef7", "#c6dbef", "#9ecae1", "#6baed6", "#c7e9c0", "#a1d99b", "#fdd0a2", "#fdae6b"}
+
+// style is a helper method that returns the label and the fill color of a node.
+//
+// Parameters:
+//   - r: The rectangle of the node.
+//
+// Returns:
+//   - string: The label.
+//   - string: The fill color.
+func (s TreemapStyle[T]) style(r TreemapRect[T]) (string, string) {
+	var label, fill string
+
+	if s.Label != nil {
+		label = s.Label(r.Node)
+	} else {
+		label = r.Node.String()
+	}
+
+	if s.Fill != nil {
+		fill = s.Fill(r.Node, r.Depth)
+	}
+
+	if fill == "" {
+		fill = treemap_palette[r.Depth%len(treemap_palette)]
+	}
+
+	return label, fill
+}
+
+// WriteTreemapSVG writes the treemap as a self-contained SVG picture.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tm: The treemap to draw.
+//   - style: The drawing options.
+//
+// Returns:
+//   - error: An error if w or tm is nil, or the first write error.
+//
+// Every rectangle has a tooltip with its label and weight. Labels are only drawn
+// where they fit.
+func WriteTreemapSVG[T TreeNoder](w io.Writer, tm *Treemap[T], style TreemapStyle[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tm == nil {
+		return gcers.NewErrNilParameter("tm")
+	}
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	tw.write(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="`, svg_num(tm.Width),
+		`" height="`, svg_num(tm.Height),
+		`" viewBox="0 0 `, svg_num(tm.Width), " ", svg_num(tm.Height), "\">\n",
+		`<g font-family="sans-serif" font-size="11" stroke="#fff" stroke-width="1">`, "\n",
+	)
+
+	for _, r := range tm.Rects {
+		label, fill := style.style(r)
+
+		tw.write(
+			`<g><title>`, html.EscapeString(label), " (", strconv.FormatFloat(r.Weight, 'g', -1, 64), ")</title>",
+			`<rect x="`, svg_num(r.X), `" y="`, svg_num(r.Y),
+			`" width="`, svg_num(r.Width), `" height="`, svg_num(r.Height), `"`,
+			svg_attr("fill", fill), "/>",
+		)
+
+		if float64(7*utf8.RuneCountInString(label)+6) <= r.Width && r.Height >= 14 {
+			tw.write(
+				`<text x="`, svg_num(r.X+3), `" y="`, svg_num(r.Y+12), `" stroke="none" fill="#000">`,
+				html.EscapeString(label), "</text>",
+			)
+		}
+
+		tw.write("</g>\n")
+	}
+
+	tw.write("</g>\n</svg>\n")
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
+
+// WriteTreemapHTML writes the treemap as a self-contained HTML page.
+//
+// Parameters:
+//   - w: The writer to write to.
+//   - tm: The treemap to draw.
+//   - style: The drawing options.
+//
+// Returns:
+//   - error: An error if w or tm is nil, or the first write error.
+//
+// Every node is an absolutely positioned div with a tooltip with its label and
+// weight.
+func WriteTreemapHTML[T TreeNoder](w io.Writer, tm *Treemap[T], style TreemapStyle[T]) error {
+	if w == nil {
+		return gcers.NewErrNilParameter("w")
+	} else if tm == nil {
+		return gcers.NewErrNilParameter("tm")
+	}
+
+	title := style.Title
+	if title == "" {
+		title = "Treemap"
+	}
+
+	tw := &text_writer{
+		w: bufio.NewWriter(w),
+	}
+
+	tw.write(
+		"<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>", html.EscapeString(title), "</title>\n",
+		"<style>\n",
+		".treemap { position: relative; font: 11px sans-serif; }\n",
+		".treemap div { position: absolute; box-sizing: border-box; border: 1px solid #fff; overflow: hidden; white-space: nowrap; padding: 1px 3px; }\n",
+		"</style>\n</head>\n<body>\n",
+		`<div class="treemap" style="width: `, svg_num(tm.Width), "px; height: ", svg_num(tm.Height), `px;">`, "\n",
+	)
+
+	for _, r := range tm.Rects {
+		label, fill := style.style(r)
+		escaped := html.EscapeString(label)
+
+		tw.write(
+			`<div title="`, escaped, " (", strconv.FormatFloat(r.Weight, 'g', -1, 64), `)" style="left: `, svg_num(r.X),
+			"px; top: ", svg_num(r.Y), "px; width: ", svg_num(r.Width), "px; height: ", svg_num(r.Height),
+			"px; background: ", html.EscapeString(fill), `;">`, escaped, "</div>\n",
+		)
+	}
+
+	tw.write("</div>\n</body>\n</html>\n")
+
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
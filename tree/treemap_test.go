@@ -0,0 +1,149 @@
+package tree_test
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestTreemapArea(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	const eps = 1e-6
+
+	for i := 0; i < 200; i++ {
+		tr := tree.NewTree(random_tree(r, 4, 26))
+
+		weights := make(map[*root.StringNode]float64)
+
+		for _, leaf := range tr.Leaves() {
+			weights[leaf] = float64(r.Intn(100))
+		}
+
+		tm, err := tree.NewTreemap(tr, tree.TreemapOptions[*root.StringNode]{
+			Weight: func(leaf *root.StringNode) float64 {
+				return weights[leaf]
+			},
+			Width:  300,
+			Height: 200,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		total := tm.Rects[0].Weight
+		if total == 0 {
+			continue
+		}
+
+		children := make(map[int][]tree.TreemapRect[*root.StringNode])
+
+		for _, rect := range tm.Rects {
+			// The area of every node is proportional to its weight.
+			want := rect.Weight / total * 300 * 200
+			if got := rect.Width * rect.Height; math.Abs(got-want) > eps*300*200 {
+				t.Fatalf("tree %d: %s has an area of %g, want %g", i, rect.Node.Data, got, want)
+			}
+
+			if rect.Parent >= 0 {
+				children[rect.Parent] = append(children[rect.Parent], rect)
+			}
+		}
+
+		// Children lie inside their parent and do not overlap.
+		for parent, kids := range children {
+			p := tm.Rects[parent]
+
+			for k, a := range kids {
+				if a.X < p.X-eps || a.Y < p.Y-eps || a.X+a.Width > p.X+p.Width+eps || a.Y+a.Height > p.Y+p.Height+eps {
+					t.Fatalf("tree %d: %s sticks out of %s", i, a.Node.Data, p.Node.Data)
+				}
+
+				for _, b := range kids[k+1:] {
+					w := min(a.X+a.Width, b.X+b.Width) - max(a.X, b.X)
+					h := min(a.Y+a.Height, b.Y+b.Height) - max(a.Y, b.Y)
+
+					if w > eps && h > eps {
+						t.Fatalf("tree %d: %s overlaps %s", i, a.Node.Data, b.Node.Data)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTreemapErrors(t *testing.T) {
+	one := func(leaf *root.StringNode) float64 {
+		return 1
+	}
+
+	tests := []struct {
+		name string
+		opts tree.TreemapOptions[*root.StringNode]
+		want string
+	}{
+		{"nil weight", tree.TreemapOptions[*root.StringNode]{}, "opts.Weight"},
+		{"width only", tree.TreemapOptions[*root.StringNode]{Weight: one, Width: 800}, "opts.Height"},
+		{"height only", tree.TreemapOptions[*root.StringNode]{Weight: one, Height: 600}, "opts.Width"},
+		{"negative width", tree.TreemapOptions[*root.StringNode]{Weight: one, Width: -1, Height: 600}, "opts.Width"},
+		{"infinite height", tree.TreemapOptions[*root.StringNode]{Weight: one, Width: 1, Height: math.Inf(1)}, "opts.Height"},
+		{"NaN width", tree.TreemapOptions[*root.StringNode]{Weight: one, Width: math.NaN(), Height: 1}, "opts.Width"},
+		{"negative weight", tree.TreemapOptions[*root.StringNode]{Weight: func(*root.StringNode) float64 { return -1 }}, "invalid weight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tree.NewTreemap(sample_tree(), tt.opts)
+			if !strings.Contains(error_chain(err), tt.want) {
+				t.Errorf("got %v, want an error about %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTreemap(t *testing.T) {
+	tm, err := tree.NewTreemap(sample_tree(), tree.TreemapOptions[*root.StringNode]{
+		Weight: func(leaf *root.StringNode) float64 {
+			return 1
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tm.Width != 800 || tm.Height != 600 {
+		t.Errorf("got a %gx%g treemap, want the default 800x600", tm.Width, tm.Height)
+	}
+
+	style := tree.TreemapStyle[*root.StringNode]{Title: "<sample>"}
+
+	var svg, page bytes.Buffer
+
+	err = tree.WriteTreemapSVG(&svg, tm, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(svg.String(), "<rect "); n != len(tm.Rects) {
+		t.Errorf("got %d rectangles, want %d", n, len(tm.Rects))
+	}
+
+	err = tree.WriteTreemapHTML(&page, tm, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(page.String(), "&lt;sample&gt;") {
+		t.Error("the title of the page is not escaped")
+	}
+
+	err = tree.WriteTreemapSVG(failing_writer{}, tm, style)
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("failing writer: got %v, want the write error", err)
+	}
+}
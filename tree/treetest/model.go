@@ -0,0 +1,197 @@
+package treetest
+
+import (
+	"slices"
+)
+
+// model is the reference model of a forest of nodes. It is deliberately naive so
+// that it is obviously correct.
+type model[T comparable] struct {
+	// parent is the parent of every node. Missing nodes are roots.
+	parent map[T]T
+
+	// children are the children of every node, in order.
+	children map[T][]T
+}
+
+// new_model creates a new model where every node is a lone root.
+//
+// Returns:
+//   - *model[T]: The new model. Never returns nil.
+func new_model[T comparable]() *model[T] {
+	return &model[T]{
+		parent:   make(map[T]T),
+		children: make(map[T][]T),
+	}
+}
+
+// parent_of returns the parent of the given node.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - T: The parent of the node.
+//   - bool: True if the node has a parent, false otherwise.
+func (m *model[T]) parent_of(node T) (T, bool) {
+	p, ok := m.parent[node]
+	return p, ok
+}
+
+// is_ancestor checks whether target is a strict ancestor of node.
+//
+// Parameters:
+//   - node: The node.
+//   - target: The candidate ancestor.
+//
+// Returns:
+//   - bool: True if target is a strict ancestor of node, false otherwise.
+func (m *model[T]) is_ancestor(node, target T) bool {
+	for {
+		p, ok := m.parent[node]
+		if !ok {
+			return false
+		}
+
+		if p == target {
+			return true
+		}
+
+		node = p
+	}
+}
+
+// root_of returns the root of the given node.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - T: The root of the node.
+func (m *model[T]) root_of(node T) T {
+	for {
+		p, ok := m.parent[node]
+		if !ok {
+			return node
+		}
+
+		node = p
+	}
+}
+
+// add_child appends the detached node target to the children of node.
+//
+// Parameters:
+//   - node: The new parent.
+//   - target: The new child.
+func (m *model[T]) add_child(node, target T) {
+	m.parent[target] = node
+	m.children[node] = append(m.children[node], target)
+}
+
+// link_children sets the children of a childless node.
+//
+// Parameters:
+//   - node: The new parent.
+//   - children: The new children.
+func (m *model[T]) link_children(node T, children []T) {
+	for _, child := range children {
+		m.add_child(node, child)
+	}
+}
+
+// detach removes the node from its parent, if any.
+//
+// Parameters:
+//   - node: The node to detach.
+func (m *model[T]) detach(node T) {
+	p, ok := m.parent[node]
+	if !ok {
+		return
+	}
+
+	delete(m.parent, node)
+
+	m.children[p] = slices.DeleteFunc(m.children[p], func(c T) bool {
+		return c == node
+	})
+}
+
+// release detaches every child of the node and returns them.
+//
+// Parameters:
+//   - node: The node whose children are released.
+//
+// Returns:
+//   - []T: The former children of the node.
+func (m *model[T]) release(node T) []T {
+	children := m.children[node]
+	delete(m.children, node)
+
+	for _, child := range children {
+		delete(m.parent, child)
+	}
+
+	return children
+}
+
+// delete_child models DeleteChild.
+//
+// Parameters:
+//   - node: The parent.
+//   - target: The child to delete.
+//
+// Returns:
+//   - []T: The former children of target. Nil if target is not a child of node.
+func (m *model[T]) delete_child(node, target T) []T {
+	p, ok := m.parent[target]
+	if !ok || p != node {
+		return nil
+	}
+
+	m.detach(target)
+
+	return m.release(target)
+}
+
+// remove_node models RemoveNode.
+//
+// Parameters:
+//   - node: The node to remove.
+//
+// Returns:
+//   - []T: The former children of the node iff it was a root.
+func (m *model[T]) remove_node(node T) []T {
+	p, ok := m.parent[node]
+	if !ok {
+		return m.release(node)
+	}
+
+	siblings := m.children[p]
+	idx := slices.Index(siblings, node)
+
+	children := m.children[node]
+	delete(m.children, node)
+
+	for _, child := range children {
+		m.parent[child] = p
+	}
+
+	m.children[p] = slices.Concat(siblings[:idx], children, siblings[idx+1:])
+	delete(m.parent, node)
+
+	return nil
+}
+
+// cleanup models Cleanup.
+//
+// Parameters:
+//   - node: The node to clean up.
+//
+// Returns:
+//   - []T: The former children of the node.
+func (m *model[T]) cleanup(node T) []T {
+	m.detach(node)
+
+	return m.release(node)
+}
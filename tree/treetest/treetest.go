@@ -0,0 +1,714 @@
+// Package treetest implements a behavioural conformance suite for first-child/next-sibling
+// tree nodes; such as the ones generated by github.com/PlayerR9/tree/cmd.
+//
+// Usage:
+//
+//	func TestFooNode(t *testing.T) {
+//		var count int
+//
+//		treetest.Run(t, func() *FooNode {
+//			count++
+//			return NewFooNode(count)
+//		})
+//	}
+package treetest
+
+import (
+	"fmt"
+	"iter"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PlayerR9/tree/tree"
+)
+
+// Node is the set of methods a node must implement to be checked by the suite.
+type Node[T tree.TreeNoder] interface {
+	AddChild(target T)
+	AddChildren(children []T)
+	Child() iter.Seq[T]
+	Cleanup() []T
+	DeleteChild(target T) []T
+	GetFirstChild() (T, bool)
+	GetParent() (T, bool)
+	HasChild(target T) bool
+	IsChildOf(target T) bool
+	LinkChildren(children []T)
+	Links() tree.Links[T]
+	RemoveNode() []T
+	tree.TreeNoder
+}
+
+// Suite is the conformance suite of a node type.
+type Suite[T Node[T]] struct {
+	// New returns a new detached node. Every call must return a distinct node.
+	New func() T
+
+	// Seed is the seed of the randomized operation sequences.
+	Seed int64
+
+	// Rounds is the number of randomized operation sequences.
+	Rounds int
+
+	// Steps is the number of operations per randomized sequence.
+	Steps int
+
+	// Nodes is the number of nodes used by each randomized sequence.
+	Nodes int
+}
+
+// Run runs the whole conformance suite with the default settings.
+//
+// Parameters:
+//   - t: The test.
+//   - new_fn: The function that returns a new detached node. Every call must return
+//     a distinct node.
+func Run[T Node[T]](t *testing.T, new_fn func() T) {
+	t.Helper()
+
+	s := Suite[T]{
+		New:    new_fn,
+		Seed:   1,
+		Rounds: 20,
+		Steps:  200,
+		Nodes:  12,
+	}
+
+	s.Run(t)
+}
+
+// Run runs the whole conformance suite.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) Run(t *testing.T) {
+	t.Helper()
+
+	if s.New == nil {
+		t.Fatal("treetest: Suite.New must be set")
+	}
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{"AddChild", s.test_add_child},
+		{"AddChildren", s.test_add_children},
+		{"LinkChildren", s.test_link_children},
+		{"DeleteChild", s.test_delete_child},
+		{"RemoveNode", s.test_remove_node},
+		{"Cleanup", s.test_cleanup},
+		{"GetParent", s.test_get_parent},
+		{"GetFirstChild", s.test_get_first_child},
+		{"HasChild", s.test_has_child},
+		{"IsChildOf", s.test_is_child_of},
+		{"Random", s.test_random},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.fn)
+	}
+}
+
+// fixture is a set of nodes together with their reference model.
+type fixture[T Node[T]] struct {
+	// nodes are all the nodes of the fixture.
+	nodes []T
+
+	// m is the reference model.
+	m *model[T]
+}
+
+// new_fixture creates n detached nodes.
+//
+// Parameters:
+//   - n: The number of nodes.
+//
+// Returns:
+//   - *fixture[T]: The new fixture. Never returns nil.
+func (s Suite[T]) new_fixture(n int) *fixture[T] {
+	f := &fixture[T]{
+		nodes: make([]T, 0, n),
+		m:     new_model[T](),
+	}
+
+	for i := 0; i < n; i++ {
+		f.nodes = append(f.nodes, s.New())
+	}
+
+	return f
+}
+
+// build builds the following tree out of the first seven nodes of the fixture
+// and returns it.
+//
+//	0
+//	├── 1
+//	│   ├── 3
+//	│   └── 4
+//	├── 2
+//	└── 5
+//	    └── 6
+//
+// Returns:
+//   - []T: The first seven nodes.
+func (f *fixture[T]) build() []T {
+	n := f.nodes[:7]
+
+	edges := [][2]int{{0, 1}, {0, 2}, {0, 5}, {1, 3}, {1, 4}, {5, 6}}
+
+	for _, e := range edges {
+		n[e[0]].AddChild(n[e[1]])
+		f.m.add_child(n[e[0]], n[e[1]])
+	}
+
+	return n
+}
+
+// check compares every node of the fixture against the reference model.
+//
+// Returns:
+//   - error: The first mismatch found. Nil if there is none.
+func (f *fixture[T]) check() error {
+	var zero T
+
+	for _, node := range f.nodes {
+		parent, ok := node.GetParent()
+		want, want_ok := f.m.parent_of(node)
+
+		if ok != want_ok || parent != want {
+			return fmt.Errorf("%s.GetParent() = (%s, %t), want (%s, %t)", node, str(parent), ok, str(want), want_ok)
+		}
+
+		want_children := f.m.children[node]
+
+		var got_children []T
+
+		for child := range node.Child() {
+			if len(got_children) > len(f.nodes) {
+				return fmt.Errorf("%s.Child() does not terminate", node)
+			}
+
+			got_children = append(got_children, child)
+		}
+
+		if !slices.Equal(got_children, want_children) {
+			return fmt.Errorf("%s.Child() = %s, want %s", node, strs(got_children), strs(want_children))
+		}
+
+		first, ok := node.GetFirstChild()
+
+		if len(want_children) == 0 {
+			if ok || first != zero {
+				return fmt.Errorf("%s.GetFirstChild() = (%s, %t), want (<nil>, false)", node, str(first), ok)
+			}
+		} else if !ok || first != want_children[0] {
+			return fmt.Errorf("%s.GetFirstChild() = (%s, %t), want (%s, true)", node, str(first), ok, want_children[0])
+		}
+
+		if node.IsLeaf() != (len(want_children) == 0) {
+			return fmt.Errorf("%s.IsLeaf() = %t, want %t", node, node.IsLeaf(), len(want_children) == 0)
+		}
+
+		if node.IsSingleton() != (len(want_children) == 1) {
+			return fmt.Errorf("%s.IsSingleton() = %t, want %t", node, node.IsSingleton(), len(want_children) == 1)
+		}
+
+		for _, other := range f.nodes {
+			want := slices.Contains(want_children, other)
+
+			if node.HasChild(other) != want {
+				return fmt.Errorf("%s.HasChild(%s) = %t, want %t", node, other, !want, want)
+			}
+
+			want = f.m.is_ancestor(node, other)
+
+			if node.IsChildOf(other) != want {
+				return fmt.Errorf("%s.IsChildOf(%s) = %t, want %t", node, other, !want, want)
+			}
+		}
+
+		if node.HasChild(zero) {
+			return fmt.Errorf("%s.HasChild(nil) = true, want false", node)
+		}
+
+		if node.IsChildOf(zero) {
+			return fmt.Errorf("%s.IsChildOf(nil) = true, want false", node)
+		}
+	}
+
+	for _, node := range f.nodes {
+		_, ok := f.m.parent_of(node)
+		if ok {
+			continue
+		}
+
+		err := tree.ValidateNode(node)
+		if err != nil {
+			return err
+		}
+
+		links := node.Links()
+
+		if links.PrevSibling != zero || links.NextSibling != zero {
+			return fmt.Errorf("root %s has siblings", node)
+		}
+	}
+
+	return nil
+}
+
+// must_check is a helper that fails the test if the fixture does not match its model.
+//
+// Parameters:
+//   - t: The test.
+//   - step: The description of the last operation.
+func (f *fixture[T]) must_check(t *testing.T, step string) {
+	t.Helper()
+
+	err := f.check()
+	if err != nil {
+		t.Fatalf("after %s: %s", step, err.Error())
+	}
+}
+
+// must_equal is a helper that fails the test if the returned nodes are not the
+// expected ones.
+//
+// Parameters:
+//   - t: The test.
+//   - step: The description of the operation.
+//   - got: The returned nodes.
+//   - want: The expected nodes.
+func must_equal[T tree.TreeNoder](t *testing.T, step string, got, want []T) {
+	t.Helper()
+
+	err := compare(step, got, want)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// compare is a helper that checks that the returned nodes are the expected ones.
+//
+// Parameters:
+//   - step: The description of the operation.
+//   - got: The returned nodes.
+//   - want: The expected nodes.
+//
+// Returns:
+//   - error: An error if the nodes differ.
+func compare[T tree.TreeNoder](step string, got, want []T) error {
+	if len(got) == 0 && len(want) == 0 {
+		return nil
+	}
+
+	if !slices.Equal(got, want) {
+		return fmt.Errorf("%s returned %s, want %s", step, strs(got), strs(want))
+	}
+
+	return nil
+}
+
+// str is a helper that stringifies a node that may be nil.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - string: The string representation of the node. "<nil>" if it is the zero value.
+func str[T tree.TreeNoder](node T) string {
+	if node == *new(T) {
+		return "<nil>"
+	}
+
+	return node.String()
+}
+
+// strs is a helper that stringifies a slice of nodes.
+//
+// Parameters:
+//   - nodes: The nodes.
+//
+// Returns:
+//   - string: The string representation of the nodes.
+func strs[T tree.TreeNoder](nodes []T) string {
+	elems := make([]string, 0, len(nodes))
+
+	for _, node := range nodes {
+		elems = append(elems, str(node))
+	}
+
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// test_add_child checks that AddChild appends a node, or a whole subtree, as the
+// last child and that it ignores a nil child.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_add_child(t *testing.T) {
+	f := s.new_fixture(9)
+	f.must_check(t, "creation")
+
+	n := f.build()
+	f.must_check(t, "building the fixture tree")
+
+	var zero T
+
+	n[0].AddChild(zero)
+	f.must_check(t, "AddChild(nil)")
+
+	// Adding a subtree keeps its descendants.
+	f.nodes[7].AddChild(f.nodes[8])
+	f.m.add_child(f.nodes[7], f.nodes[8])
+
+	n[2].AddChild(f.nodes[7])
+	f.m.add_child(n[2], f.nodes[7])
+	f.must_check(t, "AddChild of a subtree")
+}
+
+// test_add_children checks that AddChildren appends the non-nil children in order
+// whether the node already has children or not.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_add_children(t *testing.T) {
+	f := s.new_fixture(6)
+
+	var zero T
+
+	n := f.nodes
+
+	n[0].AddChildren(nil)
+	f.must_check(t, "AddChildren(nil)")
+
+	n[0].AddChildren([]T{zero, n[1], zero, n[2]})
+	f.m.add_child(n[0], n[1])
+	f.m.add_child(n[0], n[2])
+	f.must_check(t, "AddChildren with nil entries")
+
+	n[0].AddChildren([]T{n[3], n[4]})
+	f.m.add_child(n[0], n[3])
+	f.m.add_child(n[0], n[4])
+	f.must_check(t, "AddChildren on a node with children")
+
+	n[4].AddChildren([]T{n[5]})
+	f.m.add_child(n[4], n[5])
+	f.must_check(t, "AddChildren with a single child")
+}
+
+// test_link_children checks that LinkChildren replaces the children of a node with
+// the non-nil ones given and that it ignores an empty list.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_link_children(t *testing.T) {
+	f := s.new_fixture(5)
+
+	var zero T
+
+	n := f.nodes
+
+	n[0].LinkChildren(nil)
+	f.must_check(t, "LinkChildren(nil)")
+
+	n[0].LinkChildren([]T{n[1]})
+	f.m.link_children(n[0], []T{n[1]})
+	f.must_check(t, "LinkChildren with a single child")
+
+	n[1].LinkChildren([]T{n[2], zero, n[3], n[4]})
+	f.m.link_children(n[1], []T{n[2], n[3], n[4]})
+	f.must_check(t, "LinkChildren with nil entries")
+}
+
+// test_delete_child checks that DeleteChild unlinks the first, last and only
+// child, returns their children and ignores nodes that are not children.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_delete_child(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	var zero T
+
+	must_equal(t, "DeleteChild(nil)", n[0].DeleteChild(zero), nil)
+	f.must_check(t, "DeleteChild(nil)")
+
+	must_equal(t, "DeleteChild of a non-child", n[0].DeleteChild(n[3]), nil)
+	f.must_check(t, "DeleteChild of a non-child")
+
+	must_equal(t, "DeleteChild of the last child", n[0].DeleteChild(n[5]), f.m.delete_child(n[0], n[5]))
+	f.must_check(t, "DeleteChild of the last child")
+
+	must_equal(t, "DeleteChild of the first child", n[0].DeleteChild(n[1]), f.m.delete_child(n[0], n[1]))
+	f.must_check(t, "DeleteChild of the first child")
+
+	must_equal(t, "DeleteChild of the only child", n[0].DeleteChild(n[2]), f.m.delete_child(n[0], n[2]))
+	f.must_check(t, "DeleteChild of the only child")
+}
+
+// test_remove_node checks that RemoveNode splices the children of a node into its
+// parent; for middle nodes, last children, leaves, roots and lone nodes.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_remove_node(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	must_equal(t, "RemoveNode of a middle node", n[1].RemoveNode(), f.m.remove_node(n[1]))
+	f.must_check(t, "RemoveNode of a middle node")
+
+	must_equal(t, "RemoveNode of the last child", n[6].RemoveNode(), f.m.remove_node(n[6]))
+	f.must_check(t, "RemoveNode of the last child")
+
+	must_equal(t, "RemoveNode of a leaf", n[2].RemoveNode(), f.m.remove_node(n[2]))
+	f.must_check(t, "RemoveNode of a leaf")
+
+	must_equal(t, "RemoveNode of the root", n[0].RemoveNode(), f.m.remove_node(n[0]))
+	f.must_check(t, "RemoveNode of the root")
+
+	must_equal(t, "RemoveNode of a lone node", n[0].RemoveNode(), f.m.remove_node(n[0]))
+	f.must_check(t, "RemoveNode of a lone node")
+}
+
+// test_cleanup checks that Cleanup detaches a node from its parent and from its
+// children, and returns the latter.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_cleanup(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	must_equal(t, "Cleanup of a middle node", n[1].Cleanup(), f.m.cleanup(n[1]))
+	f.must_check(t, "Cleanup of a middle node")
+
+	must_equal(t, "Cleanup of the root", n[0].Cleanup(), f.m.cleanup(n[0]))
+	f.must_check(t, "Cleanup of the root")
+
+	must_equal(t, "Cleanup of a lone node", n[0].Cleanup(), f.m.cleanup(n[0]))
+	f.must_check(t, "Cleanup of a lone node")
+}
+
+// test_get_parent checks that GetParent reports the parent of a child and no parent
+// for the root.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_get_parent(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	parent, ok := n[3].GetParent()
+	if !ok || parent != n[1] {
+		t.Fatalf("GetParent() of a child = (%s, %t), want (%s, true)", str(parent), ok, n[1])
+	}
+
+	parent, ok = n[0].GetParent()
+	if ok {
+		t.Fatalf("GetParent() of the root = (%s, true), want (<nil>, false)", str(parent))
+	}
+
+	f.must_check(t, "GetParent")
+}
+
+// test_get_first_child checks that GetFirstChild reports the first child of a node
+// and no child for a leaf.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_get_first_child(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	first, ok := n[0].GetFirstChild()
+	if !ok || first != n[1] {
+		t.Fatalf("GetFirstChild() of the root = (%s, %t), want (%s, true)", str(first), ok, n[1])
+	}
+
+	first, ok = n[6].GetFirstChild()
+	if ok {
+		t.Fatalf("GetFirstChild() of a leaf = (%s, true), want (<nil>, false)", str(first))
+	}
+
+	f.must_check(t, "GetFirstChild")
+}
+
+// test_has_child checks that HasChild only reports direct children.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_has_child(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	if !n[0].HasChild(n[5]) {
+		t.Fatalf("HasChild() of the last child = false, want true")
+	}
+
+	if n[0].HasChild(n[6]) {
+		t.Fatalf("HasChild() of a grandchild = true, want false")
+	}
+
+	f.must_check(t, "HasChild")
+}
+
+// test_is_child_of checks that IsChildOf reports every ancestor of a node but
+// neither its uncles nor the node itself.
+//
+// Parameters:
+//   - t: The test.
+func (s Suite[T]) test_is_child_of(t *testing.T) {
+	f := s.new_fixture(7)
+	n := f.build()
+
+	if !n[6].IsChildOf(n[0]) {
+		t.Fatalf("IsChildOf() of the root = false, want true")
+	}
+
+	if n[6].IsChildOf(n[1]) {
+		t.Fatalf("IsChildOf() of an uncle = true, want false")
+	}
+
+	if n[6].IsChildOf(n[6]) {
+		t.Fatalf("IsChildOf() of itself = true, want false")
+	}
+
+	f.must_check(t, "IsChildOf")
+}
+
+// test_random applies random sequences of operations to both the nodes and the
+// reference model, and checks that they agree after every operation.
+//
+// Parameters:
+//   - t: The test.
+//
+// The test is skipped if the suite disables the randomized sequences.
+func (s Suite[T]) test_random(t *testing.T) {
+	if s.Nodes < 2 || s.Steps <= 0 || s.Rounds <= 0 {
+		t.Skip("treetest: randomized sequences are disabled")
+	}
+
+	for round := 0; round < s.Rounds; round++ {
+		seed := s.Seed + int64(round)
+		rng := rand.New(rand.NewSource(seed))
+
+		f := s.new_fixture(s.Nodes)
+
+		var history []string
+
+		for step := 0; step < s.Steps; step++ {
+			desc, err := f.random_step(rng)
+			history = append(history, desc)
+
+			if err == nil {
+				err = f.check()
+			}
+
+			if err != nil {
+				t.Fatalf("seed %d, step %d: %s\nhistory:\n\t%s", seed, step, err.Error(), strings.Join(history, "\n\t"))
+			}
+		}
+	}
+}
+
+// random_step applies a random operation that is valid with respect to the
+// documented preconditions of the node.
+//
+// Parameters:
+//   - rng: The random number generator.
+//
+// Returns:
+//   - string: The description of the operation.
+//   - error: An error if the operation returned unexpected nodes.
+func (f *fixture[T]) random_step(rng *rand.Rand) (string, error) {
+	n := f.nodes
+	node := n[rng.Intn(len(n))]
+
+	// detached returns the roots that can be attached under node without creating
+	// a cycle.
+	detached := func() []T {
+		var roots []T
+
+		node_root := f.m.root_of(node)
+
+		for _, other := range n {
+			_, ok := f.m.parent_of(other)
+			if !ok && other != node_root {
+				roots = append(roots, other)
+			}
+		}
+
+		return roots
+	}
+
+	switch rng.Intn(6) {
+	case 0:
+		roots := detached()
+		if len(roots) == 0 {
+			return "nothing", nil
+		}
+
+		target := roots[rng.Intn(len(roots))]
+
+		node.AddChild(target)
+		f.m.add_child(node, target)
+
+		return fmt.Sprintf("%s.AddChild(%s)", node, target), nil
+	case 1:
+		roots := detached()
+		rng.Shuffle(len(roots), func(i, j int) { roots[i], roots[j] = roots[j], roots[i] })
+
+		roots = roots[:rng.Intn(len(roots)+1)]
+		desc := fmt.Sprintf("%s.AddChildren(%s)", node, strs(roots))
+
+		node.AddChildren(slices.Clone(roots))
+
+		for _, root := range roots {
+			f.m.add_child(node, root)
+		}
+
+		return desc, nil
+	case 2:
+		if len(f.m.children[node]) != 0 {
+			return "nothing", nil
+		}
+
+		roots := detached()
+		rng.Shuffle(len(roots), func(i, j int) { roots[i], roots[j] = roots[j], roots[i] })
+
+		roots = roots[:rng.Intn(len(roots)+1)]
+
+		node.LinkChildren(roots)
+		f.m.link_children(node, roots)
+
+		return fmt.Sprintf("%s.LinkChildren(%s)", node, strs(roots)), nil
+	case 3:
+		target := n[rng.Intn(len(n))]
+		desc := fmt.Sprintf("%s.DeleteChild(%s)", node, target)
+
+		got := node.DeleteChild(target)
+		want := f.m.delete_child(node, target)
+
+		return desc, compare(desc, got, want)
+	case 4:
+		desc := fmt.Sprintf("%s.RemoveNode()", node)
+
+		got := node.RemoveNode()
+		want := f.m.remove_node(node)
+
+		return desc, compare(desc, got, want)
+	default:
+		desc := fmt.Sprintf("%s.Cleanup()", node)
+
+		got := node.Cleanup()
+		want := f.m.cleanup(node)
+
+		return desc, compare(desc, got, want)
+	}
+}
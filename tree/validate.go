@@ -0,0 +1,326 @@
+package tree
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Links are the pointers of a first-child/next-sibling node. Missing pointers
+// are the zero value of T.
+type Links[T TreeNoder] struct {
+	// Parent is the parent of the node.
+	Parent T
+
+	// FirstChild is the first child of the node.
+	FirstChild T
+
+	// NextSibling is the next sibling of the node.
+	NextSibling T
+
+	// LastChild is the last child of the node.
+	LastChild T
+
+	// PrevSibling is the previous sibling of the node.
+	PrevSibling T
+}
+
+// Violation is a broken invariant of a tree.
+type Violation struct {
+	// Path is the sibling index of every node from the root (excluded) to the
+	// offending node (included). Empty for the root.
+	Path []int
+
+	// Node is the string representation of the offending node.
+	Node string
+
+	// Reason is the invariant that was broken.
+	Reason string
+}
+
+// String implements the fmt.Stringer interface.
+//
+// Format:
+//
+//	/<index>/<index> (<node>): <reason>
+func (v Violation) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(format_path(v.Path))
+	builder.WriteString(" (")
+	builder.WriteString(v.Node)
+	builder.WriteString("): ")
+	builder.WriteString(v.Reason)
+
+	return builder.String()
+}
+
+// format_path is a helper function that formats a path of sibling indices.
+//
+// Parameters:
+//   - path: The path to format.
+//
+// Returns:
+//   - string: The formatted path. "/" if the path is empty.
+func format_path(path []int) string {
+	if len(path) == 0 {
+		return "/"
+	}
+
+	var builder strings.Builder
+
+	for _, idx := range path {
+		builder.WriteRune('/')
+		builder.WriteString(strconv.Itoa(idx))
+	}
+
+	return builder.String()
+}
+
+// validator is the helper that collects the violations of a tree.
+type validator[T interface {
+	Links() Links[T]
+	TreeNoder
+}] struct {
+	// violations are the violations found so far.
+	violations []Violation
+
+	// seen are the nodes that have been reached so far.
+	seen visited[T]
+
+	// size is the number of distinct nodes reached so far.
+	size int
+
+	// leaves are the leaves reached so far.
+	leaves []T
+
+	// paths are the paths of the nodes reached so far.
+	paths map[T][]int
+}
+
+// report adds a violation.
+//
+// Parameters:
+//   - path: The path of the offending node.
+//   - node: The offending node.
+//   - reason: The invariant that was broken.
+func (v *validator[T]) report(path []int, node T, reason string) {
+	v.violations = append(v.violations, Violation{
+		Path:   path,
+		Node:   node.String(),
+		Reason: reason,
+	})
+}
+
+// run walks the subtree rooted at the given node and checks every invariant
+// reachable from it.
+//
+// Parameters:
+//   - root: The root of the subtree.
+//
+// This function does not use recursion and is safe to use on very deep trees.
+func (v *validator[T]) run(root T) {
+	type StackElement struct {
+		node T
+		path []int
+	}
+
+	var zero T
+
+	_ = v.seen.mark(root)
+
+	stack := []StackElement{{node: root}}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node, path := top.node, top.path
+		links := node.Links()
+
+		v.size++
+		v.paths[node] = path
+
+		if (links.FirstChild == zero) != (links.LastChild == zero) {
+			v.report(path, node, "exactly one of FirstChild and LastChild is set")
+		}
+
+		if node.IsLeaf() != (links.FirstChild == zero) {
+			v.report(path, node, "IsLeaf disagrees with FirstChild")
+		}
+
+		if links.FirstChild == zero {
+			v.leaves = append(v.leaves, node)
+			continue
+		}
+
+		if links.FirstChild.Links().PrevSibling != zero {
+			v.report(append(path[:len(path):len(path)], 0), links.FirstChild, "first child has a PrevSibling")
+		}
+
+		if links.LastChild != zero && links.LastChild.Links().NextSibling != zero {
+			v.report(path, node, "LastChild has a NextSibling")
+		}
+
+		var children []StackElement
+
+		chain := make(visited[T])
+		prev := zero
+		last := zero
+
+		for child, idx := links.FirstChild, 0; child != zero; idx++ {
+			child_path := append(path[:len(path):len(path)], idx)
+
+			err := chain.mark(child)
+			if err != nil {
+				v.report(child_path, child, "sibling chain loops back on itself")
+				break
+			}
+
+			child_links := child.Links()
+
+			if child_links.Parent != node {
+				v.report(child_path, child, "Parent does not point to the node that lists it as a child")
+			}
+
+			if child_links.PrevSibling != prev {
+				v.report(child_path, child, "PrevSibling does not point to the previous sibling")
+			}
+
+			err = v.seen.mark(child)
+			if err != nil {
+				v.report(child_path, child, "node is reachable more than once (cycle)")
+			} else {
+				children = append(children, StackElement{node: child, path: child_path})
+			}
+
+			prev = child
+			last = child
+			child = child_links.NextSibling
+		}
+
+		if last != links.LastChild {
+			v.report(path, node, "LastChild is not reachable by following NextSibling from FirstChild")
+		}
+
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+}
+
+// ValidateNode checks every invariant of the first-child/next-sibling pointers of
+// the subtree rooted at the given node. Namely:
+//   - FirstChild and LastChild are either both set or both missing.
+//   - IsLeaf agrees with FirstChild.
+//   - The first child has no PrevSibling and the last child has no NextSibling.
+//   - Every child points back to its parent and to its previous sibling.
+//   - LastChild is reachable by following NextSibling from FirstChild.
+//   - No node is reachable more than once (i.e., there are no cycles).
+//
+// Parameters:
+//   - node: The root of the subtree to validate.
+//
+// Returns:
+//   - error: An error of type *ErrInvalidTree with every violation found. Nil if
+//     the subtree is valid.
+func ValidateNode[T interface {
+	Links() Links[T]
+	TreeNoder
+}](node T) error {
+	v := &validator[T]{
+		seen:  make(visited[T]),
+		paths: make(map[T][]int),
+	}
+
+	v.run(node)
+
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return NewErrInvalidTree(v.violations)
+}
+
+// Validate works like ValidateNode on the root of the tree but it also checks
+// that the root has neither a parent nor siblings and that the cached size and
+// leaves of the tree match the actual ones.
+//
+// Parameters:
+//   - tree: The tree to validate.
+//
+// Returns:
+//   - error: An error of type *ErrInvalidTree with every violation found. Nil if
+//     the tree is valid or nil.
+func Validate[T interface {
+	BackwardChild() iter.Seq[T]
+	Child() iter.Seq[T]
+	Cleanup() []T
+	Copy() T
+	LinkChildren(children []T)
+	Links() Links[T]
+	TreeNoder
+}](tree *Tree[T]) error {
+	if tree == nil {
+		return nil
+	}
+
+	var zero T
+
+	v := &validator[T]{
+		seen:  make(visited[T]),
+		paths: make(map[T][]int),
+	}
+
+	root := tree.root
+	links := root.Links()
+
+	if links.Parent != zero {
+		v.report(nil, root, "root has a Parent")
+	}
+
+	if links.PrevSibling != zero || links.NextSibling != zero {
+		v.report(nil, root, "root has siblings")
+	}
+
+	v.run(root)
+
+	if tree.size != v.size {
+		v.report(nil, root, "cached size is "+strconv.Itoa(tree.size)+" but the tree has "+strconv.Itoa(v.size)+" nodes")
+	}
+
+	actual := make(map[T]struct{}, len(v.leaves))
+	for _, leaf := range v.leaves {
+		actual[leaf] = struct{}{}
+	}
+
+	cached := make(map[T]struct{}, len(tree.leaves))
+
+	for _, leaf := range tree.leaves {
+		_, ok := cached[leaf]
+		if ok {
+			v.report(v.paths[leaf], leaf, "cached leaf is listed more than once")
+			continue
+		}
+
+		cached[leaf] = struct{}{}
+
+		_, ok = actual[leaf]
+		if !ok {
+			v.report(v.paths[leaf], leaf, "cached leaf is not a leaf of the tree")
+		}
+	}
+
+	for _, leaf := range v.leaves {
+		_, ok := cached[leaf]
+		if !ok {
+			v.report(v.paths[leaf], leaf, "leaf is missing from the cached leaves")
+		}
+	}
+
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return NewErrInvalidTree(v.violations)
+}
@@ -0,0 +1,132 @@
+package tree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	root "github.com/PlayerR9/tree"
+	"github.com/PlayerR9/tree/tree"
+)
+
+func TestValidate(t *testing.T) {
+	err := tree.Validate(sample_tree())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tree.Validate[*root.StringNode](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(tr *tree.Tree[*root.StringNode])
+		want    string
+	}{
+		{
+			name: "wrong parent",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().FirstChild.FirstChild.Parent = tr.Root()
+			},
+			want: `/0/0 (StringNode[d]): Parent does not point`,
+		},
+		{
+			name: "wrong previous sibling",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().LastChild.PrevSibling = nil
+			},
+			want: `/1 (StringNode[c]): PrevSibling does not point`,
+		},
+		{
+			name: "unreachable last child",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				b := tr.Root().FirstChild
+				b.LastChild = root.NewStringNode("x")
+			},
+			want: `/0 (StringNode[b]): LastChild is not reachable`,
+		},
+		{
+			name: "missing last child",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().LastChild.LastChild = nil
+			},
+			want: `/1 (StringNode[c]): exactly one of FirstChild and LastChild is set`,
+		},
+		{
+			name: "root with a parent",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().Parent = root.NewStringNode("x")
+			},
+			want: `/ (StringNode[a]): root has a Parent`,
+		},
+		{
+			name: "cycle",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				f := tr.Root().LastChild.FirstChild
+				f.FirstChild, f.LastChild = tr.Root(), tr.Root()
+			},
+			want: `/1/0/0 (StringNode[a]): node is reachable more than once`,
+		},
+		{
+			name: "stale size",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().LastChild.AddChild(root.NewStringNode("g"))
+			},
+			want: `cached size is 6 but the tree has 7 nodes`,
+		},
+		{
+			name: "stale leaves",
+			corrupt: func(tr *tree.Tree[*root.StringNode]) {
+				tr.Root().LastChild.FirstChild.AddChild(root.NewStringNode("g"))
+			},
+			want: `/1/0 (StringNode[f]): cached leaf is not a leaf of the tree`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := sample_tree()
+			tt.corrupt(tr)
+
+			err := tree.Validate(tr)
+
+			var invalid *tree.ErrInvalidTree
+
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %v, want an *ErrInvalidTree", err)
+			}
+
+			var found bool
+
+			for _, v := range invalid.Violations {
+				if strings.Contains(v.String(), tt.want) {
+					found = true
+				}
+			}
+
+			if !found {
+				t.Errorf("got %v, want a violation %q", invalid.Violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNode(t *testing.T) {
+	tr := sample_tree()
+	b := tr.Root().FirstChild
+
+	// A subtree is valid on its own even though its root has a parent.
+	err := tree.ValidateNode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.FirstChild.NextSibling.NextSibling = b.FirstChild
+
+	err = tree.ValidateNode(b)
+	if !strings.Contains(error_chain(err), "sibling chain loops back on itself") {
+		t.Errorf("got %v, want a sibling loop", err)
+	}
+}